@@ -24,30 +24,79 @@ func main() {
 		logger.Get().Fatal("Failed prepare config", zap.Error(err))
 	}
 
+	if cfg.MigrateOnly {
+		logger.Get().Info("Running in migrate-only mode")
+		dbs, err := server.NewDBStorage(cfg.GetDBConfig())
+		if err != nil {
+			logger.Get().Fatal("Failed to apply migrations", zap.Error(err))
+		}
+		dbs.ShutDown()
+		return
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer stop()
 
-	store := server.NewStorageFromConfig(cfg)
+	store, err := server.NewStorageFromConfig(cfg)
+	if err != nil {
+		logger.Get().Fatal("Failed to init storage", zap.Error(err))
+	}
+	store = server.NewInstrumentedStorage(store)
 
 	logger.Get().Info("Starting server",
 		zap.String("ServerAddress", cfg.ServerAddress),
 		zap.String("ServerGRPCAddress", cfg.ServerGRPCAddress),
+		zap.String("AdminAddress", cfg.AdminAddress),
 		zap.Uint("StoreInterval", cfg.StoreInterval),
 		zap.String("FileStoragePath", cfg.FileStoragePath),
 		zap.Bool("Restore", cfg.Restore),
 		zap.String("CryptoKey", cfg.CryptoKey),
 		zap.String("ConfigFile", cfg.ConfigFile),
 		zap.String("TrustedSubnet", cfg.TrustedSubnet),
+		zap.Uint("HistorySize", cfg.HistorySize),
+		zap.String("HistoryRetention", cfg.HistoryRetention),
+		zap.Uint("MetricTTL", cfg.MetricTTL),
+		zap.String("WALPath", cfg.WALPath),
+		zap.String("BoltPath", cfg.BoltPath),
+		zap.Uint("CacheSize", cfg.CacheSize),
+		zap.Uint("DBMaxConns", cfg.DBMaxConns),
+		zap.Uint("DBMinConns", cfg.DBMinConns),
+		zap.Uint("DBStmtTimeout", cfg.DBStmtTimeout),
+		zap.String("DBConnectBackoff", cfg.DBConnectBackoff),
+		zap.String("AlertRulesFile", cfg.AlertRulesFile),
+		zap.Int("AlertRules", len(cfg.GetAlertRules())),
+		zap.Uint("AlertInterval", cfg.AlertInterval),
+		zap.String("AlertWebhooks", cfg.AlertWebhooks),
+		zap.String("AlertOutboxPath", cfg.AlertOutboxPath),
+		zap.String("StatsDAddress", cfg.StatsDAddress),
+		zap.Uint("StatsDFlushInterval", cfg.StatsDFlushInterval),
+		zap.String("CarbonAddress", cfg.CarbonAddress),
+		zap.Int("CarbonMappings", len(cfg.GetCarbonMappings())),
+		zap.Uint("ShutdownDrainDelay", cfg.ShutdownDrainDelay),
 	)
 
+	health := server.NewHealth(store)
+
+	alerts := server.NewAlertEngine(store, cfg.GetAlertRules())
+	var notifier *server.AlertNotifier
+	if webhooks := cfg.GetAlertWebhooks(); len(webhooks) > 0 {
+		notifier, err = server.NewAlertNotifier(webhooks, cfg.HashKey, cfg.AlertOutboxPath)
+		if err != nil {
+			logger.Get().Fatal("Failed to init alert notifier", zap.Error(err))
+		}
+		notifier.Start()
+		alerts.SetNotifier(notifier)
+	}
+	alerts.Start(cfg.AlertInterval)
+
 	// create http
-	router := server.NewRouter(store, cfg)
+	router := server.NewRouter(store, cfg, health, alerts)
 	srv := &http.Server{
 		Addr:    cfg.ServerAddress,
 		Handler: router,
 	}
 
-	serverErrChan := make(chan error, 2)
+	serverErrChan := make(chan error, 6)
 
 	// start http
 	go func() {
@@ -64,7 +113,7 @@ func main() {
 	// start grpc
 	var grpcServer *grpc.Server
 	if cfg.ServerGRPCAddress != "" {
-		grpcServer, err = server.StartGRPCServer(store, logger.Get(), cfg, serverErrChan)
+		grpcServer, err = server.StartGRPCServer(store, health, alerts, logger.Get(), cfg, serverErrChan)
 		if err != nil {
 			logger.Get().Fatal("Failed to start gRPC server", zap.Error(err))
 		}
@@ -72,6 +121,42 @@ func main() {
 		logger.Get().Info("gRPC server is disabled (no address configured)")
 	}
 
+	// start admin
+	var adminServer *http.Server
+	if cfg.AdminAddress != "" {
+		adminServer, err = server.StartAdminServer(store, cfg, serverErrChan)
+		if err != nil {
+			logger.Get().Fatal("Failed to start admin server", zap.Error(err))
+		}
+	} else {
+		logger.Get().Info("Admin server is disabled (no address configured)")
+	}
+
+	// start statsd
+	var statsdServer *server.StatsDServer
+	if cfg.StatsDAddress != "" {
+		statsdServer, err = server.StartStatsDServer(store, cfg, serverErrChan)
+		if err != nil {
+			logger.Get().Fatal("Failed to start StatsD server", zap.Error(err))
+		}
+	} else {
+		logger.Get().Info("StatsD server is disabled (no address configured)")
+	}
+
+	// start carbon
+	var carbonServer *server.GraphiteServer
+	if cfg.CarbonAddress != "" {
+		carbonServer, err = server.StartGraphiteServer(store, cfg, serverErrChan)
+		if err != nil {
+			logger.Get().Fatal("Failed to start carbon server", zap.Error(err))
+		}
+	} else {
+		logger.Get().Info("Carbon server is disabled (no address configured)")
+	}
+
+	// The storage is restored and migrated, accept traffic.
+	health.SetServing()
+
 	select {
 	case <-ctx.Done():
 		logger.Get().Info("Received shutdown signal")
@@ -83,6 +168,12 @@ func main() {
 
 	logger.Get().Info("Shutting down servers...")
 
+	// Fail readiness first and keep the listeners open for the drain delay,
+	// so load balancers see /readyz and the gRPC health service fail and
+	// stop sending traffic before the listeners close.
+	logger.Get().Info("Draining before shutdown", zap.Duration("delay", cfg.GetShutdownDrainDelay()))
+	health.Drain(cfg.GetShutdownDrainDelay())
+
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 
@@ -95,6 +186,19 @@ func main() {
 	// shutdown grpc
 	server.StopGRPCServer(grpcServer, shutdownCtx)
 
+	// shutdown admin
+	server.StopAdminServer(adminServer, shutdownCtx)
+
+	// shutdown statsd
+	server.StopStatsDServer(statsdServer, shutdownCtx)
+
+	// shutdown carbon
+	server.StopGraphiteServer(carbonServer, shutdownCtx)
+
+	alerts.Stop()
+	if notifier != nil {
+		notifier.Stop()
+	}
 	store.ShutDown()
 	logger.Get().Info("Server(s) stopped")
 
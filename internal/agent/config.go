@@ -23,8 +23,10 @@ type config struct {
 	RateLimit      uint   `env:"RATE_LIMIT" json:"-"`
 	CryptoKey      string `env:"CRYPTO_KEY" json:"crypto_key"`
 	ConfigFile     string `env:"CONFIG" json:"-"`
+	Instance       string `env:"INSTANCE" json:"instance"`
 	publicKey      *rsa.PublicKey
 	localIP        net.IP
+	labels         map[string]string
 	mu             sync.RWMutex
 }
 
@@ -45,6 +47,35 @@ func (c *config) getLocalIP() net.IP {
 	return c.localIP
 }
 
+// getLabels returns labels attached to every reported metric. The instance
+// label defaults to the host name, set Instance to tell apart several agents
+// running on the same host.
+func (c *config) getLabels() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.labels != nil {
+		return c.labels
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		logger.Get().Error("Failed to get hostname", zap.Error(err))
+		host = "unknown"
+	}
+
+	instance := c.Instance
+	if instance == "" {
+		instance = host
+	}
+
+	c.labels = map[string]string{
+		labelHost:     host,
+		labelInstance: instance,
+	}
+	return c.labels
+}
+
 func (c *config) GetServerEndpoint() string {
 	return c.ServerEndpoint
 }
@@ -112,6 +143,7 @@ func parseFlags(cfg *config) {
 	flag.StringVar(&cfg.HashKey, "k", cfg.HashKey, "Hash key")
 	flag.UintVar(&cfg.RateLimit, "l", cfg.RateLimit, "Rate limit ")
 	flag.StringVar(&cfg.CryptoKey, "crypto-key", cfg.CryptoKey, "Crypto key")
+	flag.StringVar(&cfg.Instance, "instance", cfg.Instance, "instance label value (defaults to hostname)")
 	flag.StringVar(&cfg.ConfigFile, "c", "", "Config file path")
 	flag.StringVar(&cfg.ConfigFile, "config", "", "Config file path")
 	flag.Parse()
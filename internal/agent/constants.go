@@ -3,3 +3,8 @@ package agent
 const defaultServerEndpointProtocol = "http"
 
 const maxRandNum int = 1_000_000
+
+const (
+	labelHost     = "host"
+	labelInstance = "instance"
+)
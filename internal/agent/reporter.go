@@ -27,6 +27,11 @@ func (r *Reporter) report(ctx context.Context) {
 		return
 	}
 
+	labels := r.cfg.getLabels()
+	for i := range metrics {
+		metrics[i].Labels = labels
+	}
+
 	err := r.metricClient.SendBatch(ctx, metrics)
 	if err != nil {
 		logger.Get().Error("Error sending metrics", zap.Error(err))
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -102,7 +103,11 @@ func TestReporter_WithRealStats(t *testing.T) {
 
 		assert.Equal(t, uint(1), reporter.iteration)
 		assert.Equal(t, 1, len(mockClient.sendBatchCalls))
-		assert.Equal(t, testMetrics(), mockClient.sendBatchCalls[0].metrics)
+		expected := testMetrics()
+		for i := range expected {
+			expected[i].Labels = cfg.getLabels()
+		}
+		assert.Equal(t, expected, mockClient.sendBatchCalls[0].metrics)
 	})
 
 	t.Run("report without collection - no metrics", func(t *testing.T) {
@@ -153,7 +158,8 @@ func TestReporter_WithRealCollectors(t *testing.T) {
 			collectors: []Collecter{NewAnyCollector()},
 		}
 
-		reporter := newReporter(stats, &config{ReportInterval: 10}, mockClient)
+		cfg := &config{ReportInterval: 10}
+		reporter := newReporter(stats, cfg, mockClient)
 
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
@@ -176,6 +182,9 @@ func TestReporter_WithRealCollectors(t *testing.T) {
 
 		assert.Equal(t, uint(1), reporter.iteration)
 		assert.Equal(t, 1, len(mockClient.sendBatchCalls))
+		for i := range metrics {
+			metrics[i].Labels = cfg.getLabels()
+		}
 		assert.Equal(t, metrics, mockClient.sendBatchCalls[0].metrics)
 	})
 
@@ -277,3 +286,27 @@ func TestReporter_Integration(t *testing.T) {
 		}
 	})
 }
+
+func TestReporter_Labels(t *testing.T) {
+	mockClient := &mockMetricClient{}
+
+	stats := &Stats{
+		mu:         &sync.RWMutex{},
+		collectors: []Collecter{&testCollector{metrics: testMetrics()}},
+	}
+
+	cfg := &config{ReportInterval: 10, Instance: "agent-1"}
+	reporter := newReporter(stats, cfg, mockClient)
+
+	require.NoError(t, stats.collect(context.Background()))
+	reporter.report(context.Background())
+
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	require.Equal(t, 1, len(mockClient.sendBatchCalls))
+	for _, m := range mockClient.sendBatchCalls[0].metrics {
+		assert.Equal(t, map[string]string{"host": host, "instance": "agent-1"}, m.Labels)
+	}
+	assert.Nil(t, stats.GetMetrics()[0].Labels)
+}
@@ -1,8 +1,10 @@
 package common
 
 const (
-	MetricTypeGauge   = "gauge"
-	MetricTypeCounter = "counter"
+	MetricTypeGauge     = "gauge"
+	MetricTypeCounter   = "counter"
+	MetricTypeHistogram = "histogram"
+	MetricTypeSummary   = "summary"
 )
 
 const HashHeaderKey = "HashSHA256"
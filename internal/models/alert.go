@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// AlertState is the state of an alert.
+type AlertState string
+
+const (
+	// AlertStatePending is an alert whose condition holds for less than
+	// the duration the rule requires.
+	AlertStatePending AlertState = "pending"
+	// AlertStateFiring is an alert whose condition holds long enough.
+	AlertStateFiring AlertState = "firing"
+	// AlertStateResolved is a firing alert whose condition stopped holding.
+	AlertStateResolved AlertState = "resolved"
+)
+
+// AlertAck records that someone took care of an alert.
+type AlertAck struct {
+	Author  string    `json:"author"`
+	Comment string    `json:"comment,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Alert is the state of an alerting rule whose condition holds or held.
+// ActiveAt is when the condition started to hold, FiredAt and ResolvedAt
+// are set once the alert fired and resolved. SilencedBy holds the IDs of
+// silences muting the alert. Acknowledged is set once the alert was
+// acknowledged, a new activation of the rule starts unacknowledged.
+type Alert struct {
+	Rule         string            `json:"rule"`
+	Expr         string            `json:"expr"`
+	State        AlertState        `json:"state"`
+	Value        float64           `json:"value"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	ActiveAt     time.Time         `json:"active_at"`
+	FiredAt      *time.Time        `json:"fired_at,omitempty"`
+	ResolvedAt   *time.Time        `json:"resolved_at,omitempty"`
+	Silenced     bool              `json:"silenced"`
+	SilencedBy   []string          `json:"silenced_by,omitempty"`
+	Acknowledged *AlertAck         `json:"acknowledged,omitempty"`
+}
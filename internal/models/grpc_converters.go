@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/etoneja/go-metrics/internal/common"
 	"github.com/etoneja/go-metrics/internal/proto"
@@ -39,8 +40,9 @@ func MetricModelsFromGRPC(grpcMetrics []*proto.Metric) ([]MetricModel, error) {
 
 func MetricModelToGRPC(appMetric MetricModel) (*proto.Metric, error) {
 	grpcMetric := &proto.Metric{
-		Id:   appMetric.ID,
-		Type: appMetric.MType,
+		Id:     appMetric.ID,
+		Type:   appMetric.MType,
+		Labels: appMetric.Labels,
 	}
 
 	switch appMetric.MType {
@@ -56,6 +58,29 @@ func MetricModelToGRPC(appMetric MetricModel) (*proto.Metric, error) {
 		}
 		grpcMetric.Value = appMetric.Value
 
+	case common.MetricTypeHistogram:
+		if appMetric.Histogram == nil {
+			return nil, fmt.Errorf("histogram metric %s has nil histogram", appMetric.ID)
+		}
+		grpcMetric.Histogram = &proto.Histogram{
+			Bounds: appMetric.Histogram.Bounds,
+			Counts: appMetric.Histogram.Counts,
+			Sum:    appMetric.Histogram.Sum,
+		}
+
+	case common.MetricTypeSummary:
+		if len(appMetric.Observations) == 0 && appMetric.Summary == nil {
+			return nil, fmt.Errorf("summary metric %s has neither observations nor summary", appMetric.ID)
+		}
+		grpcMetric.Observations = appMetric.Observations
+		if appMetric.Summary != nil {
+			grpcMetric.Summary = &proto.Summary{
+				Count:     appMetric.Summary.Count,
+				Sum:       appMetric.Summary.Sum,
+				Quantiles: appMetric.Summary.Quantiles,
+			}
+		}
+
 	default:
 		return nil, fmt.Errorf("unknown metric type: %s", appMetric.MType)
 	}
@@ -66,6 +91,10 @@ func MetricModelToGRPC(appMetric MetricModel) (*proto.Metric, error) {
 func MetricModelFromGRPC(grpcMetric *proto.Metric) (MetricModel, error) {
 	var appMetric MetricModel
 
+	if err := ValidateID(grpcMetric.Id); err != nil {
+		return MetricModel{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	switch grpcMetric.Type {
 	case common.MetricTypeGauge:
 		if grpcMetric.Value == nil {
@@ -81,10 +110,120 @@ func MetricModelFromGRPC(grpcMetric *proto.Metric) (MetricModel, error) {
 		}
 		appMetric = *NewMetricModel(grpcMetric.Id, grpcMetric.Type, *grpcMetric.Delta, 0)
 
+	case common.MetricTypeHistogram:
+		if grpcMetric.Histogram == nil {
+			return MetricModel{}, status.Error(codes.InvalidArgument,
+				fmt.Sprintf("histogram metric %s has nil histogram", grpcMetric.Id))
+		}
+		h := &Histogram{
+			Bounds: grpcMetric.Histogram.Bounds,
+			Counts: grpcMetric.Histogram.Counts,
+			Sum:    grpcMetric.Histogram.Sum,
+		}
+		if err := h.Validate(); err != nil {
+			return MetricModel{}, status.Error(codes.InvalidArgument,
+				fmt.Sprintf("histogram metric %s is invalid: %v", grpcMetric.Id, err))
+		}
+		appMetric = *NewHistogramMetricModel(grpcMetric.Id, h)
+
+	case common.MetricTypeSummary:
+		if len(grpcMetric.Observations) == 0 {
+			return MetricModel{}, status.Error(codes.InvalidArgument,
+				fmt.Sprintf("summary metric %s has no observations", grpcMetric.Id))
+		}
+		if err := ValidateObservations(grpcMetric.Observations); err != nil {
+			return MetricModel{}, status.Error(codes.InvalidArgument,
+				fmt.Sprintf("summary metric %s is invalid: %v", grpcMetric.Id, err))
+		}
+		appMetric = MetricModel{
+			ID:           grpcMetric.Id,
+			MType:        grpcMetric.Type,
+			Observations: grpcMetric.Observations,
+		}
+
 	default:
 		return MetricModel{}, status.Error(codes.InvalidArgument,
 			fmt.Sprintf("unknown metric type: %s", grpcMetric.Type))
 	}
 
+	if len(grpcMetric.Labels) > 0 {
+		if err := ValidateLabels(grpcMetric.Labels); err != nil {
+			return MetricModel{}, status.Error(codes.InvalidArgument,
+				fmt.Sprintf("metric %s has bad labels: %v", grpcMetric.Id, err))
+		}
+		appMetric.Labels = grpcMetric.Labels
+	}
+
 	return appMetric, nil
 }
+
+func AlertsToGRPC(alerts []Alert) []*proto.Alert {
+	grpcAlerts := make([]*proto.Alert, 0, len(alerts))
+
+	for _, alert := range alerts {
+		grpcAlert := &proto.Alert{
+			Rule:     alert.Rule,
+			Expr:     alert.Expr,
+			State:    string(alert.State),
+			Value:    alert.Value,
+			Labels:   alert.Labels,
+			ActiveAt: alert.ActiveAt.UnixMilli(),
+		}
+		if alert.FiredAt != nil {
+			grpcAlert.FiredAt = alert.FiredAt.UnixMilli()
+		}
+		if alert.ResolvedAt != nil {
+			grpcAlert.ResolvedAt = alert.ResolvedAt.UnixMilli()
+		}
+		grpcAlert.Silenced = alert.Silenced
+		grpcAlert.SilencedBy = alert.SilencedBy
+		if alert.Acknowledged != nil {
+			grpcAlert.Acknowledged = &proto.AlertAck{
+				Author:  alert.Acknowledged.Author,
+				Comment: alert.Acknowledged.Comment,
+				At:      alert.Acknowledged.At.UnixMilli(),
+			}
+		}
+		grpcAlerts = append(grpcAlerts, grpcAlert)
+	}
+
+	return grpcAlerts
+}
+
+func SilencesToGRPC(silences []Silence) []*proto.Silence {
+	grpcSilences := make([]*proto.Silence, 0, len(silences))
+
+	for _, silence := range silences {
+		grpcSilences = append(grpcSilences, SilenceToGRPC(silence))
+	}
+
+	return grpcSilences
+}
+
+func SilenceToGRPC(silence Silence) *proto.Silence {
+	return &proto.Silence{
+		Id:         silence.ID,
+		MetricId:   silence.MetricID,
+		MetricType: silence.MType,
+		StartsAt:   silence.StartsAt.UnixMilli(),
+		EndsAt:     silence.EndsAt.UnixMilli(),
+		Author:     silence.Author,
+		Comment:    silence.Comment,
+	}
+}
+
+// SilenceFromGRPC converts a silence, a zero start time is left unset.
+func SilenceFromGRPC(grpcSilence *proto.Silence) Silence {
+	silence := Silence{
+		ID:       grpcSilence.GetId(),
+		MetricID: grpcSilence.GetMetricId(),
+		MType:    grpcSilence.GetMetricType(),
+		EndsAt:   time.UnixMilli(grpcSilence.GetEndsAt()).UTC(),
+		Author:   grpcSilence.GetAuthor(),
+		Comment:  grpcSilence.GetComment(),
+	}
+	if grpcSilence.GetStartsAt() != 0 {
+		silence.StartsAt = time.UnixMilli(grpcSilence.GetStartsAt()).UTC()
+	}
+	return silence
+}
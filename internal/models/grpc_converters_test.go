@@ -156,3 +156,104 @@ func TestMetricModelFromGRPC(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricModelGRPC_Labels(t *testing.T) {
+	appMetric := MetricModel{
+		ID:     "gauge1",
+		MType:  common.MetricTypeGauge,
+		Value:  common.Float64Ptr(1.23),
+		Labels: map[string]string{"host": "web1"},
+	}
+
+	grpcMetric, err := MetricModelToGRPC(appMetric)
+	if err != nil {
+		t.Fatalf("MetricModelToGRPC failed: %v", err)
+	}
+	if grpcMetric.Labels["host"] != "web1" {
+		t.Errorf("Expected host label web1, got %v", grpcMetric.Labels)
+	}
+
+	back, err := MetricModelFromGRPC(grpcMetric)
+	if err != nil {
+		t.Fatalf("MetricModelFromGRPC failed: %v", err)
+	}
+	if back.SeriesKey() != appMetric.SeriesKey() {
+		t.Errorf("Expected series %s, got %s", appMetric.SeriesKey(), back.SeriesKey())
+	}
+
+	grpcMetric.Labels = map[string]string{"bad-name": "x"}
+	_, err = MetricModelFromGRPC(grpcMetric)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for bad label name, got %v", err)
+	}
+
+	grpcMetric.Labels = nil
+	grpcMetric.Id = `gauge1{host="web1"}`
+	_, err = MetricModelFromGRPC(grpcMetric)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for braces in id, got %v", err)
+	}
+}
+
+func TestMetricModelGRPC_Histogram(t *testing.T) {
+	h := NewHistogram([]float64{1, 2})
+	h.Observe(1.5)
+	appMetric := *NewHistogramMetricModel("latency", h)
+
+	grpcMetric, err := MetricModelToGRPC(appMetric)
+	if err != nil {
+		t.Fatalf("MetricModelToGRPC failed: %v", err)
+	}
+
+	back, err := MetricModelFromGRPC(grpcMetric)
+	if err != nil {
+		t.Fatalf("MetricModelFromGRPC failed: %v", err)
+	}
+	if back.MType != common.MetricTypeHistogram || back.Histogram.Count() != 1 || back.Histogram.Sum != 1.5 {
+		t.Errorf("Unexpected histogram after round trip: %+v", back.Histogram)
+	}
+
+	grpcMetric.Histogram = &proto.Histogram{Bounds: []float64{2, 1}, Counts: []uint64{0, 0, 0}}
+	_, err = MetricModelFromGRPC(grpcMetric)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for bad histogram, got %v", err)
+	}
+
+	grpcMetric.Histogram = nil
+	_, err = MetricModelFromGRPC(grpcMetric)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for nil histogram, got %v", err)
+	}
+}
+
+func TestMetricModelGRPC_Summary(t *testing.T) {
+	appMetric := MetricModel{ID: "latency", MType: common.MetricTypeSummary, Observations: []float64{1, 2}}
+
+	grpcMetric, err := MetricModelToGRPC(appMetric)
+	if err != nil {
+		t.Fatalf("MetricModelToGRPC failed: %v", err)
+	}
+
+	back, err := MetricModelFromGRPC(grpcMetric)
+	if err != nil {
+		t.Fatalf("MetricModelFromGRPC failed: %v", err)
+	}
+	if len(back.Observations) != 2 {
+		t.Errorf("Observations = %v, want 2 values", back.Observations)
+	}
+
+	sketch := NewQuantileSketch()
+	sketch.Add(3)
+	grpcMetric, err = MetricModelToGRPC(*NewSummaryMetricModel("latency", sketch))
+	if err != nil {
+		t.Fatalf("MetricModelToGRPC failed: %v", err)
+	}
+	if grpcMetric.Summary.GetCount() != 1 || grpcMetric.Summary.GetQuantiles()["0.5"] != 3 {
+		t.Errorf("Unexpected summary %v", grpcMetric.Summary)
+	}
+
+	_, err = MetricModelFromGRPC(&proto.Metric{Id: "latency", Type: common.MetricTypeSummary})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for summary without observations, got %v", err)
+	}
+}
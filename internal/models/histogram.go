@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ErrHistogramBoundsMismatch is returned when histograms with different
+// bucket boundaries are merged.
+var ErrHistogramBoundsMismatch = errors.New("histogram bucket bounds mismatch")
+
+// DefaultHistogramBounds are bucket boundaries suitable for latencies in seconds.
+var DefaultHistogramBounds = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram is a distribution of observed values.
+//
+// Bounds are the upper bounds of the buckets in increasing order. Counts has
+// one element more than Bounds: Counts[i] is the number of observations in
+// (Bounds[i-1], Bounds[i]], the last one counts observations above the last
+// bound. Counts are not cumulative, so histograms are merged by adding them.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []uint64  `json:"counts"`
+	Sum    float64   `json:"sum"`
+}
+
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		Bounds: slices.Clone(bounds),
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Observe adds a value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.Bounds, v)
+	h.Counts[i]++
+	h.Sum += v
+}
+
+// Count returns the total number of observations.
+func (h *Histogram) Count() uint64 {
+	var count uint64
+	for _, c := range h.Counts {
+		count += c
+	}
+	return count
+}
+
+// Validate checks that bounds are finite and increasing and there is a count
+// for every bucket.
+func (h *Histogram) Validate() error {
+	for i, b := range h.Bounds {
+		if math.IsNaN(b) || math.IsInf(b, 0) {
+			return fmt.Errorf("histogram bound %v is not finite", b)
+		}
+		if i > 0 && b <= h.Bounds[i-1] {
+			return fmt.Errorf("histogram bounds are not increasing: %v after %v", b, h.Bounds[i-1])
+		}
+	}
+	if len(h.Counts) != len(h.Bounds)+1 {
+		return fmt.Errorf("histogram has %d counts for %d bounds, want %d", len(h.Counts), len(h.Bounds), len(h.Bounds)+1)
+	}
+	if math.IsNaN(h.Sum) {
+		return errors.New("histogram sum is NaN")
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the histogram.
+func (h *Histogram) Clone() *Histogram {
+	return &Histogram{
+		Bounds: slices.Clone(h.Bounds),
+		Counts: slices.Clone(h.Counts),
+		Sum:    h.Sum,
+	}
+}
+
+// Merge adds counts and sum of other to the histogram. Both histograms must
+// have the same bounds.
+func (h *Histogram) Merge(other *Histogram) error {
+	if !slices.Equal(h.Bounds, other.Bounds) || len(h.Counts) != len(other.Counts) {
+		return fmt.Errorf("%w: %v and %v", ErrHistogramBoundsMismatch, h.Bounds, other.Bounds)
+	}
+	for i, c := range other.Counts {
+		h.Counts[i] += c
+	}
+	h.Sum += other.Sum
+	return nil
+}
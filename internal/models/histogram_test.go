@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestHistogram_Observe(t *testing.T) {
+	h := NewHistogram([]float64{1, 5})
+	for _, v := range []float64{0.5, 1, 3, 10} {
+		h.Observe(v)
+	}
+
+	if want := []uint64{2, 1, 1}; !reflect.DeepEqual(h.Counts, want) {
+		t.Errorf("Counts = %v, want %v", h.Counts, want)
+	}
+	if h.Count() != 4 {
+		t.Errorf("Count() = %d, want 4", h.Count())
+	}
+	if h.Sum != 14.5 {
+		t.Errorf("Sum = %v, want 14.5", h.Sum)
+	}
+}
+
+func TestHistogram_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       Histogram
+		wantErr bool
+	}{
+		{name: "valid", h: Histogram{Bounds: []float64{1, 2}, Counts: []uint64{0, 1, 2}}},
+		{name: "no bounds", h: Histogram{Counts: []uint64{3}}},
+		{name: "not increasing", h: Histogram{Bounds: []float64{2, 1}, Counts: []uint64{0, 0, 0}}, wantErr: true},
+		{name: "infinite bound", h: Histogram{Bounds: []float64{math.Inf(1)}, Counts: []uint64{0, 0}}, wantErr: true},
+		{name: "counts length", h: Histogram{Bounds: []float64{1}, Counts: []uint64{0}}, wantErr: true},
+		{name: "nan sum", h: Histogram{Counts: []uint64{0}, Sum: math.NaN()}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.h.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHistogram_Merge(t *testing.T) {
+	h := &Histogram{Bounds: []float64{1}, Counts: []uint64{1, 2}, Sum: 5}
+	other := &Histogram{Bounds: []float64{1}, Counts: []uint64{3, 4}, Sum: 7}
+
+	clone := h.Clone()
+	if err := h.Merge(other); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if want := []uint64{4, 6}; !reflect.DeepEqual(h.Counts, want) {
+		t.Errorf("Counts = %v, want %v", h.Counts, want)
+	}
+	if h.Sum != 12 {
+		t.Errorf("Sum = %v, want 12", h.Sum)
+	}
+	if want := []uint64{1, 2}; !reflect.DeepEqual(clone.Counts, want) {
+		t.Errorf("Clone counts changed to %v", clone.Counts)
+	}
+
+	err := h.Merge(&Histogram{Bounds: []float64{2}, Counts: []uint64{0, 0}})
+	if !errors.Is(err, ErrHistogramBoundsMismatch) {
+		t.Errorf("Expected ErrHistogramBoundsMismatch, got %v", err)
+	}
+}
+
+func TestMetricModel_JSON_Histogram(t *testing.T) {
+	jsonData := `{"id":"latency","type":"histogram","value":1,"histogram":{"bounds":[0.1,1],"counts":[1,2,3],"sum":4.5}}`
+
+	var metric MetricModel
+	if err := json.Unmarshal([]byte(jsonData), &metric); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if metric.Value != nil {
+		t.Error("Value should be nil for histogram metrics")
+	}
+	if metric.Histogram.Count() != 6 {
+		t.Errorf("Count() = %d, want 6", metric.Histogram.Count())
+	}
+
+	data, err := json.Marshal(metric)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"id":"latency","type":"histogram","histogram":{"bounds":[0.1,1],"counts":[1,2,3],"sum":4.5}}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	for _, bad := range []string{
+		`{"id":"latency","type":"histogram"}`,
+		`{"id":"latency","type":"histogram","histogram":{"bounds":[1],"counts":[1]}}`,
+	} {
+		var badMetric MetricModel
+		if err := json.Unmarshal([]byte(bad), &badMetric); err == nil {
+			t.Errorf("Expected error for %s", bad)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidateLabels checks that every label name matches [a-zA-Z_][a-zA-Z0-9_]*.
+func ValidateLabels(labels map[string]string) error {
+	for name := range labels {
+		if !isValidLabelName(name) {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	return nil
+}
+
+// ValidateID checks that a metric ID has no braces, they are reserved for
+// the label set of series keys. Otherwise the key of a metric with an ID like
+// cpu{host="a"} would collide with the key of the labelled series.
+func ValidateID(id string) error {
+	if strings.ContainsAny(id, "{}") {
+		return fmt.Errorf("invalid metric id %q: braces are reserved for labels", id)
+	}
+	return nil
+}
+
+func isValidLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// SeriesKey builds a canonical series key from a metric ID and labels.
+//
+// Metrics without labels are keyed by their ID, labelled metrics by
+// ID{name="value",...} with label names sorted, so the key does not depend
+// on the order labels were set in.
+func SeriesKey(id string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return id
+	}
+
+	var b strings.Builder
+	b.WriteString(id)
+	b.WriteByte('{')
+	for i, name := range slices.Sorted(maps.Keys(labels)) {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[name]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// ParseSeriesKey splits a key built by SeriesKey into the metric ID and labels.
+// A key without a valid label set is returned as ID with nil labels.
+func ParseSeriesKey(key string) (string, map[string]string) {
+	if !strings.HasSuffix(key, "}") {
+		return key, nil
+	}
+
+	for i := strings.IndexByte(key, '{'); i >= 0; {
+		labels, ok := parseLabelSet(key[i+1 : len(key)-1])
+		if ok {
+			return key[:i], labels
+		}
+		next := strings.IndexByte(key[i+1:], '{')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+
+	return key, nil
+}
+
+func parseLabelSet(s string) (map[string]string, bool) {
+	labels := make(map[string]string)
+	for s != "" {
+		name, rest, ok := strings.Cut(s, "=")
+		if !ok || !isValidLabelName(name) {
+			return nil, false
+		}
+
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil, false
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, false
+		}
+		labels[name] = value
+
+		s = rest[len(quoted):]
+		if s == "" {
+			break
+		}
+		if s[0] != ',' || len(s) == 1 {
+			return nil, false
+		}
+		s = s[1:]
+	}
+	if len(labels) == 0 {
+		return nil, false
+	}
+	return labels, true
+}
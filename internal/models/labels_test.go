@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		labels   map[string]string
+		expected string
+	}{
+		{"no labels", "Alloc", nil, "Alloc"},
+		{"empty labels", "Alloc", map[string]string{}, "Alloc"},
+		{"sorted labels", "Alloc", map[string]string{"instance": "a:1", "host": "web1"}, `Alloc{host="web1",instance="a:1"}`},
+		{"escaped value", "Alloc", map[string]string{"path": `C:\tmp "x"`}, `Alloc{path="C:\\tmp \"x\""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := SeriesKey(tt.id, tt.labels)
+			assert.Equal(t, tt.expected, key)
+
+			id, labels := ParseSeriesKey(key)
+			assert.Equal(t, tt.id, id)
+			if len(tt.labels) == 0 {
+				assert.Nil(t, labels)
+			} else {
+				assert.Equal(t, tt.labels, labels)
+			}
+		})
+	}
+}
+
+func TestParseSeriesKey_NotALabelSet(t *testing.T) {
+	for _, key := range []string{"a{b}", "a{}", `a{b="c",}`, `a{b="c"x}`, `a{1b="c"}`, "a}"} {
+		id, labels := ParseSeriesKey(key)
+		assert.Equal(t, key, id)
+		assert.Nil(t, labels)
+	}
+
+	id, labels := ParseSeriesKey(`we{ird{host="a"}`)
+	assert.Equal(t, "we{ird", id)
+	assert.Equal(t, map[string]string{"host": "a"}, labels)
+}
+
+func TestValidateLabels(t *testing.T) {
+	assert.NoError(t, ValidateLabels(nil))
+	assert.NoError(t, ValidateLabels(map[string]string{"host": "a", "_x1": ""}))
+	assert.Error(t, ValidateLabels(map[string]string{"1host": "a"}))
+	assert.Error(t, ValidateLabels(map[string]string{"ho-st": "a"}))
+	assert.Error(t, ValidateLabels(map[string]string{"": "a"}))
+}
+
+func TestValidateID(t *testing.T) {
+	assert.NoError(t, ValidateID("Alloc"))
+	assert.NoError(t, ValidateID("cpu.usage idle"))
+	assert.Error(t, ValidateID(`cpu{host="a"}`))
+	assert.Error(t, ValidateID("cpu}"))
+}
+
+func TestMetricModel_Labels_JSON(t *testing.T) {
+	var m MetricModel
+	err := json.Unmarshal([]byte(`{"id":"Alloc","type":"gauge","value":1,"labels":{"host":"web1"}}`), &m)
+	assert.NoError(t, err)
+	assert.Equal(t, `Alloc{host="web1"}`, m.SeriesKey())
+
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"Alloc","type":"gauge","value":1,"labels":{"host":"web1"}}`, string(data))
+
+	err = json.Unmarshal([]byte(`{"id":"Alloc","type":"gauge","value":1,"labels":{"bad-name":"x"}}`), &m)
+	assert.Error(t, err)
+
+	// An ID cannot pose as the key of a labelled series.
+	err = json.Unmarshal([]byte(`{"id":"Alloc{host=\"web1\"}","type":"gauge","value":1}`), &m)
+	assert.Error(t, err)
+}
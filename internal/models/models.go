@@ -3,19 +3,77 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/etoneja/go-metrics/internal/common"
 )
 
+// MetricModel is a metric update or state.
+//
+// Summary metrics are updated with raw Observations (or a Sketch to merge)
+// and reported with Summary. A response to a request for a single quantile
+// carries the Quantile and its estimate in Value. Storages fill Sketch to
+// persist the state of summaries. UpdatedAt and ExpiresAt are filled by
+// storages when listing metrics, ExpiresAt only if metrics expire.
 type MetricModel struct {
-	ID    string   `json:"id"`
-	MType string   `json:"type"`
-	Delta *int64   `json:"delta,omitempty"`
-	Value *float64 `json:"value,omitempty"`
+	ID           string            `json:"id"`
+	MType        string            `json:"type"`
+	Delta        *int64            `json:"delta,omitempty"`
+	Value        *float64          `json:"value,omitempty"`
+	Histogram    *Histogram        `json:"histogram,omitempty"`
+	Observations []float64         `json:"observations,omitempty"`
+	Summary      *Summary          `json:"summary,omitempty"`
+	Quantile     *float64          `json:"quantile,omitempty"`
+	Sketch       *QuantileSketch   `json:"sketch,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	UpdatedAt    *time.Time        `json:"updated_at,omitempty"`
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty"`
 }
 type MetricGetRequestModel struct {
-	ID    string `json:"id"`
-	MType string `json:"type"`
+	ID       string            `json:"id"`
+	MType    string            `json:"type"`
+	Quantile *float64          `json:"quantile,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// MetricSample is a timestamped value of a metric.
+// Counters carry the accumulated value after the update in Delta and the
+// applied increment in Increment, gauges carry Value.
+type MetricSample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Delta     *int64    `json:"delta,omitempty"`
+	Value     *float64  `json:"value,omitempty"`
+	Increment *int64    `json:"increment,omitempty"`
+}
+
+func NewMetricSample(ts time.Time, mtype string, delta int64, value float64) MetricSample {
+	sample := MetricSample{Timestamp: ts}
+	switch mtype {
+	case common.MetricTypeCounter:
+		sample.Delta = &delta
+	case common.MetricTypeGauge:
+		sample.Value = &value
+	}
+	return sample
+}
+
+func NewCounterSample(ts time.Time, total int64, increment int64) MetricSample {
+	sample := NewMetricSample(ts, common.MetricTypeCounter, total, 0)
+	sample.Increment = &increment
+	return sample
+}
+
+// MetricRollup aggregates the samples of a metric that fall into one time bucket
+// starting at Timestamp. Gauges carry Min, Max, Avg and Last, counters carry
+// the sum of increments in Sum.
+type MetricRollup struct {
+	Timestamp time.Time `json:"timestamp"`
+	Count     int64     `json:"count"`
+	Min       *float64  `json:"min,omitempty"`
+	Max       *float64  `json:"max,omitempty"`
+	Avg       *float64  `json:"avg,omitempty"`
+	Last      *float64  `json:"last,omitempty"`
+	Sum       *int64    `json:"sum,omitempty"`
 }
 
 func NewMetricModel(id string, mtype string, delta int64, value float64) *MetricModel {
@@ -27,14 +85,48 @@ func NewMetricModel(id string, mtype string, delta int64, value float64) *Metric
 	}
 }
 
+func NewHistogramMetricModel(id string, h *Histogram) *MetricModel {
+	return &MetricModel{
+		ID:        id,
+		MType:     common.MetricTypeHistogram,
+		Histogram: h,
+	}
+}
+
+// NewSummaryMetricModel creates a summary metric model reporting
+// DefaultSummaryQuantiles of the sketch.
+func NewSummaryMetricModel(id string, sketch *QuantileSketch) *MetricModel {
+	return &MetricModel{
+		ID:      id,
+		MType:   common.MetricTypeSummary,
+		Summary: sketch.Summary(DefaultSummaryQuantiles),
+	}
+}
+
+// SeriesKey returns the key that identifies the series of the metric in storages.
+func (m MetricModel) SeriesKey() string {
+	return SeriesKey(m.ID, m.Labels)
+}
+
 func (m MetricModel) MarshalJSON() ([]byte, error) {
 	type Alias MetricModel
 
 	switch m.MType {
 	case common.MetricTypeCounter:
 		m.Value = nil
+		m.Histogram = nil
 	case common.MetricTypeGauge:
 		m.Delta = nil
+		m.Histogram = nil
+	case common.MetricTypeHistogram:
+		m.Delta = nil
+		m.Value = nil
+	case common.MetricTypeSummary:
+		m.Delta = nil
+		m.Histogram = nil
+		if m.Quantile == nil {
+			m.Value = nil
+		}
 	}
 
 	return json.Marshal(Alias(m))
@@ -50,6 +142,13 @@ func (m *MetricModel) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if err := ValidateID(m.ID); err != nil {
+		return err
+	}
+	if err := ValidateLabels(m.Labels); err != nil {
+		return fmt.Errorf("bad labels for metric: metrics=%s, type=%s: %w", m.ID, m.MType, err)
+	}
+
 	switch m.MType {
 	case common.MetricTypeCounter:
 		if aux.Delta == nil {
@@ -57,12 +156,38 @@ func (m *MetricModel) UnmarshalJSON(data []byte) error {
 		}
 		m.Delta = aux.Delta
 		m.Value = nil
+		m.Histogram = nil
 	case common.MetricTypeGauge:
 		if aux.Value == nil {
 			return fmt.Errorf("bad metric value for metric: metrics=%s, type=%s", m.ID, m.MType)
 		}
 		m.Value = aux.Value
 		m.Delta = nil
+		m.Histogram = nil
+	case common.MetricTypeHistogram:
+		if aux.Histogram == nil {
+			return fmt.Errorf("bad metric histogram for metric: metrics=%s, type=%s", m.ID, m.MType)
+		}
+		if err := aux.Histogram.Validate(); err != nil {
+			return fmt.Errorf("bad metric histogram for metric: metrics=%s, type=%s: %w", m.ID, m.MType, err)
+		}
+		m.Delta = nil
+		m.Value = nil
+	case common.MetricTypeSummary:
+		if len(aux.Observations) == 0 && aux.Sketch == nil {
+			return fmt.Errorf("bad metric observations for metric: metrics=%s, type=%s", m.ID, m.MType)
+		}
+		if err := ValidateObservations(aux.Observations); err != nil {
+			return fmt.Errorf("bad metric observations for metric: metrics=%s, type=%s: %w", m.ID, m.MType, err)
+		}
+		if aux.Sketch != nil {
+			if err := aux.Sketch.Validate(); err != nil {
+				return fmt.Errorf("bad metric sketch for metric: metrics=%s, type=%s: %w", m.ID, m.MType, err)
+			}
+		}
+		m.Delta = nil
+		m.Value = nil
+		m.Histogram = nil
 	default:
 		return fmt.Errorf("unknown metric type for metric: metrics=%s, type=%s", m.ID, m.MType)
 	}
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/etoneja/go-metrics/internal/common"
 )
@@ -233,3 +234,41 @@ func TestMetricModel_RoundTrip(t *testing.T) {
 		t.Errorf("Value mismatch: %f != %f", *original.Value, *restored.Value)
 	}
 }
+
+func TestNewMetricSample(t *testing.T) {
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	counter := NewMetricSample(ts, common.MetricTypeCounter, 7, 0)
+	if counter.Delta == nil || *counter.Delta != 7 || counter.Value != nil {
+		t.Errorf("unexpected counter sample: %+v", counter)
+	}
+
+	gauge := NewMetricSample(ts, common.MetricTypeGauge, 0, 1.5)
+	if gauge.Value == nil || *gauge.Value != 1.5 || gauge.Delta != nil {
+		t.Errorf("unexpected gauge sample: %+v", gauge)
+	}
+
+	data, err := json.Marshal(gauge)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expected := `{"timestamp":"2025-01-02T03:04:05Z","value":1.5}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, string(data))
+	}
+}
+
+func TestNewCounterSample(t *testing.T) {
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	sample := NewCounterSample(ts, 10, 3)
+
+	data, err := json.Marshal(sample)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expected := `{"timestamp":"2025-01-02T03:04:05Z","delta":10,"increment":3}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, string(data))
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+)
+
+// Silence mutes alerts of rules on a metric from StartsAt until EndsAt.
+//
+// MetricID is a metric name, matching every series of the metric, or a
+// series key with labels matching that series only. An empty MType matches
+// metrics of any type.
+type Silence struct {
+	ID       string    `json:"id"`
+	MetricID string    `json:"metric_id"`
+	MType    string    `json:"metric_type,omitempty"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Author   string    `json:"author"`
+	Comment  string    `json:"comment"`
+}
+
+// Validate checks a silence requested by a user.
+func (s *Silence) Validate() error {
+	if s.MetricID == "" {
+		return errors.New("silence has no metric id")
+	}
+	switch s.MType {
+	case "", common.MetricTypeGauge, common.MetricTypeCounter, common.MetricTypeHistogram, common.MetricTypeSummary:
+	default:
+		return fmt.Errorf("unknown metric type %s", s.MType)
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return errors.New("silence ends before it starts")
+	}
+	if s.Author == "" {
+		return errors.New("silence has no author")
+	}
+	return nil
+}
+
+// Active reports whether the silence mutes alerts at now.
+func (s *Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// Expired reports whether the silence ended by now.
+func (s *Silence) Expired(now time.Time) bool {
+	return !now.Before(s.EndsAt)
+}
+
+// Matches reports whether the silence applies to the series key of a metric.
+func (s *Silence) Matches(mType string, key string) bool {
+	if s.MType != "" && s.MType != mType {
+		return false
+	}
+	if s.MetricID == key {
+		return true
+	}
+	id, _ := ParseSeriesKey(key)
+	return s.MetricID == id
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSilence_Matches(t *testing.T) {
+	tests := []struct {
+		name     string
+		silence  Silence
+		mType    string
+		key      string
+		expected bool
+	}{
+		{"name", Silence{MetricID: "cpu"}, "gauge", "cpu", true},
+		{"name matches every series", Silence{MetricID: "cpu"}, "gauge", `cpu{host="web1"}`, true},
+		{"series key", Silence{MetricID: `cpu{host="web1"}`}, "gauge", `cpu{host="web1"}`, true},
+		{"other series", Silence{MetricID: `cpu{host="web2"}`}, "gauge", `cpu{host="web1"}`, false},
+		{"other name", Silence{MetricID: "mem"}, "gauge", "cpu", false},
+		{"type", Silence{MetricID: "cpu", MType: "gauge"}, "gauge", "cpu", true},
+		{"other type", Silence{MetricID: "cpu", MType: "counter"}, "gauge", "cpu", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.silence.Matches(tt.mType, tt.key))
+		})
+	}
+}
+
+func TestSilence_ActiveExpired(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	s := Silence{StartsAt: start, EndsAt: start.Add(time.Hour)}
+
+	assert.False(t, s.Active(start.Add(-time.Second)))
+	assert.False(t, s.Expired(start.Add(-time.Second)))
+	assert.True(t, s.Active(start))
+	assert.False(t, s.Active(start.Add(time.Hour)))
+	assert.True(t, s.Expired(start.Add(time.Hour)))
+}
@@ -0,0 +1,234 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+)
+
+const (
+	// SketchRelativeAccuracy is the relative error of quantiles estimated by QuantileSketch.
+	SketchRelativeAccuracy = 0.01
+	// SketchMaxBuckets bounds the memory used by a QuantileSketch.
+	SketchMaxBuckets = 2048
+)
+
+// DefaultSummaryQuantiles are the quantiles reported for summary metrics.
+var DefaultSummaryQuantiles = []float64{0.5, 0.9, 0.99}
+
+var sketchGamma = (1 + SketchRelativeAccuracy) / (1 - SketchRelativeAccuracy)
+var sketchLogGamma = math.Log(sketchGamma)
+
+// QuantileSketch is a mergeable streaming quantile estimator with bounded
+// memory (DDSketch).
+//
+// Observations are counted in logarithmically sized buckets, so every
+// estimated quantile is within SketchRelativeAccuracy of the exact value.
+// Positive and Negative map bucket indexes to counts of positive values and
+// absolute values of negative ones, zeros are counted in Zero. When there
+// are more than SketchMaxBuckets buckets the ones closest to zero are
+// collapsed, which only affects accuracy of the lowest magnitude values.
+type QuantileSketch struct {
+	Count    uint64         `json:"count"`
+	Sum      float64        `json:"sum"`
+	Min      float64        `json:"min"`
+	Max      float64        `json:"max"`
+	Zero     uint64         `json:"zero,omitempty"`
+	Positive map[int]uint64 `json:"positive,omitempty"`
+	Negative map[int]uint64 `json:"negative,omitempty"`
+}
+
+// Summary is the state of a summary metric as reported to clients.
+// Quantiles are keyed by the quantile formatted with FormatQuantile.
+type Summary struct {
+	Count     uint64             `json:"count"`
+	Sum       float64            `json:"sum"`
+	Quantiles map[string]float64 `json:"quantiles"`
+}
+
+func NewQuantileSketch() *QuantileSketch {
+	return &QuantileSketch{
+		Positive: make(map[int]uint64),
+		Negative: make(map[int]uint64),
+	}
+}
+
+// FormatQuantile formats a quantile as used in Summary.Quantiles ("0.99").
+func FormatQuantile(q float64) string {
+	return strconv.FormatFloat(q, 'g', -1, 64)
+}
+
+// ValidateObservations checks that all observations are finite numbers.
+func ValidateObservations(values []float64) error {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("observation %v is not finite", v)
+		}
+	}
+	return nil
+}
+
+// ValidateQuantile checks that q is in [0, 1].
+func ValidateQuantile(q float64) error {
+	if !(q >= 0 && q <= 1) {
+		return fmt.Errorf("quantile %v is not in [0, 1]", q)
+	}
+	return nil
+}
+
+func sketchIndex(v float64) int {
+	return int(math.Ceil(math.Log(v) / sketchLogGamma))
+}
+
+// sketchValue returns the value of a bucket that has the smallest relative
+// error to every value in the bucket.
+func sketchValue(index int) float64 {
+	return 2 * math.Pow(sketchGamma, float64(index)) / (sketchGamma + 1)
+}
+
+// Add adds an observation to the sketch. v must be finite.
+func (s *QuantileSketch) Add(v float64) {
+	if s.Count == 0 || v < s.Min {
+		s.Min = v
+	}
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+	s.Count++
+	s.Sum += v
+
+	switch {
+	case v > 0:
+		s.Positive[sketchIndex(v)]++
+	case v < 0:
+		s.Negative[sketchIndex(-v)]++
+	default:
+		s.Zero++
+	}
+	s.collapse()
+}
+
+// Merge adds all observations of other to the sketch.
+func (s *QuantileSketch) Merge(other *QuantileSketch) {
+	if other.Count == 0 {
+		return
+	}
+	if s.Count == 0 || other.Min < s.Min {
+		s.Min = other.Min
+	}
+	if s.Count == 0 || other.Max > s.Max {
+		s.Max = other.Max
+	}
+	s.Count += other.Count
+	s.Sum += other.Sum
+	s.Zero += other.Zero
+	for i, c := range other.Positive {
+		s.Positive[i] += c
+	}
+	for i, c := range other.Negative {
+		s.Negative[i] += c
+	}
+	s.collapse()
+}
+
+// collapse merges the buckets closest to zero until the sketch fits into
+// SketchMaxBuckets.
+func (s *QuantileSketch) collapse() {
+	for len(s.Positive)+len(s.Negative) > SketchMaxBuckets {
+		store := s.Positive
+		if len(s.Negative) > len(s.Positive) {
+			store = s.Negative
+		}
+		keys := slices.Sorted(maps.Keys(store))
+		store[keys[1]] += store[keys[0]]
+		delete(store, keys[0])
+	}
+}
+
+// Quantile returns the estimated q-quantile, q must be in [0, 1].
+// It returns NaN for an empty sketch.
+func (s *QuantileSketch) Quantile(q float64) float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+
+	rank := uint64(q * float64(s.Count-1))
+	var seen uint64
+
+	estimate := func() float64 {
+		for _, i := range slices.Backward(slices.Sorted(maps.Keys(s.Negative))) {
+			seen += s.Negative[i]
+			if seen > rank {
+				return -sketchValue(i)
+			}
+		}
+		seen += s.Zero
+		if seen > rank {
+			return 0
+		}
+		for _, i := range slices.Sorted(maps.Keys(s.Positive)) {
+			seen += s.Positive[i]
+			if seen > rank {
+				return sketchValue(i)
+			}
+		}
+		return s.Max
+	}
+
+	return math.Min(math.Max(estimate(), s.Min), s.Max)
+}
+
+// Summary returns count, sum and the given quantiles of the sketch.
+func (s *QuantileSketch) Summary(quantiles []float64) *Summary {
+	summary := &Summary{
+		Count:     s.Count,
+		Sum:       s.Sum,
+		Quantiles: make(map[string]float64, len(quantiles)),
+	}
+	for _, q := range quantiles {
+		summary.Quantiles[FormatQuantile(q)] = s.Quantile(q)
+	}
+	return summary
+}
+
+// Clone returns a deep copy of the sketch.
+func (s *QuantileSketch) Clone() *QuantileSketch {
+	c := *s
+	c.Positive = maps.Clone(s.Positive)
+	c.Negative = maps.Clone(s.Negative)
+	if c.Positive == nil {
+		c.Positive = make(map[int]uint64)
+	}
+	if c.Negative == nil {
+		c.Negative = make(map[int]uint64)
+	}
+	return &c
+}
+
+// Validate checks that bucket counts add up to Count and the sketch fits
+// into SketchMaxBuckets.
+func (s *QuantileSketch) Validate() error {
+	total := s.Zero
+	for _, c := range s.Positive {
+		total += c
+	}
+	for _, c := range s.Negative {
+		total += c
+	}
+	if total != s.Count {
+		return fmt.Errorf("sketch has %d observations in buckets, want %d", total, s.Count)
+	}
+	if len(s.Positive)+len(s.Negative) > SketchMaxBuckets {
+		return fmt.Errorf("sketch has more than %d buckets", SketchMaxBuckets)
+	}
+	if err := ValidateObservations([]float64{s.Sum, s.Min, s.Max}); err != nil {
+		return errors.New("sketch sum, min and max must be finite")
+	}
+	if s.Count > 0 && s.Min > s.Max {
+		return fmt.Errorf("sketch min %v is greater than max %v", s.Min, s.Max)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestQuantileSketch_Quantile(t *testing.T) {
+	s := NewQuantileSketch()
+	if !math.IsNaN(s.Quantile(0.5)) {
+		t.Error("Expected NaN for empty sketch")
+	}
+
+	for i := 1; i <= 10000; i++ {
+		s.Add(float64(i))
+	}
+
+	for _, q := range []float64{0, 0.5, 0.9, 0.99, 1} {
+		exact := 1 + q*9999
+		got := s.Quantile(q)
+		if math.Abs(got-exact)/exact > SketchRelativeAccuracy+1e-3 {
+			t.Errorf("Quantile(%v) = %v, want %v within %v", q, got, exact, SketchRelativeAccuracy)
+		}
+	}
+	if s.Count != 10000 || s.Sum != 50005000 {
+		t.Errorf("Count = %d, Sum = %v", s.Count, s.Sum)
+	}
+}
+
+func TestQuantileSketch_NegativeAndZero(t *testing.T) {
+	s := NewQuantileSketch()
+	for _, v := range []float64{-10, -1, 0, 1, 10} {
+		s.Add(v)
+	}
+
+	if got := s.Quantile(0); got != -10 {
+		t.Errorf("Quantile(0) = %v, want -10", got)
+	}
+	if got := s.Quantile(0.5); got != 0 {
+		t.Errorf("Quantile(0.5) = %v, want 0", got)
+	}
+	if got := s.Quantile(0.25); math.Abs(got+1) > SketchRelativeAccuracy {
+		t.Errorf("Quantile(0.25) = %v, want -1", got)
+	}
+	if got := s.Quantile(1); got != 10 {
+		t.Errorf("Quantile(1) = %v, want 10", got)
+	}
+}
+
+func TestQuantileSketch_BoundedBuckets(t *testing.T) {
+	s := NewQuantileSketch()
+	for i := -400; i < 400; i++ {
+		s.Add(math.Pow(1.1, float64(i)))
+	}
+	if n := len(s.Positive) + len(s.Negative); n > SketchMaxBuckets {
+		t.Errorf("Sketch has %d buckets, want at most %d", n, SketchMaxBuckets)
+	}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+
+	want := math.Pow(1.1, 399)
+	if got := s.Quantile(1); got != want {
+		t.Errorf("Quantile(1) = %v, want %v", got, want)
+	}
+}
+
+func TestQuantileSketch_Merge(t *testing.T) {
+	a, b, all := NewQuantileSketch(), NewQuantileSketch(), NewQuantileSketch()
+	for i := 1; i <= 100; i++ {
+		if i%2 == 0 {
+			a.Add(float64(i))
+		} else {
+			b.Add(float64(i))
+		}
+		all.Add(float64(i))
+	}
+
+	a.Merge(b)
+	if a.Count != all.Count || a.Sum != all.Sum || a.Min != all.Min || a.Max != all.Max {
+		t.Errorf("Merged sketch %+v differs from %+v", a, all)
+	}
+	for _, q := range DefaultSummaryQuantiles {
+		if a.Quantile(q) != all.Quantile(q) {
+			t.Errorf("Quantile(%v) = %v, want %v", q, a.Quantile(q), all.Quantile(q))
+		}
+	}
+}
+
+func TestQuantileSketch_JSONRoundTrip(t *testing.T) {
+	s := NewQuantileSketch()
+	for _, v := range []float64{-2, 0, 0.5, 3} {
+		s.Add(v)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var restored QuantileSketch
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := restored.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if restored.Quantile(0.9) != s.Quantile(0.9) {
+		t.Errorf("Quantile(0.9) = %v, want %v", restored.Quantile(0.9), s.Quantile(0.9))
+	}
+
+	restored.Count++
+	if err := restored.Validate(); err == nil {
+		t.Error("Expected error for inconsistent count")
+	}
+}
+
+func TestMetricModel_JSON_Summary(t *testing.T) {
+	var metric MetricModel
+	if err := json.Unmarshal([]byte(`{"id":"latency","type":"summary","observations":[1,2,3]}`), &metric); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(metric.Observations) != 3 {
+		t.Errorf("Observations = %v, want 3 values", metric.Observations)
+	}
+
+	s := NewQuantileSketch()
+	s.Add(1)
+	data, err := json.Marshal(NewSummaryMetricModel("latency", s))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"id":"latency","type":"summary","summary":{"count":1,"sum":1,"quantiles":{"0.5":1,"0.9":1,"0.99":1}}}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	for _, bad := range []string{
+		`{"id":"latency","type":"summary"}`,
+		`{"id":"latency","type":"summary","observations":[]}`,
+		`{"id":"latency","type":"summary","sketch":{"count":1}}`,
+	} {
+		var badMetric MetricModel
+		if err := json.Unmarshal([]byte(bad), &badMetric); err == nil {
+			t.Errorf("Expected error for %s", bad)
+		}
+	}
+}
@@ -27,6 +27,10 @@ type Metric struct {
 	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
 	Delta         *int64                 `protobuf:"varint,3,opt,name=delta,proto3,oneof" json:"delta,omitempty"`
 	Value         *float64               `protobuf:"fixed64,4,opt,name=value,proto3,oneof" json:"value,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Histogram     *Histogram             `protobuf:"bytes,6,opt,name=histogram,proto3" json:"histogram,omitempty"`
+	Observations  []float64              `protobuf:"fixed64,7,rep,packed,name=observations,proto3" json:"observations,omitempty"`
+	Summary       *Summary               `protobuf:"bytes,8,opt,name=summary,proto3" json:"summary,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -89,6 +93,154 @@ func (x *Metric) GetValue() float64 {
 	return 0
 }
 
+func (x *Metric) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Metric) GetHistogram() *Histogram {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+func (x *Metric) GetObservations() []float64 {
+	if x != nil {
+		return x.Observations
+	}
+	return nil
+}
+
+func (x *Metric) GetSummary() *Summary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+type Histogram struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bounds        []float64              `protobuf:"fixed64,1,rep,packed,name=bounds,proto3" json:"bounds,omitempty"`
+	Counts        []uint64               `protobuf:"varint,2,rep,packed,name=counts,proto3" json:"counts,omitempty"`
+	Sum           float64                `protobuf:"fixed64,3,opt,name=sum,proto3" json:"sum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Histogram) Reset() {
+	*x = Histogram{}
+	mi := &file_internal_proto_proto_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Histogram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Histogram) ProtoMessage() {}
+
+func (x *Histogram) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Histogram.ProtoReflect.Descriptor instead.
+func (*Histogram) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Histogram) GetBounds() []float64 {
+	if x != nil {
+		return x.Bounds
+	}
+	return nil
+}
+
+func (x *Histogram) GetCounts() []uint64 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+func (x *Histogram) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+type Summary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         uint64                 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Sum           float64                `protobuf:"fixed64,2,opt,name=sum,proto3" json:"sum,omitempty"`
+	Quantiles     map[string]float64     `protobuf:"bytes,3,rep,name=quantiles,proto3" json:"quantiles,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Summary) Reset() {
+	*x = Summary{}
+	mi := &file_internal_proto_proto_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Summary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Summary) ProtoMessage() {}
+
+func (x *Summary) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Summary.ProtoReflect.Descriptor instead.
+func (*Summary) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Summary) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Summary) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *Summary) GetQuantiles() map[string]float64 {
+	if x != nil {
+		return x.Quantiles
+	}
+	return nil
+}
+
 type BatchUpdateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Metrics       []*Metric              `protobuf:"bytes,1,rep,name=metrics,proto3" json:"metrics,omitempty"`
@@ -98,7 +250,7 @@ type BatchUpdateRequest struct {
 
 func (x *BatchUpdateRequest) Reset() {
 	*x = BatchUpdateRequest{}
-	mi := &file_internal_proto_proto_proto_msgTypes[1]
+	mi := &file_internal_proto_proto_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -110,7 +262,7 @@ func (x *BatchUpdateRequest) String() string {
 func (*BatchUpdateRequest) ProtoMessage() {}
 
 func (x *BatchUpdateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_proto_proto_msgTypes[1]
+	mi := &file_internal_proto_proto_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -123,7 +275,7 @@ func (x *BatchUpdateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchUpdateRequest.ProtoReflect.Descriptor instead.
 func (*BatchUpdateRequest) Descriptor() ([]byte, []int) {
-	return file_internal_proto_proto_proto_rawDescGZIP(), []int{1}
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *BatchUpdateRequest) GetMetrics() []*Metric {
@@ -142,7 +294,7 @@ type BatchUpdateResponse struct {
 
 func (x *BatchUpdateResponse) Reset() {
 	*x = BatchUpdateResponse{}
-	mi := &file_internal_proto_proto_proto_msgTypes[2]
+	mi := &file_internal_proto_proto_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -154,7 +306,7 @@ func (x *BatchUpdateResponse) String() string {
 func (*BatchUpdateResponse) ProtoMessage() {}
 
 func (x *BatchUpdateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_proto_proto_msgTypes[2]
+	mi := &file_internal_proto_proto_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -167,7 +319,7 @@ func (x *BatchUpdateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchUpdateResponse.ProtoReflect.Descriptor instead.
 func (*BatchUpdateResponse) Descriptor() ([]byte, []int) {
-	return file_internal_proto_proto_proto_rawDescGZIP(), []int{2}
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *BatchUpdateResponse) GetMetrics() []*Metric {
@@ -185,7 +337,7 @@ type PingRequest struct {
 
 func (x *PingRequest) Reset() {
 	*x = PingRequest{}
-	mi := &file_internal_proto_proto_proto_msgTypes[3]
+	mi := &file_internal_proto_proto_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -197,7 +349,7 @@ func (x *PingRequest) String() string {
 func (*PingRequest) ProtoMessage() {}
 
 func (x *PingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_proto_proto_msgTypes[3]
+	mi := &file_internal_proto_proto_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -210,7 +362,7 @@ func (x *PingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
 func (*PingRequest) Descriptor() ([]byte, []int) {
-	return file_internal_proto_proto_proto_rawDescGZIP(), []int{3}
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{5}
 }
 
 type PingResponse struct {
@@ -222,7 +374,7 @@ type PingResponse struct {
 
 func (x *PingResponse) Reset() {
 	*x = PingResponse{}
-	mi := &file_internal_proto_proto_proto_msgTypes[4]
+	mi := &file_internal_proto_proto_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -234,7 +386,7 @@ func (x *PingResponse) String() string {
 func (*PingResponse) ProtoMessage() {}
 
 func (x *PingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_proto_proto_msgTypes[4]
+	mi := &file_internal_proto_proto_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -247,7 +399,7 @@ func (x *PingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return file_internal_proto_proto_proto_rawDescGZIP(), []int{4}
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PingResponse) GetSuccess() bool {
@@ -265,7 +417,7 @@ type ListMetricsRequest struct {
 
 func (x *ListMetricsRequest) Reset() {
 	*x = ListMetricsRequest{}
-	mi := &file_internal_proto_proto_proto_msgTypes[5]
+	mi := &file_internal_proto_proto_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -277,7 +429,7 @@ func (x *ListMetricsRequest) String() string {
 func (*ListMetricsRequest) ProtoMessage() {}
 
 func (x *ListMetricsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_proto_proto_msgTypes[5]
+	mi := &file_internal_proto_proto_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -290,7 +442,7 @@ func (x *ListMetricsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListMetricsRequest.ProtoReflect.Descriptor instead.
 func (*ListMetricsRequest) Descriptor() ([]byte, []int) {
-	return file_internal_proto_proto_proto_rawDescGZIP(), []int{5}
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{7}
 }
 
 type ListMetricsResponse struct {
@@ -302,7 +454,7 @@ type ListMetricsResponse struct {
 
 func (x *ListMetricsResponse) Reset() {
 	*x = ListMetricsResponse{}
-	mi := &file_internal_proto_proto_proto_msgTypes[6]
+	mi := &file_internal_proto_proto_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -314,7 +466,7 @@ func (x *ListMetricsResponse) String() string {
 func (*ListMetricsResponse) ProtoMessage() {}
 
 func (x *ListMetricsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_proto_proto_msgTypes[6]
+	mi := &file_internal_proto_proto_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -327,7 +479,7 @@ func (x *ListMetricsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListMetricsResponse.ProtoReflect.Descriptor instead.
 func (*ListMetricsResponse) Descriptor() ([]byte, []int) {
-	return file_internal_proto_proto_proto_rawDescGZIP(), []int{6}
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ListMetricsResponse) GetMetrics() []*Metric {
@@ -337,32 +489,1228 @@ func (x *ListMetricsResponse) GetMetrics() []*Metric {
 	return nil
 }
 
-var File_internal_proto_proto_proto protoreflect.FileDescriptor
+type DeleteMetricRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_internal_proto_proto_proto_rawDesc = "" +
-	"\n" +
-	"\x1ainternal/proto/proto.proto\x12\ametrics\"v\n" +
-	"\x06Metric\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04type\x18\x02 \x01(\tR\x04type\x12\x19\n" +
-	"\x05delta\x18\x03 \x01(\x03H\x00R\x05delta\x88\x01\x01\x12\x19\n" +
-	"\x05value\x18\x04 \x01(\x01H\x01R\x05value\x88\x01\x01B\b\n" +
-	"\x06_deltaB\b\n" +
-	"\x06_value\"?\n" +
-	"\x12BatchUpdateRequest\x12)\n" +
-	"\ametrics\x18\x01 \x03(\v2\x0f.metrics.MetricR\ametrics\"@\n" +
-	"\x13BatchUpdateResponse\x12)\n" +
-	"\ametrics\x18\x01 \x03(\v2\x0f.metrics.MetricR\ametrics\"\r\n" +
-	"\vPingRequest\"(\n" +
-	"\fPingResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x14\n" +
-	"\x12ListMetricsRequest\"@\n" +
-	"\x13ListMetricsResponse\x12)\n" +
-	"\ametrics\x18\x01 \x03(\v2\x0f.metrics.MetricR\ametrics2\xd9\x01\n" +
-	"\x0eMetricsService\x12H\n" +
-	"\vBatchUpdate\x12\x1b.metrics.BatchUpdateRequest\x1a\x1c.metrics.BatchUpdateResponse\x123\n" +
-	"\x04Ping\x12\x14.metrics.PingRequest\x1a\x15.metrics.PingResponse\x12H\n" +
-	"\vListMetrics\x12\x1b.metrics.ListMetricsRequest\x1a\x1c.metrics.ListMetricsResponseB\x11Z\x0f/internal/protob\x06proto3"
+func (x *DeleteMetricRequest) Reset() {
+	*x = DeleteMetricRequest{}
+	mi := &file_internal_proto_proto_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMetricRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMetricRequest) ProtoMessage() {}
+
+func (x *DeleteMetricRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMetricRequest.ProtoReflect.Descriptor instead.
+func (*DeleteMetricRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteMetricRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteMetricRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *DeleteMetricRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type DeleteMetricResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMetricResponse) Reset() {
+	*x = DeleteMetricResponse{}
+	mi := &file_internal_proto_proto_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMetricResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMetricResponse) ProtoMessage() {}
+
+func (x *DeleteMetricResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMetricResponse.ProtoReflect.Descriptor instead.
+func (*DeleteMetricResponse) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{10}
+}
+
+type ResetCounterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetCounterRequest) Reset() {
+	*x = ResetCounterRequest{}
+	mi := &file_internal_proto_proto_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetCounterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetCounterRequest) ProtoMessage() {}
+
+func (x *ResetCounterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetCounterRequest.ProtoReflect.Descriptor instead.
+func (*ResetCounterRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ResetCounterRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ResetCounterRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type ResetCounterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetCounterResponse) Reset() {
+	*x = ResetCounterResponse{}
+	mi := &file_internal_proto_proto_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetCounterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetCounterResponse) ProtoMessage() {}
+
+func (x *ResetCounterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetCounterResponse.ProtoReflect.Descriptor instead.
+func (*ResetCounterResponse) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{12}
+}
+
+type Alert struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Expr          string                 `protobuf:"bytes,2,opt,name=expr,proto3" json:"expr,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Value         float64                `protobuf:"fixed64,4,opt,name=value,proto3" json:"value,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ActiveAt      int64                  `protobuf:"varint,6,opt,name=active_at,json=activeAt,proto3" json:"active_at,omitempty"`
+	FiredAt       int64                  `protobuf:"varint,7,opt,name=fired_at,json=firedAt,proto3" json:"fired_at,omitempty"`
+	ResolvedAt    int64                  `protobuf:"varint,8,opt,name=resolved_at,json=resolvedAt,proto3" json:"resolved_at,omitempty"`
+	Silenced      bool                   `protobuf:"varint,9,opt,name=silenced,proto3" json:"silenced,omitempty"`
+	SilencedBy    []string               `protobuf:"bytes,10,rep,name=silenced_by,json=silencedBy,proto3" json:"silenced_by,omitempty"`
+	Acknowledged  *AlertAck              `protobuf:"bytes,11,opt,name=acknowledged,proto3" json:"acknowledged,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Alert) Reset() {
+	*x = Alert{}
+	mi := &file_internal_proto_proto_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alert) ProtoMessage() {}
+
+func (x *Alert) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alert.ProtoReflect.Descriptor instead.
+func (*Alert) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Alert) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Alert) GetExpr() string {
+	if x != nil {
+		return x.Expr
+	}
+	return ""
+}
+
+func (x *Alert) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Alert) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Alert) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Alert) GetActiveAt() int64 {
+	if x != nil {
+		return x.ActiveAt
+	}
+	return 0
+}
+
+func (x *Alert) GetFiredAt() int64 {
+	if x != nil {
+		return x.FiredAt
+	}
+	return 0
+}
+
+func (x *Alert) GetResolvedAt() int64 {
+	if x != nil {
+		return x.ResolvedAt
+	}
+	return 0
+}
+
+func (x *Alert) GetSilenced() bool {
+	if x != nil {
+		return x.Silenced
+	}
+	return false
+}
+
+func (x *Alert) GetSilencedBy() []string {
+	if x != nil {
+		return x.SilencedBy
+	}
+	return nil
+}
+
+func (x *Alert) GetAcknowledged() *AlertAck {
+	if x != nil {
+		return x.Acknowledged
+	}
+	return nil
+}
+
+type AlertAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Author        string                 `protobuf:"bytes,1,opt,name=author,proto3" json:"author,omitempty"`
+	Comment       string                 `protobuf:"bytes,2,opt,name=comment,proto3" json:"comment,omitempty"`
+	At            int64                  `protobuf:"varint,3,opt,name=at,proto3" json:"at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AlertAck) Reset() {
+	*x = AlertAck{}
+	mi := &file_internal_proto_proto_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AlertAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlertAck) ProtoMessage() {}
+
+func (x *AlertAck) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlertAck.ProtoReflect.Descriptor instead.
+func (*AlertAck) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *AlertAck) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *AlertAck) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *AlertAck) GetAt() int64 {
+	if x != nil {
+		return x.At
+	}
+	return 0
+}
+
+type ListAlertsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAlertsRequest) Reset() {
+	*x = ListAlertsRequest{}
+	mi := &file_internal_proto_proto_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlertsRequest) ProtoMessage() {}
+
+func (x *ListAlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlertsRequest.ProtoReflect.Descriptor instead.
+func (*ListAlertsRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{15}
+}
+
+type ListAlertsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alerts        []*Alert               `protobuf:"bytes,1,rep,name=alerts,proto3" json:"alerts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAlertsResponse) Reset() {
+	*x = ListAlertsResponse{}
+	mi := &file_internal_proto_proto_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlertsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlertsResponse) ProtoMessage() {}
+
+func (x *ListAlertsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlertsResponse.ProtoReflect.Descriptor instead.
+func (*ListAlertsResponse) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListAlertsResponse) GetAlerts() []*Alert {
+	if x != nil {
+		return x.Alerts
+	}
+	return nil
+}
+
+type AcknowledgeAlertRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Author        string                 `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Comment       string                 `protobuf:"bytes,3,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcknowledgeAlertRequest) Reset() {
+	*x = AcknowledgeAlertRequest{}
+	mi := &file_internal_proto_proto_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcknowledgeAlertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcknowledgeAlertRequest) ProtoMessage() {}
+
+func (x *AcknowledgeAlertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcknowledgeAlertRequest.ProtoReflect.Descriptor instead.
+func (*AcknowledgeAlertRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AcknowledgeAlertRequest) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *AcknowledgeAlertRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *AcknowledgeAlertRequest) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+type AcknowledgeAlertResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alert         *Alert                 `protobuf:"bytes,1,opt,name=alert,proto3" json:"alert,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcknowledgeAlertResponse) Reset() {
+	*x = AcknowledgeAlertResponse{}
+	mi := &file_internal_proto_proto_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcknowledgeAlertResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcknowledgeAlertResponse) ProtoMessage() {}
+
+func (x *AcknowledgeAlertResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcknowledgeAlertResponse.ProtoReflect.Descriptor instead.
+func (*AcknowledgeAlertResponse) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *AcknowledgeAlertResponse) GetAlert() *Alert {
+	if x != nil {
+		return x.Alert
+	}
+	return nil
+}
+
+type Silence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MetricId      string                 `protobuf:"bytes,2,opt,name=metric_id,json=metricId,proto3" json:"metric_id,omitempty"`
+	MetricType    string                 `protobuf:"bytes,3,opt,name=metric_type,json=metricType,proto3" json:"metric_type,omitempty"`
+	StartsAt      int64                  `protobuf:"varint,4,opt,name=starts_at,json=startsAt,proto3" json:"starts_at,omitempty"`
+	EndsAt        int64                  `protobuf:"varint,5,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"`
+	Author        string                 `protobuf:"bytes,6,opt,name=author,proto3" json:"author,omitempty"`
+	Comment       string                 `protobuf:"bytes,7,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Silence) Reset() {
+	*x = Silence{}
+	mi := &file_internal_proto_proto_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Silence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Silence) ProtoMessage() {}
+
+func (x *Silence) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Silence.ProtoReflect.Descriptor instead.
+func (*Silence) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Silence) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Silence) GetMetricId() string {
+	if x != nil {
+		return x.MetricId
+	}
+	return ""
+}
+
+func (x *Silence) GetMetricType() string {
+	if x != nil {
+		return x.MetricType
+	}
+	return ""
+}
+
+func (x *Silence) GetStartsAt() int64 {
+	if x != nil {
+		return x.StartsAt
+	}
+	return 0
+}
+
+func (x *Silence) GetEndsAt() int64 {
+	if x != nil {
+		return x.EndsAt
+	}
+	return 0
+}
+
+func (x *Silence) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Silence) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+type CreateSilenceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Silence       *Silence               `protobuf:"bytes,1,opt,name=silence,proto3" json:"silence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSilenceRequest) Reset() {
+	*x = CreateSilenceRequest{}
+	mi := &file_internal_proto_proto_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSilenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSilenceRequest) ProtoMessage() {}
+
+func (x *CreateSilenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSilenceRequest.ProtoReflect.Descriptor instead.
+func (*CreateSilenceRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CreateSilenceRequest) GetSilence() *Silence {
+	if x != nil {
+		return x.Silence
+	}
+	return nil
+}
+
+type CreateSilenceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Silence       *Silence               `protobuf:"bytes,1,opt,name=silence,proto3" json:"silence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSilenceResponse) Reset() {
+	*x = CreateSilenceResponse{}
+	mi := &file_internal_proto_proto_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSilenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSilenceResponse) ProtoMessage() {}
+
+func (x *CreateSilenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSilenceResponse.ProtoReflect.Descriptor instead.
+func (*CreateSilenceResponse) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CreateSilenceResponse) GetSilence() *Silence {
+	if x != nil {
+		return x.Silence
+	}
+	return nil
+}
+
+type ListSilencesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSilencesRequest) Reset() {
+	*x = ListSilencesRequest{}
+	mi := &file_internal_proto_proto_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSilencesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSilencesRequest) ProtoMessage() {}
+
+func (x *ListSilencesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSilencesRequest.ProtoReflect.Descriptor instead.
+func (*ListSilencesRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{22}
+}
+
+type ListSilencesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Silences      []*Silence             `protobuf:"bytes,1,rep,name=silences,proto3" json:"silences,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSilencesResponse) Reset() {
+	*x = ListSilencesResponse{}
+	mi := &file_internal_proto_proto_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSilencesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSilencesResponse) ProtoMessage() {}
+
+func (x *ListSilencesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSilencesResponse.ProtoReflect.Descriptor instead.
+func (*ListSilencesResponse) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListSilencesResponse) GetSilences() []*Silence {
+	if x != nil {
+		return x.Silences
+	}
+	return nil
+}
+
+type DeleteSilenceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSilenceRequest) Reset() {
+	*x = DeleteSilenceRequest{}
+	mi := &file_internal_proto_proto_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSilenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSilenceRequest) ProtoMessage() {}
+
+func (x *DeleteSilenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSilenceRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSilenceRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *DeleteSilenceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteSilenceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSilenceResponse) Reset() {
+	*x = DeleteSilenceResponse{}
+	mi := &file_internal_proto_proto_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSilenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSilenceResponse) ProtoMessage() {}
+
+func (x *DeleteSilenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSilenceResponse.ProtoReflect.Descriptor instead.
+func (*DeleteSilenceResponse) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{25}
+}
+
+type RemoteWriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timeseries    []*RemoteTimeSeries    `protobuf:"bytes,1,rep,name=timeseries,proto3" json:"timeseries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoteWriteRequest) Reset() {
+	*x = RemoteWriteRequest{}
+	mi := &file_internal_proto_proto_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoteWriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteWriteRequest) ProtoMessage() {}
+
+func (x *RemoteWriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteWriteRequest.ProtoReflect.Descriptor instead.
+func (*RemoteWriteRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *RemoteWriteRequest) GetTimeseries() []*RemoteTimeSeries {
+	if x != nil {
+		return x.Timeseries
+	}
+	return nil
+}
+
+type RemoteTimeSeries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Labels        []*RemoteLabel         `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty"`
+	Samples       []*RemoteSample        `protobuf:"bytes,2,rep,name=samples,proto3" json:"samples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoteTimeSeries) Reset() {
+	*x = RemoteTimeSeries{}
+	mi := &file_internal_proto_proto_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoteTimeSeries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteTimeSeries) ProtoMessage() {}
+
+func (x *RemoteTimeSeries) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteTimeSeries.ProtoReflect.Descriptor instead.
+func (*RemoteTimeSeries) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RemoteTimeSeries) GetLabels() []*RemoteLabel {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *RemoteTimeSeries) GetSamples() []*RemoteSample {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+type RemoteLabel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoteLabel) Reset() {
+	*x = RemoteLabel{}
+	mi := &file_internal_proto_proto_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoteLabel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteLabel) ProtoMessage() {}
+
+func (x *RemoteLabel) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteLabel.ProtoReflect.Descriptor instead.
+func (*RemoteLabel) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RemoteLabel) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RemoteLabel) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type RemoteSample struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         float64                `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoteSample) Reset() {
+	*x = RemoteSample{}
+	mi := &file_internal_proto_proto_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoteSample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteSample) ProtoMessage() {}
+
+func (x *RemoteSample) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_proto_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteSample.ProtoReflect.Descriptor instead.
+func (*RemoteSample) Descriptor() ([]byte, []int) {
+	return file_internal_proto_proto_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RemoteSample) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *RemoteSample) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+var File_internal_proto_proto_proto protoreflect.FileDescriptor
+
+const file_internal_proto_proto_proto_rawDesc = "" +
+	"\n" +
+	"\x1ainternal/proto/proto.proto\x12\ametrics\"\xe8\x02\n" +
+	"\x06Metric\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x19\n" +
+	"\x05delta\x18\x03 \x01(\x03H\x00R\x05delta\x88\x01\x01\x12\x19\n" +
+	"\x05value\x18\x04 \x01(\x01H\x01R\x05value\x88\x01\x01\x123\n" +
+	"\x06labels\x18\x05 \x03(\v2\x1b.metrics.Metric.LabelsEntryR\x06labels\x120\n" +
+	"\thistogram\x18\x06 \x01(\v2\x12.metrics.HistogramR\thistogram\x12\"\n" +
+	"\fobservations\x18\a \x03(\x01R\fobservations\x12*\n" +
+	"\asummary\x18\b \x01(\v2\x10.metrics.SummaryR\asummary\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\b\n" +
+	"\x06_deltaB\b\n" +
+	"\x06_value\"M\n" +
+	"\tHistogram\x12\x16\n" +
+	"\x06bounds\x18\x01 \x03(\x01R\x06bounds\x12\x16\n" +
+	"\x06counts\x18\x02 \x03(\x04R\x06counts\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\"\xae\x01\n" +
+	"\aSummary\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x04R\x05count\x12\x10\n" +
+	"\x03sum\x18\x02 \x01(\x01R\x03sum\x12=\n" +
+	"\tquantiles\x18\x03 \x03(\v2\x1f.metrics.Summary.QuantilesEntryR\tquantiles\x1a<\n" +
+	"\x0eQuantilesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"?\n" +
+	"\x12BatchUpdateRequest\x12)\n" +
+	"\ametrics\x18\x01 \x03(\v2\x0f.metrics.MetricR\ametrics\"@\n" +
+	"\x13BatchUpdateResponse\x12)\n" +
+	"\ametrics\x18\x01 \x03(\v2\x0f.metrics.MetricR\ametrics\"\r\n" +
+	"\vPingRequest\"(\n" +
+	"\fPingResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x14\n" +
+	"\x12ListMetricsRequest\"@\n" +
+	"\x13ListMetricsResponse\x12)\n" +
+	"\ametrics\x18\x01 \x03(\v2\x0f.metrics.MetricR\ametrics\"\xb6\x01\n" +
+	"\x13DeleteMetricRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12@\n" +
+	"\x06labels\x18\x03 \x03(\v2(.metrics.DeleteMetricRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x16\n" +
+	"\x14DeleteMetricResponse\"\xa2\x01\n" +
+	"\x13ResetCounterRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12@\n" +
+	"\x06labels\x18\x02 \x03(\v2(.metrics.ResetCounterRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x16\n" +
+	"\x14ResetCounterResponse\"\x97\x03\n" +
+	"\x05Alert\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x12\n" +
+	"\x04expr\x18\x02 \x01(\tR\x04expr\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\x01R\x05value\x122\n" +
+	"\x06labels\x18\x05 \x03(\v2\x1a.metrics.Alert.LabelsEntryR\x06labels\x12\x1b\n" +
+	"\tactive_at\x18\x06 \x01(\x03R\bactiveAt\x12\x19\n" +
+	"\bfired_at\x18\a \x01(\x03R\afiredAt\x12\x1f\n" +
+	"\vresolved_at\x18\b \x01(\x03R\n" +
+	"resolvedAt\x12\x1a\n" +
+	"\bsilenced\x18\t \x01(\bR\bsilenced\x12\x1f\n" +
+	"\vsilenced_by\x18\n" +
+	" \x03(\tR\n" +
+	"silencedBy\x125\n" +
+	"\facknowledged\x18\v \x01(\v2\x11.metrics.AlertAckR\facknowledged\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"L\n" +
+	"\bAlertAck\x12\x16\n" +
+	"\x06author\x18\x01 \x01(\tR\x06author\x12\x18\n" +
+	"\acomment\x18\x02 \x01(\tR\acomment\x12\x0e\n" +
+	"\x02at\x18\x03 \x01(\x03R\x02at\"\x13\n" +
+	"\x11ListAlertsRequest\"<\n" +
+	"\x12ListAlertsResponse\x12&\n" +
+	"\x06alerts\x18\x01 \x03(\v2\x0e.metrics.AlertR\x06alerts\"_\n" +
+	"\x17AcknowledgeAlertRequest\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x16\n" +
+	"\x06author\x18\x02 \x01(\tR\x06author\x12\x18\n" +
+	"\acomment\x18\x03 \x01(\tR\acomment\"@\n" +
+	"\x18AcknowledgeAlertResponse\x12$\n" +
+	"\x05alert\x18\x01 \x01(\v2\x0e.metrics.AlertR\x05alert\"\xbf\x01\n" +
+	"\aSilence\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tmetric_id\x18\x02 \x01(\tR\bmetricId\x12\x1f\n" +
+	"\vmetric_type\x18\x03 \x01(\tR\n" +
+	"metricType\x12\x1b\n" +
+	"\tstarts_at\x18\x04 \x01(\x03R\bstartsAt\x12\x17\n" +
+	"\aends_at\x18\x05 \x01(\x03R\x06endsAt\x12\x16\n" +
+	"\x06author\x18\x06 \x01(\tR\x06author\x12\x18\n" +
+	"\acomment\x18\a \x01(\tR\acomment\"B\n" +
+	"\x14CreateSilenceRequest\x12*\n" +
+	"\asilence\x18\x01 \x01(\v2\x10.metrics.SilenceR\asilence\"C\n" +
+	"\x15CreateSilenceResponse\x12*\n" +
+	"\asilence\x18\x01 \x01(\v2\x10.metrics.SilenceR\asilence\"\x15\n" +
+	"\x13ListSilencesRequest\"D\n" +
+	"\x14ListSilencesResponse\x12,\n" +
+	"\bsilences\x18\x01 \x03(\v2\x10.metrics.SilenceR\bsilences\"&\n" +
+	"\x14DeleteSilenceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x17\n" +
+	"\x15DeleteSilenceResponse\"O\n" +
+	"\x12RemoteWriteRequest\x129\n" +
+	"\n" +
+	"timeseries\x18\x01 \x03(\v2\x19.metrics.RemoteTimeSeriesR\n" +
+	"timeseries\"q\n" +
+	"\x10RemoteTimeSeries\x12,\n" +
+	"\x06labels\x18\x01 \x03(\v2\x14.metrics.RemoteLabelR\x06labels\x12/\n" +
+	"\asamples\x18\x02 \x03(\v2\x15.metrics.RemoteSampleR\asamples\"7\n" +
+	"\vRemoteLabel\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"B\n" +
+	"\fRemoteSample\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x01R\x05value\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp2\x80\x06\n" +
+	"\x0eMetricsService\x12H\n" +
+	"\vBatchUpdate\x12\x1b.metrics.BatchUpdateRequest\x1a\x1c.metrics.BatchUpdateResponse\x123\n" +
+	"\x04Ping\x12\x14.metrics.PingRequest\x1a\x15.metrics.PingResponse\x12H\n" +
+	"\vListMetrics\x12\x1b.metrics.ListMetricsRequest\x1a\x1c.metrics.ListMetricsResponse\x12K\n" +
+	"\fDeleteMetric\x12\x1c.metrics.DeleteMetricRequest\x1a\x1d.metrics.DeleteMetricResponse\x12K\n" +
+	"\fResetCounter\x12\x1c.metrics.ResetCounterRequest\x1a\x1d.metrics.ResetCounterResponse\x12E\n" +
+	"\n" +
+	"ListAlerts\x12\x1a.metrics.ListAlertsRequest\x1a\x1b.metrics.ListAlertsResponse\x12W\n" +
+	"\x10AcknowledgeAlert\x12 .metrics.AcknowledgeAlertRequest\x1a!.metrics.AcknowledgeAlertResponse\x12N\n" +
+	"\rCreateSilence\x12\x1d.metrics.CreateSilenceRequest\x1a\x1e.metrics.CreateSilenceResponse\x12K\n" +
+	"\fListSilences\x12\x1c.metrics.ListSilencesRequest\x1a\x1d.metrics.ListSilencesResponse\x12N\n" +
+	"\rDeleteSilence\x12\x1d.metrics.DeleteSilenceRequest\x1a\x1e.metrics.DeleteSilenceResponseB\x11Z\x0f/internal/protob\x06proto3"
 
 var (
 	file_internal_proto_proto_proto_rawDescOnce sync.Once
@@ -376,31 +1724,89 @@ func file_internal_proto_proto_proto_rawDescGZIP() []byte {
 	return file_internal_proto_proto_proto_rawDescData
 }
 
-var file_internal_proto_proto_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_internal_proto_proto_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
 var file_internal_proto_proto_proto_goTypes = []any{
-	(*Metric)(nil),              // 0: metrics.Metric
-	(*BatchUpdateRequest)(nil),  // 1: metrics.BatchUpdateRequest
-	(*BatchUpdateResponse)(nil), // 2: metrics.BatchUpdateResponse
-	(*PingRequest)(nil),         // 3: metrics.PingRequest
-	(*PingResponse)(nil),        // 4: metrics.PingResponse
-	(*ListMetricsRequest)(nil),  // 5: metrics.ListMetricsRequest
-	(*ListMetricsResponse)(nil), // 6: metrics.ListMetricsResponse
+	(*Metric)(nil),                   // 0: metrics.Metric
+	(*Histogram)(nil),                // 1: metrics.Histogram
+	(*Summary)(nil),                  // 2: metrics.Summary
+	(*BatchUpdateRequest)(nil),       // 3: metrics.BatchUpdateRequest
+	(*BatchUpdateResponse)(nil),      // 4: metrics.BatchUpdateResponse
+	(*PingRequest)(nil),              // 5: metrics.PingRequest
+	(*PingResponse)(nil),             // 6: metrics.PingResponse
+	(*ListMetricsRequest)(nil),       // 7: metrics.ListMetricsRequest
+	(*ListMetricsResponse)(nil),      // 8: metrics.ListMetricsResponse
+	(*DeleteMetricRequest)(nil),      // 9: metrics.DeleteMetricRequest
+	(*DeleteMetricResponse)(nil),     // 10: metrics.DeleteMetricResponse
+	(*ResetCounterRequest)(nil),      // 11: metrics.ResetCounterRequest
+	(*ResetCounterResponse)(nil),     // 12: metrics.ResetCounterResponse
+	(*Alert)(nil),                    // 13: metrics.Alert
+	(*AlertAck)(nil),                 // 14: metrics.AlertAck
+	(*ListAlertsRequest)(nil),        // 15: metrics.ListAlertsRequest
+	(*ListAlertsResponse)(nil),       // 16: metrics.ListAlertsResponse
+	(*AcknowledgeAlertRequest)(nil),  // 17: metrics.AcknowledgeAlertRequest
+	(*AcknowledgeAlertResponse)(nil), // 18: metrics.AcknowledgeAlertResponse
+	(*Silence)(nil),                  // 19: metrics.Silence
+	(*CreateSilenceRequest)(nil),     // 20: metrics.CreateSilenceRequest
+	(*CreateSilenceResponse)(nil),    // 21: metrics.CreateSilenceResponse
+	(*ListSilencesRequest)(nil),      // 22: metrics.ListSilencesRequest
+	(*ListSilencesResponse)(nil),     // 23: metrics.ListSilencesResponse
+	(*DeleteSilenceRequest)(nil),     // 24: metrics.DeleteSilenceRequest
+	(*DeleteSilenceResponse)(nil),    // 25: metrics.DeleteSilenceResponse
+	(*RemoteWriteRequest)(nil),       // 26: metrics.RemoteWriteRequest
+	(*RemoteTimeSeries)(nil),         // 27: metrics.RemoteTimeSeries
+	(*RemoteLabel)(nil),              // 28: metrics.RemoteLabel
+	(*RemoteSample)(nil),             // 29: metrics.RemoteSample
+	nil,                              // 30: metrics.Metric.LabelsEntry
+	nil,                              // 31: metrics.Summary.QuantilesEntry
+	nil,                              // 32: metrics.DeleteMetricRequest.LabelsEntry
+	nil,                              // 33: metrics.ResetCounterRequest.LabelsEntry
+	nil,                              // 34: metrics.Alert.LabelsEntry
 }
 var file_internal_proto_proto_proto_depIdxs = []int32{
-	0, // 0: metrics.BatchUpdateRequest.metrics:type_name -> metrics.Metric
-	0, // 1: metrics.BatchUpdateResponse.metrics:type_name -> metrics.Metric
-	0, // 2: metrics.ListMetricsResponse.metrics:type_name -> metrics.Metric
-	1, // 3: metrics.MetricsService.BatchUpdate:input_type -> metrics.BatchUpdateRequest
-	3, // 4: metrics.MetricsService.Ping:input_type -> metrics.PingRequest
-	5, // 5: metrics.MetricsService.ListMetrics:input_type -> metrics.ListMetricsRequest
-	2, // 6: metrics.MetricsService.BatchUpdate:output_type -> metrics.BatchUpdateResponse
-	4, // 7: metrics.MetricsService.Ping:output_type -> metrics.PingResponse
-	6, // 8: metrics.MetricsService.ListMetrics:output_type -> metrics.ListMetricsResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	30, // 0: metrics.Metric.labels:type_name -> metrics.Metric.LabelsEntry
+	1,  // 1: metrics.Metric.histogram:type_name -> metrics.Histogram
+	2,  // 2: metrics.Metric.summary:type_name -> metrics.Summary
+	31, // 3: metrics.Summary.quantiles:type_name -> metrics.Summary.QuantilesEntry
+	0,  // 4: metrics.BatchUpdateRequest.metrics:type_name -> metrics.Metric
+	0,  // 5: metrics.BatchUpdateResponse.metrics:type_name -> metrics.Metric
+	0,  // 6: metrics.ListMetricsResponse.metrics:type_name -> metrics.Metric
+	32, // 7: metrics.DeleteMetricRequest.labels:type_name -> metrics.DeleteMetricRequest.LabelsEntry
+	33, // 8: metrics.ResetCounterRequest.labels:type_name -> metrics.ResetCounterRequest.LabelsEntry
+	34, // 9: metrics.Alert.labels:type_name -> metrics.Alert.LabelsEntry
+	14, // 10: metrics.Alert.acknowledged:type_name -> metrics.AlertAck
+	13, // 11: metrics.ListAlertsResponse.alerts:type_name -> metrics.Alert
+	13, // 12: metrics.AcknowledgeAlertResponse.alert:type_name -> metrics.Alert
+	19, // 13: metrics.CreateSilenceRequest.silence:type_name -> metrics.Silence
+	19, // 14: metrics.CreateSilenceResponse.silence:type_name -> metrics.Silence
+	19, // 15: metrics.ListSilencesResponse.silences:type_name -> metrics.Silence
+	27, // 16: metrics.RemoteWriteRequest.timeseries:type_name -> metrics.RemoteTimeSeries
+	28, // 17: metrics.RemoteTimeSeries.labels:type_name -> metrics.RemoteLabel
+	29, // 18: metrics.RemoteTimeSeries.samples:type_name -> metrics.RemoteSample
+	3,  // 19: metrics.MetricsService.BatchUpdate:input_type -> metrics.BatchUpdateRequest
+	5,  // 20: metrics.MetricsService.Ping:input_type -> metrics.PingRequest
+	7,  // 21: metrics.MetricsService.ListMetrics:input_type -> metrics.ListMetricsRequest
+	9,  // 22: metrics.MetricsService.DeleteMetric:input_type -> metrics.DeleteMetricRequest
+	11, // 23: metrics.MetricsService.ResetCounter:input_type -> metrics.ResetCounterRequest
+	15, // 24: metrics.MetricsService.ListAlerts:input_type -> metrics.ListAlertsRequest
+	17, // 25: metrics.MetricsService.AcknowledgeAlert:input_type -> metrics.AcknowledgeAlertRequest
+	20, // 26: metrics.MetricsService.CreateSilence:input_type -> metrics.CreateSilenceRequest
+	22, // 27: metrics.MetricsService.ListSilences:input_type -> metrics.ListSilencesRequest
+	24, // 28: metrics.MetricsService.DeleteSilence:input_type -> metrics.DeleteSilenceRequest
+	4,  // 29: metrics.MetricsService.BatchUpdate:output_type -> metrics.BatchUpdateResponse
+	6,  // 30: metrics.MetricsService.Ping:output_type -> metrics.PingResponse
+	8,  // 31: metrics.MetricsService.ListMetrics:output_type -> metrics.ListMetricsResponse
+	10, // 32: metrics.MetricsService.DeleteMetric:output_type -> metrics.DeleteMetricResponse
+	12, // 33: metrics.MetricsService.ResetCounter:output_type -> metrics.ResetCounterResponse
+	16, // 34: metrics.MetricsService.ListAlerts:output_type -> metrics.ListAlertsResponse
+	18, // 35: metrics.MetricsService.AcknowledgeAlert:output_type -> metrics.AcknowledgeAlertResponse
+	21, // 36: metrics.MetricsService.CreateSilence:output_type -> metrics.CreateSilenceResponse
+	23, // 37: metrics.MetricsService.ListSilences:output_type -> metrics.ListSilencesResponse
+	25, // 38: metrics.MetricsService.DeleteSilence:output_type -> metrics.DeleteSilenceResponse
+	29, // [29:39] is the sub-list for method output_type
+	19, // [19:29] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_internal_proto_proto_proto_init() }
@@ -415,7 +1821,7 @@ func file_internal_proto_proto_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_proto_proto_proto_rawDesc), len(file_internal_proto_proto_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   35,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// NewAdminRouter returns the handler of the admin listener. It is meant for
+// operators only, so it has none of the middlewares of the metrics API.
+func NewAdminRouter(store Storager) http.Handler {
+	r := chi.NewRouter()
+
+	bh := BaseHandler{store: store, logger: logger.Get()}
+
+	r.Get("/metrics", bh.SelfMetricsHandler())
+
+	return r
+}
+
+// StartAdminServer serves the admin router on cfg.AdminAddress.
+func StartAdminServer(store Storager, cfg *config, serverErrChan chan<- error) (*http.Server, error) {
+	listener, err := net.Listen("tcp", cfg.AdminAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen admin on %s: %w", cfg.AdminAddress, err)
+	}
+
+	srv := &http.Server{
+		Addr:    cfg.AdminAddress,
+		Handler: NewAdminRouter(store),
+	}
+
+	go func() {
+		logger.Get().Info("Admin server starting", zap.String("addr", cfg.AdminAddress))
+		err := srv.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Get().Error("Admin server failed", zap.Error(err))
+			serverErrChan <- err
+		}
+	}()
+
+	return srv, nil
+}
+
+func StopAdminServer(srv *http.Server, shutdownCtx context.Context) {
+	if srv == nil {
+		return
+	}
+	logger.Get().Info("Stopping admin server...")
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Get().Error("Admin server shutdown error", zap.Error(err))
+	}
+}
@@ -0,0 +1,403 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/etoneja/go-metrics/internal/models"
+	"go.uber.org/zap"
+)
+
+// alertRuleState is the evaluation state of one rule.
+type alertRuleState struct {
+	// alert is nil while the condition does not hold and the rule never fired.
+	alert *models.Alert
+
+	// Previous counter value of rate rules.
+	prevValue float64
+	prevTime  time.Time
+	hasPrev   bool
+
+	// muted is set when a change of the alert was not notified because the
+	// alert was silenced, it is notified once the silence ends.
+	muted bool
+}
+
+// AlertEngine periodically evaluates alerting rules against the storage and
+// tracks alerts: pending while the condition holds for less than the rule
+// requires, then firing, then resolved once it stops holding.
+//
+// Silences kept by a storage implementing SilenceStorer mute alerts on
+// matching metrics: silenced alerts are listed flagged but not notified.
+// Expired silences are removed on evaluation.
+type AlertEngine struct {
+	store    Storager
+	rules    []*AlertRule
+	notifier *AlertNotifier
+	mu       sync.Mutex
+	states   []alertRuleState
+	silences []models.Silence
+
+	started  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAlertEngine returns an engine for rules, it evaluates them once started.
+func NewAlertEngine(store Storager, rules []*AlertRule) *AlertEngine {
+	return &AlertEngine{
+		store:    store,
+		rules:    rules,
+		states:   make([]alertRuleState, len(rules)),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start evaluates the rules every period seconds until Stop. Without rules
+// there is nothing to evaluate and it does nothing, expired silences are
+// then removed by Silences and AddSilence.
+func (ae *AlertEngine) Start(period uint) {
+	if len(ae.rules) == 0 {
+		return
+	}
+	ae.started = true
+
+	ticker := time.NewTicker(time.Second * time.Duration(period))
+
+	go func() {
+		defer close(ae.doneChan)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				ae.evaluate(context.Background(), now)
+			case <-ae.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// SetNotifier makes the engine send alerts that fired or resolved to n, it
+// must be called before Start.
+func (ae *AlertEngine) SetNotifier(n *AlertNotifier) {
+	ae.notifier = n
+}
+
+func (ae *AlertEngine) Stop() {
+	ae.stopOnce.Do(func() {
+		close(ae.stopChan)
+	})
+	if ae.started {
+		<-ae.doneChan
+	}
+}
+
+// evaluate evaluates every rule at now. Alerts that fired or resolved are
+// sent to the notifier together.
+func (ae *AlertEngine) evaluate(ctx context.Context, now time.Time) {
+	if err := ae.loadSilences(ctx, now); err != nil && !errors.Is(err, errSilencesNotSupported) {
+		// Previously loaded silences stay in effect.
+		logger.Get().Error("Failed to load silences", zap.Error(err))
+	}
+
+	var changed []models.Alert
+	for i, rule := range ae.rules {
+		value, ok, err := ae.value(ctx, rule, &ae.states[i], now)
+		if err != nil {
+			// The state is kept as is, a storage failure is not a
+			// change of the metric.
+			logger.Get().Error("Failed to evaluate alert rule",
+				zap.String("rule", rule.Name),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		ae.mu.Lock()
+		state := &ae.states[i]
+		if state.transition(rule, ok && rule.holds(value), value, now) || state.muted {
+			switch {
+			case state.alert == nil:
+				state.muted = false
+			case state.alert.State == models.AlertStatePending:
+				// Only firing and resolved alerts are notified.
+			case len(ae.silencedBy(rule, now)) > 0:
+				state.muted = true
+			default:
+				state.muted = false
+				changed = append(changed, *state.alert)
+			}
+		}
+		ae.mu.Unlock()
+	}
+
+	if ae.notifier != nil {
+		ae.notifier.Notify(changed)
+	}
+}
+
+// value returns the current value of the metric of a rule, ok is false if
+// there is no value, e.g. the metric does not exist or a rate has no
+// previous value yet.
+func (ae *AlertEngine) value(ctx context.Context, rule *AlertRule, state *alertRuleState, now time.Time) (float64, bool, error) {
+	var value float64
+	var err error
+	switch rule.MType {
+	case common.MetricTypeGauge:
+		value, err = ae.store.GetGauge(ctx, rule.Key)
+	default:
+		var counter int64
+		counter, err = ae.store.GetCounter(ctx, rule.Key)
+		value = float64(counter)
+	}
+	if errors.Is(err, ErrNotFound) {
+		state.hasPrev = false
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if !rule.Rate {
+		return value, true, nil
+	}
+
+	prevValue, prevTime, hasPrev := state.prevValue, state.prevTime, state.hasPrev
+	state.prevValue, state.prevTime, state.hasPrev = value, now, true
+	elapsed := now.Sub(prevTime).Seconds()
+	if !hasPrev || elapsed <= 0 {
+		return 0, false, nil
+	}
+	increase := value - prevValue
+	if increase < 0 {
+		// The counter was reset, it counted value since.
+		increase = value
+	}
+	return increase / elapsed, true, nil
+}
+
+// transition moves the alert of a rule to the state for the condition at now
+// and reports whether the alert fired or resolved.
+func (s *alertRuleState) transition(rule *AlertRule, holds bool, value float64, now time.Time) bool {
+	active := s.alert != nil && s.alert.State != models.AlertStateResolved
+
+	if !holds {
+		switch {
+		case !active:
+		case s.alert.State == models.AlertStateFiring:
+			s.alert.State = models.AlertStateResolved
+			s.alert.ResolvedAt = &now
+			return true
+		default:
+			s.alert = nil
+		}
+		return false
+	}
+
+	if !active {
+		s.alert = &models.Alert{
+			Rule:     rule.Name,
+			Expr:     rule.Expr,
+			State:    models.AlertStatePending,
+			Labels:   rule.Labels,
+			ActiveAt: now,
+		}
+	}
+	s.alert.Value = value
+	if s.alert.State == models.AlertStatePending && now.Sub(s.alert.ActiveAt) >= rule.For {
+		s.alert.State = models.AlertStateFiring
+		s.alert.FiredAt = &now
+		return true
+	}
+	return false
+}
+
+// Alerts returns pending and firing alerts ordered by rule name.
+func (ae *AlertEngine) Alerts() []models.Alert {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+
+	now := time.Now()
+	alerts := make([]models.Alert, 0)
+	// Only alerts are guarded by mu, rate state belongs to evaluate.
+	for i := range ae.states {
+		alert := ae.states[i].alert
+		if alert == nil || alert.State == models.AlertStateResolved {
+			continue
+		}
+		alerts = append(alerts, ae.flagged(ae.rules[i], alert, now))
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Rule < alerts[j].Rule
+	})
+	return alerts
+}
+
+// Acknowledge marks the pending or firing alert of a rule as taken care of
+// by author and returns it. The acknowledgement is kept with the alert state
+// in memory and ends with the alert: once the alert resolves, a new
+// activation of the rule is unacknowledged again. It does not mute
+// notifications, silences do.
+func (ae *AlertEngine) Acknowledge(rule, author, comment string) (models.Alert, error) {
+	if author == "" {
+		return models.Alert{}, fmt.Errorf("%w: acknowledgement has no author", errInvalidAck)
+	}
+
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+
+	now := time.Now()
+	for i, r := range ae.rules {
+		if r.Name != rule {
+			continue
+		}
+		alert := ae.states[i].alert
+		if alert == nil || alert.State == models.AlertStateResolved {
+			break
+		}
+		alert.Acknowledged = &models.AlertAck{Author: author, Comment: comment, At: now}
+		return ae.flagged(r, alert, now), nil
+	}
+	return models.Alert{}, fmt.Errorf("alert %s: %w", rule, ErrNotFound)
+}
+
+// flagged returns a copy of the alert of rule flagged by the silences muting
+// it at now, the caller must hold mu.
+func (ae *AlertEngine) flagged(rule *AlertRule, alert *models.Alert, now time.Time) models.Alert {
+	flagged := *alert
+	flagged.SilencedBy = ae.silencedBy(rule, now)
+	flagged.Silenced = len(flagged.SilencedBy) > 0
+	return flagged
+}
+
+// silencedBy returns the IDs of silences muting alerts of rule at now, the
+// caller must hold mu.
+func (ae *AlertEngine) silencedBy(rule *AlertRule, now time.Time) []string {
+	var ids []string
+	for i := range ae.silences {
+		if ae.silences[i].Active(now) && ae.silences[i].Matches(rule.MType, rule.Key) {
+			ids = append(ids, ae.silences[i].ID)
+		}
+	}
+	return ids
+}
+
+// loadSilences reads silences from the storage and removes the expired ones.
+func (ae *AlertEngine) loadSilences(ctx context.Context, now time.Time) error {
+	silenceStorer, ok := ae.store.(SilenceStorer)
+	if !ok {
+		return errSilencesNotSupported
+	}
+	silences, err := silenceStorer.GetSilences(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := silences[:0]
+	for _, silence := range silences {
+		if !silence.Expired(now) {
+			current = append(current, silence)
+			continue
+		}
+		err = silenceStorer.DeleteSilence(ctx, silence.ID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			logger.Get().Error("Failed to delete expired silence",
+				zap.String("silence", silence.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	ae.mu.Lock()
+	ae.silences = current
+	ae.mu.Unlock()
+	return nil
+}
+
+// Silences returns silences that have not expired yet ordered by ID. Expired
+// silences are removed from the storage, so they expire without rules to
+// evaluate.
+func (ae *AlertEngine) Silences(ctx context.Context) ([]models.Silence, error) {
+	if err := ae.loadSilences(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+
+	ae.mu.Lock()
+	silences := slices.Clone(ae.silences)
+	ae.mu.Unlock()
+	slices.SortFunc(silences, func(a, b models.Silence) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return silences, nil
+}
+
+// AddSilence stores s with a new ID and returns it. A silence without a
+// start time starts now. Expired silences are removed from the storage
+// first, like by Silences.
+func (ae *AlertEngine) AddSilence(ctx context.Context, s models.Silence) (models.Silence, error) {
+	silenceStorer, ok := ae.store.(SilenceStorer)
+	if !ok {
+		return models.Silence{}, errSilencesNotSupported
+	}
+
+	now := time.Now()
+	if err := ae.loadSilences(ctx, now); err != nil {
+		return models.Silence{}, err
+	}
+	if s.StartsAt.IsZero() {
+		s.StartsAt = now
+	}
+	if err := s.Validate(); err != nil {
+		return models.Silence{}, fmt.Errorf("%w: %w", errInvalidSilence, err)
+	}
+	if s.Expired(now) {
+		return models.Silence{}, fmt.Errorf("%w: silence already ended", errInvalidSilence)
+	}
+	s.ID = newSilenceID()
+
+	if err := silenceStorer.SaveSilence(ctx, s); err != nil {
+		return models.Silence{}, err
+	}
+
+	ae.mu.Lock()
+	ae.silences = append(ae.silences, s)
+	ae.mu.Unlock()
+	return s, nil
+}
+
+// DeleteSilence removes a silence, alerts it muted are notified on the next
+// evaluation.
+func (ae *AlertEngine) DeleteSilence(ctx context.Context, id string) error {
+	silenceStorer, ok := ae.store.(SilenceStorer)
+	if !ok {
+		return errSilencesNotSupported
+	}
+	if err := silenceStorer.DeleteSilence(ctx, id); err != nil {
+		return err
+	}
+
+	ae.mu.Lock()
+	ae.silences = slices.DeleteFunc(ae.silences, func(silence models.Silence) bool {
+		return silence.ID == id
+	})
+	ae.mu.Unlock()
+	return nil
+}
+
+func newSilenceID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
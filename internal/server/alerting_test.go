@@ -0,0 +1,579 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/etoneja/go-metrics/internal/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func mustParseAlertRule(t *testing.T, name, expr string) *AlertRule {
+	t.Helper()
+	rule, err := ParseAlertRule(name, expr)
+	require.NoError(t, err)
+	return rule
+}
+
+func TestAlertEngine_Lifecycle(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	rule := mustParseAlertRule(t, "HighHeap", "gauge HeapAlloc > 500MB for 2m")
+	rule.Labels = map[string]string{"severity": "page"}
+	ae := NewAlertEngine(store, []*AlertRule{rule})
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	ae.evaluate(ctx, start)
+	assert.Empty(t, ae.Alerts(), "missing metric does not alert")
+
+	_, err := store.SetGauge(ctx, "HeapAlloc", 600<<20)
+	require.NoError(t, err)
+	ae.evaluate(ctx, start.Add(time.Minute))
+	alerts := ae.Alerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, models.AlertStatePending, alerts[0].State)
+	assert.Equal(t, float64(600<<20), alerts[0].Value)
+	assert.Equal(t, start.Add(time.Minute), alerts[0].ActiveAt)
+	assert.Equal(t, map[string]string{"severity": "page"}, alerts[0].Labels)
+
+	ae.evaluate(ctx, start.Add(2*time.Minute))
+	assert.Equal(t, models.AlertStatePending, ae.Alerts()[0].State)
+
+	ae.evaluate(ctx, start.Add(3*time.Minute))
+	alerts = ae.Alerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, models.AlertStateFiring, alerts[0].State)
+	require.NotNil(t, alerts[0].FiredAt)
+	assert.Equal(t, start.Add(3*time.Minute), *alerts[0].FiredAt)
+
+	_, err = store.SetGauge(ctx, "HeapAlloc", 100<<20)
+	require.NoError(t, err)
+	ae.evaluate(ctx, start.Add(4*time.Minute))
+	assert.Empty(t, ae.Alerts())
+	assert.Equal(t, models.AlertStateResolved, ae.states[0].alert.State)
+	assert.Equal(t, start.Add(4*time.Minute), *ae.states[0].alert.ResolvedAt)
+
+	_, err = store.SetGauge(ctx, "HeapAlloc", 600<<20)
+	require.NoError(t, err)
+	ae.evaluate(ctx, start.Add(5*time.Minute))
+	alerts = ae.Alerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, models.AlertStatePending, alerts[0].State, "a resolved alert starts over")
+	assert.Equal(t, start.Add(5*time.Minute), alerts[0].ActiveAt)
+}
+
+func TestAlertEngine_PendingIsDroppedWhenConditionStops(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	ae := NewAlertEngine(store, []*AlertRule{mustParseAlertRule(t, "Errors", "counter errors > 0 for 1m")})
+	now := time.Now()
+
+	_, err := store.IncrementCounter(ctx, "errors", 1)
+	require.NoError(t, err)
+	ae.evaluate(ctx, now)
+	require.Len(t, ae.Alerts(), 1)
+
+	require.NoError(t, store.ResetCounter(ctx, "errors"))
+	ae.evaluate(ctx, now.Add(30*time.Second))
+	assert.Empty(t, ae.Alerts())
+	assert.Nil(t, ae.states[0].alert)
+}
+
+func TestAlertEngine_Rate(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	ae := NewAlertEngine(store, []*AlertRule{mustParseAlertRule(t, "AgentDown", "rate(counter PollCount) == 0")})
+	now := time.Now()
+
+	_, err := store.IncrementCounter(ctx, "PollCount", 10)
+	require.NoError(t, err)
+	ae.evaluate(ctx, now)
+	assert.Empty(t, ae.Alerts(), "no rate without a previous value")
+
+	_, err = store.IncrementCounter(ctx, "PollCount", 20)
+	require.NoError(t, err)
+	ae.evaluate(ctx, now.Add(10*time.Second))
+	assert.Empty(t, ae.Alerts())
+
+	ae.evaluate(ctx, now.Add(20*time.Second))
+	alerts := ae.Alerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, models.AlertStateFiring, alerts[0].State)
+	assert.Equal(t, 0.0, alerts[0].Value)
+
+	// A reset counter counts from zero.
+	require.NoError(t, store.ResetCounter(ctx, "PollCount"))
+	_, err = store.IncrementCounter(ctx, "PollCount", 5)
+	require.NoError(t, err)
+	ae.evaluate(ctx, now.Add(30*time.Second))
+	assert.Empty(t, ae.Alerts())
+}
+
+func TestAlertEngine_StorageErrorKeepsState(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	failing := &failingGetStorage{Storager: store}
+	ae := NewAlertEngine(failing, []*AlertRule{mustParseAlertRule(t, "High", "gauge g > 1")})
+
+	_, err := store.SetGauge(ctx, "g", 2)
+	require.NoError(t, err)
+	ae.evaluate(ctx, time.Now())
+	require.Len(t, ae.Alerts(), 1)
+
+	failing.fail = true
+	ae.evaluate(ctx, time.Now())
+	assert.Len(t, ae.Alerts(), 1)
+}
+
+// failingGetStorage fails reads of gauges when fail is set.
+type failingGetStorage struct {
+	Storager
+	fail bool
+}
+
+func (s *failingGetStorage) GetGauge(ctx context.Context, key string) (float64, error) {
+	if s.fail {
+		return 0, errors.New("connection refused")
+	}
+	return s.Storager.GetGauge(ctx, key)
+}
+
+func TestAlertEngine_StartStop(t *testing.T) {
+	store := NewMemStorage()
+	_, err := store.SetGauge(context.Background(), "g", 2)
+	require.NoError(t, err)
+
+	ae := NewAlertEngine(store, []*AlertRule{mustParseAlertRule(t, "High", "gauge g > 1")})
+	ae.Start(1)
+	assert.Eventually(t, func() bool {
+		return len(ae.Alerts()) == 1
+	}, 3*time.Second, 50*time.Millisecond)
+	ae.Stop()
+	ae.Stop()
+
+	// Without rules nothing is started.
+	empty := NewAlertEngine(store, nil)
+	empty.Start(1)
+	empty.Stop()
+}
+
+func TestAlertsHandler(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	_, err := store.SetGauge(ctx, "g", 2)
+	require.NoError(t, err)
+	ae := NewAlertEngine(store, []*AlertRule{
+		mustParseAlertRule(t, "Low", "gauge g < 1"),
+		mustParseAlertRule(t, "High", "gauge g > 1 for 1m"),
+	})
+	ae.evaluate(ctx, time.Now())
+
+	health := NewHealth(store)
+	server := httptest.NewServer(NewRouter(store, &config{}, health, ae))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/alerts")
+	require.NoError(t, err)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Logf("Failed to close response body: %v", err)
+		}
+	}()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var alerts []models.Alert
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&alerts))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "High", alerts[0].Rule)
+	assert.Equal(t, models.AlertStatePending, alerts[0].State)
+}
+
+func TestGRPCServer_ListAlerts(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	_, err := store.SetGauge(ctx, "g", 2)
+	require.NoError(t, err)
+	ae := NewAlertEngine(store, []*AlertRule{mustParseAlertRule(t, "High", "gauge g > 1")})
+	now := time.Now()
+	ae.evaluate(ctx, now)
+
+	server := NewGRPCServer(store, ae, zaptest.NewLogger(t))
+	resp, err := server.ListAlerts(ctx, &proto.ListAlertsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Alerts, 1)
+	assert.Equal(t, "High", resp.Alerts[0].Rule)
+	assert.Equal(t, string(models.AlertStateFiring), resp.Alerts[0].State)
+	assert.Equal(t, 2.0, resp.Alerts[0].Value)
+	assert.Equal(t, now.UnixMilli(), resp.Alerts[0].FiredAt)
+	assert.Zero(t, resp.Alerts[0].ResolvedAt)
+}
+
+func TestAlertEngine_Silences(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	_, err := store.SetGauge(ctx, `cpu{host="web1"}`, 2)
+	require.NoError(t, err)
+	ae := NewAlertEngine(store, []*AlertRule{
+		mustParseAlertRule(t, "HighCPU", `gauge cpu{host="web1"} > 1`),
+	})
+
+	now := time.Now()
+	byName, err := ae.AddSilence(ctx, models.Silence{MetricID: "cpu", EndsAt: now.Add(time.Hour), Author: "alice"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, byName.ID)
+	assert.False(t, byName.StartsAt.Before(now), "silences start now by default")
+	other, err := ae.AddSilence(ctx, models.Silence{MetricID: "cpu", MType: "counter", EndsAt: now.Add(time.Hour), Author: "bob"})
+	require.NoError(t, err)
+
+	ae.evaluate(ctx, time.Now())
+	alerts := ae.Alerts()
+	require.Len(t, alerts, 1)
+	assert.True(t, alerts[0].Silenced)
+	assert.Equal(t, []string{byName.ID}, alerts[0].SilencedBy)
+
+	silences, err := ae.Silences(ctx)
+	require.NoError(t, err)
+	require.Len(t, silences, 2)
+	assert.Less(t, silences[0].ID, silences[1].ID, "silences are ordered by ID")
+
+	require.NoError(t, ae.DeleteSilence(ctx, byName.ID))
+	alerts = ae.Alerts()
+	require.Len(t, alerts, 1)
+	assert.False(t, alerts[0].Silenced)
+	assert.ErrorIs(t, ae.DeleteSilence(ctx, byName.ID), ErrNotFound)
+
+	silences, err = ae.Silences(ctx)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	assert.Equal(t, other.ID, silences[0].ID)
+}
+
+func TestAlertEngine_SilenceValidation(t *testing.T) {
+	ae := NewAlertEngine(NewMemStorage(), nil)
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		silence models.Silence
+	}{
+		{"no metric", models.Silence{EndsAt: now.Add(time.Hour), Author: "alice"}},
+		{"no author", models.Silence{MetricID: "cpu", EndsAt: now.Add(time.Hour)}},
+		{"bad type", models.Silence{MetricID: "cpu", MType: "meter", EndsAt: now.Add(time.Hour), Author: "alice"}},
+		{"ends before start", models.Silence{MetricID: "cpu", StartsAt: now, EndsAt: now.Add(-time.Minute), Author: "alice"}},
+		{"already ended", models.Silence{MetricID: "cpu", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(-time.Minute), Author: "alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ae.AddSilence(ctx, tt.silence)
+			assert.ErrorIs(t, err, errInvalidSilence)
+		})
+	}
+
+	unsupported := NewAlertEngine(&failingGetStorage{Storager: NewMemStorage()}, nil)
+	_, err := unsupported.AddSilence(ctx, models.Silence{MetricID: "cpu", EndsAt: now.Add(time.Hour), Author: "alice"})
+	assert.ErrorIs(t, err, errSilencesNotSupported)
+}
+
+func TestAlertEngine_SilenceExpires(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	start := time.Now()
+	require.NoError(t, store.SaveSilence(ctx, models.Silence{
+		ID:       "s1",
+		MetricID: "g",
+		StartsAt: start.Add(-time.Hour),
+		EndsAt:   start.Add(time.Minute),
+		Author:   "alice",
+	}))
+	ae := NewAlertEngine(store, []*AlertRule{mustParseAlertRule(t, "High", "gauge g > 1")})
+
+	ae.evaluate(ctx, start)
+	silences, err := store.GetSilences(ctx)
+	require.NoError(t, err)
+	assert.Len(t, silences, 1)
+
+	ae.evaluate(ctx, start.Add(2*time.Minute))
+	silences, err = store.GetSilences(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, silences, "expired silences are removed")
+}
+
+func TestAlertEngine_SilenceExpiresWithoutRules(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	now := time.Now()
+	expired := func(id string) models.Silence {
+		return models.Silence{ID: id, MetricID: "g", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(-time.Minute), Author: "alice"}
+	}
+	require.NoError(t, store.SaveSilence(ctx, expired("s1")))
+	ae := NewAlertEngine(store, nil)
+	ae.Start(1)
+	defer ae.Stop()
+
+	silences, err := ae.Silences(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, silences)
+	stored, err := store.GetSilences(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, stored, "listing removes expired silences")
+
+	require.NoError(t, store.SaveSilence(ctx, expired("s2")))
+	added, err := ae.AddSilence(ctx, models.Silence{MetricID: "g", EndsAt: now.Add(time.Hour), Author: "alice"})
+	require.NoError(t, err)
+	stored, err = store.GetSilences(ctx)
+	require.NoError(t, err)
+	require.Len(t, stored, 1, "adding removes expired silences")
+	assert.Equal(t, added.ID, stored[0].ID)
+}
+
+func TestAlertEngine_SilencedAlertsAreNotNotified(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, "")
+	an := newTestAlertNotifier(t, []string{server.URL}, "", "")
+
+	store := NewMemStorage()
+	ae := NewAlertEngine(store, []*AlertRule{mustParseAlertRule(t, "High", "gauge g > 1")})
+	ae.SetNotifier(an)
+	now := time.Now()
+	silence, err := ae.AddSilence(t.Context(), models.Silence{MetricID: "g", StartsAt: now, EndsAt: now.Add(time.Hour), Author: "alice"})
+	require.NoError(t, err)
+
+	_, err = store.SetGauge(t.Context(), "g", 2)
+	require.NoError(t, err)
+	ae.evaluate(t.Context(), now)
+	an.flush()
+	assert.Empty(t, receiver.payloads())
+
+	// The firing alert is notified once the silence is gone.
+	require.NoError(t, ae.DeleteSilence(t.Context(), silence.ID))
+	ae.evaluate(t.Context(), now.Add(10*time.Second))
+	ae.evaluate(t.Context(), now.Add(20*time.Second))
+	an.flush()
+	payloads := receiver.payloads()
+	require.Len(t, payloads, 1)
+	require.Len(t, payloads[0].Alerts, 1)
+	assert.Equal(t, models.AlertStateFiring, payloads[0].Alerts[0].State)
+	assert.False(t, payloads[0].Alerts[0].Silenced)
+}
+
+func TestSilenceHandlers(t *testing.T) {
+	store := NewMemStorage()
+	ae := NewAlertEngine(store, nil)
+	server := httptest.NewServer(NewRouter(store, &config{}, NewHealth(store), ae))
+	defer server.Close()
+
+	do := func(method, path, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Logf("Failed to close response body: %v", err)
+			}
+		})
+		return resp
+	}
+
+	endsAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	resp := do(http.MethodPost, server.URL+"/silences", `{"metric_id": "HeapAlloc", "ends_at": "`+endsAt+`", "author": "alice", "comment": "load test"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created models.Silence
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, "alice", created.Author)
+
+	resp = do(http.MethodPost, server.URL+"/silences", `{"metric_id": "HeapAlloc", "ends_at": "`+endsAt+`"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = do(http.MethodPost, server.URL+"/silences", `{`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = do(http.MethodGet, server.URL+"/silences", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var silences []models.Silence
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&silences))
+	require.Len(t, silences, 1)
+	assert.Equal(t, created.ID, silences[0].ID)
+
+	resp = do(http.MethodDelete, server.URL+"/silences/"+created.ID, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do(http.MethodDelete, server.URL+"/silences/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	unsupported := &failingGetStorage{Storager: store}
+	unsupportedServer := httptest.NewServer(NewRouter(unsupported, &config{}, NewHealth(unsupported), NewAlertEngine(unsupported, nil)))
+	defer unsupportedServer.Close()
+	resp = do(http.MethodGet, unsupportedServer.URL+"/silences", "")
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+func TestGRPCServer_Silences(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	ae := NewAlertEngine(store, nil)
+	server := NewGRPCServer(store, ae, zaptest.NewLogger(t))
+	endsAt := time.Now().Add(time.Hour).UnixMilli()
+
+	created, err := server.CreateSilence(ctx, &proto.CreateSilenceRequest{Silence: &proto.Silence{
+		MetricId:   "PollCount",
+		MetricType: "counter",
+		EndsAt:     endsAt,
+		Author:     "alice",
+	}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.Silence.Id)
+	assert.NotZero(t, created.Silence.StartsAt)
+	assert.Equal(t, endsAt, created.Silence.EndsAt)
+
+	_, err = server.CreateSilence(ctx, &proto.CreateSilenceRequest{Silence: &proto.Silence{MetricId: "PollCount", EndsAt: endsAt}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.CreateSilence(ctx, &proto.CreateSilenceRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	listed, err := server.ListSilences(ctx, &proto.ListSilencesRequest{})
+	require.NoError(t, err)
+	require.Len(t, listed.Silences, 1)
+	assert.Equal(t, created.Silence.Id, listed.Silences[0].Id)
+
+	_, err = server.DeleteSilence(ctx, &proto.DeleteSilenceRequest{Id: created.Silence.Id})
+	require.NoError(t, err)
+	_, err = server.DeleteSilence(ctx, &proto.DeleteSilenceRequest{Id: created.Silence.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAlertEngine_Acknowledge(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	ae := NewAlertEngine(store, []*AlertRule{
+		mustParseAlertRule(t, "High", "gauge g > 1"),
+	})
+	now := time.Now()
+
+	_, err := ae.Acknowledge("High", "alice", "")
+	assert.ErrorIs(t, err, ErrNotFound, "no alert yet")
+	_, err = ae.Acknowledge("Missing", "alice", "")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = store.SetGauge(ctx, "g", 2)
+	require.NoError(t, err)
+	ae.evaluate(ctx, now)
+	_, err = ae.Acknowledge("High", "", "looking")
+	assert.ErrorIs(t, err, errInvalidAck)
+
+	acked, err := ae.Acknowledge("High", "alice", "looking")
+	require.NoError(t, err)
+	require.NotNil(t, acked.Acknowledged)
+	assert.Equal(t, "alice", acked.Acknowledged.Author)
+	assert.Equal(t, "looking", acked.Acknowledged.Comment)
+
+	ae.evaluate(ctx, now.Add(10*time.Second))
+	alerts := ae.Alerts()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, acked.Acknowledged, alerts[0].Acknowledged, "the acknowledgement stays while the alert fires")
+
+	// Once resolved, the next alert of the rule needs a new acknowledgement.
+	_, err = store.SetGauge(ctx, "g", 0)
+	require.NoError(t, err)
+	ae.evaluate(ctx, now.Add(20*time.Second))
+	_, err = ae.Acknowledge("High", "alice", "")
+	assert.ErrorIs(t, err, ErrNotFound, "resolved alerts cannot be acknowledged")
+	_, err = store.SetGauge(ctx, "g", 2)
+	require.NoError(t, err)
+	ae.evaluate(ctx, now.Add(30*time.Second))
+	alerts = ae.Alerts()
+	require.Len(t, alerts, 1)
+	assert.Nil(t, alerts[0].Acknowledged)
+}
+
+func TestAlertAckHandler(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	ae := NewAlertEngine(store, []*AlertRule{
+		mustParseAlertRule(t, "High", "gauge g > 1"),
+	})
+	server := httptest.NewServer(NewRouter(store, &config{}, NewHealth(store), ae))
+	defer server.Close()
+
+	post := func(path, body string) *http.Response {
+		t.Helper()
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Logf("Failed to close response body: %v", err)
+			}
+		})
+		return resp
+	}
+
+	resp := post("/alerts/High/ack", `{"author": "alice"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, err := store.SetGauge(ctx, "g", 2)
+	require.NoError(t, err)
+	ae.evaluate(ctx, time.Now())
+
+	resp = post("/alerts/High/ack", `{"comment": "no author"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = post("/alerts/High/ack", `{`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = post("/alerts/High/ack", `{"author": "alice", "comment": "looking"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var acked models.Alert
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&acked))
+	require.NotNil(t, acked.Acknowledged)
+	assert.Equal(t, "alice", acked.Acknowledged.Author)
+
+	getResp, err := http.Get(server.URL + "/alerts")
+	require.NoError(t, err)
+	defer func() { _ = getResp.Body.Close() }()
+	var alerts []models.Alert
+	require.NoError(t, json.NewDecoder(getResp.Body).Decode(&alerts))
+	require.Len(t, alerts, 1)
+	require.NotNil(t, alerts[0].Acknowledged)
+	assert.Equal(t, "looking", alerts[0].Acknowledged.Comment)
+}
+
+func TestGRPCServer_AcknowledgeAlert(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	ae := NewAlertEngine(store, []*AlertRule{
+		mustParseAlertRule(t, "High", "gauge g > 1"),
+	})
+	server := NewGRPCServer(store, ae, zaptest.NewLogger(t))
+
+	_, err := server.AcknowledgeAlert(ctx, &proto.AcknowledgeAlertRequest{Rule: "High", Author: "alice"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = store.SetGauge(ctx, "g", 2)
+	require.NoError(t, err)
+	ae.evaluate(ctx, time.Now())
+
+	_, err = server.AcknowledgeAlert(ctx, &proto.AcknowledgeAlertRequest{Rule: "High"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err := server.AcknowledgeAlert(ctx, &proto.AcknowledgeAlertRequest{Rule: "High", Author: "alice", Comment: "looking"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", resp.Alert.GetAcknowledged().GetAuthor())
+	assert.NotZero(t, resp.Alert.GetAcknowledged().GetAt())
+
+	list, err := server.ListAlerts(ctx, &proto.ListAlertsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Alerts, 1)
+	assert.Equal(t, "looking", list.Alerts[0].GetAcknowledged().GetComment())
+}
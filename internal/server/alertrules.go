@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+)
+
+// AlertRule is a threshold rule evaluated against stored metrics.
+//
+// Rules are written as "<metric> <op> <threshold> [for <duration>]" where
+// metric is "gauge <name>", "counter <name>" or "rate(counter <name>)", the
+// per-second increase of a counter between evaluations. Names may carry
+// labels like stored series, e.g. cpu{host="web1"}. Thresholds may have a
+// KB, MB, GB or TB suffix, powers of 1024 as memory sizes are reported:
+//
+//	gauge HeapAlloc > 500MB for 2m
+//	rate(counter PollCount) == 0 for 5m
+type AlertRule struct {
+	Name      string
+	Expr      string
+	Labels    map[string]string
+	MType     string
+	Key       string
+	Rate      bool
+	Op        string
+	Threshold float64
+	For       time.Duration
+}
+
+// alertRuleFile is the format of the rules file.
+type alertRuleFile struct {
+	Rules []struct {
+		Name   string            `json:"name"`
+		Expr   string            `json:"expr"`
+		Labels map[string]string `json:"labels"`
+	} `json:"rules"`
+}
+
+var alertExprRe = regexp.MustCompile(
+	`^(?:rate\(\s*(\w+)\s+(.+?)\s*\)|(\w+)\s+(.+?))\s+(>=|<=|==|!=|>|<)\s+(\S+)(?:\s+for\s+(\S+))?$`,
+)
+
+var alertThresholdUnits = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"KB", 1 << 10},
+	{"MB", 1 << 20},
+	{"GB", 1 << 30},
+	{"TB", 1 << 40},
+}
+
+// LoadAlertRules reads alerting rules from a JSON file like
+//
+//	{"rules": [{"name": "HighHeap", "expr": "gauge HeapAlloc > 500MB for 2m", "labels": {"severity": "page"}}]}
+//
+// An empty path means no rules.
+func LoadAlertRules(path string) ([]*AlertRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read alert rules file %s: %w", path, err)
+	}
+	var file alertRuleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse alert rules file %s: %w", path, err)
+	}
+
+	rules := make([]*AlertRule, 0, len(file.Rules))
+	names := make(map[string]bool, len(file.Rules))
+	for _, r := range file.Rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("alert rule %q has no name", r.Expr)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("duplicate alert rule %q", r.Name)
+		}
+		names[r.Name] = true
+
+		if err := models.ValidateLabels(r.Labels); err != nil {
+			return nil, fmt.Errorf("alert rule %q: %w", r.Name, err)
+		}
+		rule, err := ParseAlertRule(r.Name, r.Expr)
+		if err != nil {
+			return nil, err
+		}
+		rule.Labels = r.Labels
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseAlertRule parses the expression of a rule, see AlertRule.
+func ParseAlertRule(name, expr string) (*AlertRule, error) {
+	expr = strings.TrimSpace(expr)
+	match := alertExprRe.FindStringSubmatch(expr)
+	if match == nil {
+		return nil, fmt.Errorf("alert rule %q: invalid expression %q", name, expr)
+	}
+
+	rule := &AlertRule{
+		Name: name,
+		Expr: expr,
+		Op:   match[5],
+	}
+
+	metric := match[4]
+	rule.MType = match[3]
+	if match[1] != "" {
+		rule.Rate = true
+		rule.MType = match[1]
+		metric = match[2]
+	}
+	switch rule.MType {
+	case common.MetricTypeGauge:
+		if rule.Rate {
+			return nil, fmt.Errorf("alert rule %q: rate of a gauge", name)
+		}
+	case common.MetricTypeCounter:
+	default:
+		return nil, fmt.Errorf("alert rule %q: unsupported metric type %q", name, rule.MType)
+	}
+
+	id, labels := models.ParseSeriesKey(metric)
+	if id == "" || strings.Contains(id, " ") {
+		return nil, fmt.Errorf("alert rule %q: invalid metric %q", name, metric)
+	}
+	if err := models.ValidateID(id); err != nil {
+		return nil, fmt.Errorf("alert rule %q: %w", name, err)
+	}
+	if err := models.ValidateLabels(labels); err != nil {
+		return nil, fmt.Errorf("alert rule %q: %w", name, err)
+	}
+	rule.Key = models.SeriesKey(id, labels)
+
+	threshold, err := parseAlertThreshold(match[6])
+	if err != nil {
+		return nil, fmt.Errorf("alert rule %q: %w", name, err)
+	}
+	rule.Threshold = threshold
+
+	if match[7] != "" {
+		rule.For, err = parseRetentionDuration(match[7])
+		if err != nil || rule.For < 0 {
+			return nil, fmt.Errorf("alert rule %q: invalid duration %q", name, match[7])
+		}
+	}
+
+	return rule, nil
+}
+
+func parseAlertThreshold(s string) (float64, error) {
+	number, multiplier := s, 1.0
+	for _, unit := range alertThresholdUnits {
+		if n, ok := strings.CutSuffix(s, unit.suffix); ok {
+			number, multiplier = n, unit.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid threshold %q", s)
+	}
+	return value * multiplier, nil
+}
+
+// holds reports whether the condition of the rule holds for value.
+func (r *AlertRule) holds(value float64) bool {
+	switch r.Op {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	case "==":
+		return value == r.Threshold
+	case "!=":
+		return value != r.Threshold
+	}
+	return false
+}
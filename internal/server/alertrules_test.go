@@ -0,0 +1,101 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAlertRule(t *testing.T) {
+	tests := []struct {
+		expr string
+		want AlertRule
+	}{
+		{
+			expr: "gauge HeapAlloc > 500MB for 2m",
+			want: AlertRule{MType: "gauge", Key: "HeapAlloc", Op: ">", Threshold: 500 << 20, For: 2 * time.Minute},
+		},
+		{
+			expr: "rate(counter PollCount) == 0 for 5m",
+			want: AlertRule{MType: "counter", Key: "PollCount", Rate: true, Op: "==", Threshold: 0, For: 5 * time.Minute},
+		},
+		{
+			expr: "counter errors >= 10",
+			want: AlertRule{MType: "counter", Key: "errors", Op: ">=", Threshold: 10},
+		},
+		{
+			expr: `gauge cpu{instance="10.0.0.1",host="web1"} <= 0.5 for 1d`,
+			want: AlertRule{MType: "gauge", Key: `cpu{host="web1",instance="10.0.0.1"}`, Op: "<=", Threshold: 0.5, For: 24 * time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			rule, err := ParseAlertRule("rule", tt.expr)
+			require.NoError(t, err)
+			tt.want.Name = "rule"
+			tt.want.Expr = tt.expr
+			assert.Equal(t, &tt.want, rule)
+		})
+	}
+}
+
+func TestParseAlertRule_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"gauge HeapAlloc",
+		"gauge HeapAlloc >",
+		"gauge HeapAlloc => 1",
+		"histogram latency > 1",
+		"rate(gauge HeapAlloc) > 1",
+		"gauge HeapAlloc > lots",
+		"gauge HeapAlloc > 1 for ever",
+		"gauge HeapAlloc > 1 during 5m",
+		`gauge cpu{"host"="web1"} > 1`,
+	} {
+		_, err := ParseAlertRule("rule", expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestLoadAlertRules(t *testing.T) {
+	rules, err := LoadAlertRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": [
+		{"name": "HighHeap", "expr": "gauge HeapAlloc > 500MB for 2m", "labels": {"severity": "page"}},
+		{"name": "AgentDown", "expr": "rate(counter PollCount) == 0 for 5m"}
+	]}`), 0o600))
+
+	rules, err = LoadAlertRules(path)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "HighHeap", rules[0].Name)
+	assert.Equal(t, map[string]string{"severity": "page"}, rules[0].Labels)
+	assert.Equal(t, "AgentDown", rules[1].Name)
+	assert.True(t, rules[1].Rate)
+}
+
+func TestLoadAlertRules_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"not json":     `rules`,
+		"no name":      `{"rules": [{"expr": "gauge a > 1"}]}`,
+		"duplicate":    `{"rules": [{"name": "a", "expr": "gauge a > 1"}, {"name": "a", "expr": "gauge b > 1"}]}`,
+		"bad expr":     `{"rules": [{"name": "a", "expr": "gauge a"}]}`,
+		"bad label":    `{"rules": [{"name": "a", "expr": "gauge a > 1", "labels": {"0": "x"}}]}`,
+		"missing file": "",
+	} {
+		path := filepath.Join(t.TempDir(), "rules.json")
+		if content != "" {
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		}
+		_, err := LoadAlertRules(path)
+		assert.Error(t, err, name)
+	}
+}
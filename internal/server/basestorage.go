@@ -1,22 +1,133 @@
 package server
 
 import (
+	"sort"
+
 	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/etoneja/go-metrics/internal/models"
+	"go.uber.org/zap"
 )
 
-func NewStorageFromConfig(cfg *config) Storager {
+func NewStorageFromConfig(cfg *config) (Storager, error) {
 	var store Storager
-	if cfg.DatabaseDSN == "" {
+	if cfg.DatabaseDSN == "" && cfg.BoltPath != "" {
+		logger.Get().Info("Init boltstorage")
+		bs, err := NewBoltStorage(cfg.BoltPath)
+		if err != nil {
+			return nil, err
+		}
+		if ttl := cfg.GetMetricTTL(); ttl > 0 {
+			bs.ttl = ttl
+			bs.sweeper = startMetricSweeper(bs, ttl)
+		}
+		store = bs
+	} else if cfg.DatabaseDSN == "" {
 		logger.Get().Info("Init memstorage")
 		storageConfig := &StorageConfig{
 			StoreInterval:   cfg.StoreInterval,
 			FileStoragePath: cfg.FileStoragePath,
 			Restore:         cfg.Restore,
+			HistorySize:     cfg.HistorySize,
+			Retention:       cfg.GetRetentionPolicy(),
+			CompactInterval: cfg.CompactInterval,
+			MetricTTL:       cfg.GetMetricTTL(),
+			WALPath:         cfg.WALPath,
+		}
+		ms, err := NewMemStorageFromStorageConfig(storageConfig)
+		if err != nil {
+			return nil, err
 		}
-		store = NewMemStorageFromStorageConfig(storageConfig)
+		store = ms
 	} else {
 		logger.Get().Info("Init memstorage")
-		store = NewDBStorage(cfg.DatabaseDSN)
+		dbs, err := NewDBStorage(cfg.GetDBConfig())
+		if err != nil {
+			return nil, err
+		}
+		store = dbs
+		if policy := cfg.GetRetentionPolicy(); policy != nil && cfg.CompactInterval > 0 {
+			dbs.compactor = startHistoryCompactor(dbs, policy, cfg.CompactInterval)
+		}
+
+		// Expiry goes through the cache, so it drops expired values.
+		var expirer MetricExpirer = dbs
+		if cfg.CacheSize > 0 {
+			logger.Get().Info("Init storage cache", zap.Uint("size", cfg.CacheSize))
+			cs := NewCachedStorage(dbs, int(cfg.CacheSize))
+			cs.notifier = startPGCacheNotifier(dbs.pool, cs.cache)
+			store = cs
+			expirer = cs
+		}
+		if ttl := cfg.GetMetricTTL(); ttl > 0 {
+			dbs.ttl = ttl
+			dbs.sweeper = startMetricSweeper(expirer, ttl)
+		}
+	}
+	return store, nil
+}
+
+// newSeriesMetricModel creates a metric model from a series key built by models.SeriesKey.
+func newSeriesMetricModel(key string, mtype string, delta int64, value float64) *models.MetricModel {
+	id, labels := models.ParseSeriesKey(key)
+	m := models.NewMetricModel(id, mtype, delta, value)
+	m.Labels = labels
+	return m
+}
+
+// newSeriesHistogramModel creates a histogram metric model from a series key built by models.SeriesKey.
+func newSeriesHistogramModel(key string, h *models.Histogram) *models.MetricModel {
+	id, labels := models.ParseSeriesKey(key)
+	m := models.NewHistogramMetricModel(id, h)
+	m.Labels = labels
+	return m
+}
+
+// mergeHistogram returns a new histogram with h added to prev, prev may be nil.
+func mergeHistogram(prev *models.Histogram, h *models.Histogram) (*models.Histogram, error) {
+	if prev == nil {
+		return h.Clone(), nil
+	}
+	merged := prev.Clone()
+	if err := merged.Merge(h); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// newSeriesSummaryModel creates a summary metric model from a series key
+// built by models.SeriesKey. The model carries the sketch so it can be restored.
+func newSeriesSummaryModel(key string, sketch *models.QuantileSketch) *models.MetricModel {
+	id, labels := models.ParseSeriesKey(key)
+	m := models.NewSummaryMetricModel(id, sketch)
+	m.Sketch = sketch
+	m.Labels = labels
+	return m
+}
+
+// mergeSummary returns a new sketch with observations and sketch added to
+// prev, prev and sketch may be nil.
+func mergeSummary(prev *models.QuantileSketch, observations []float64, sketch *models.QuantileSketch) *models.QuantileSketch {
+	var merged *models.QuantileSketch
+	if prev == nil {
+		merged = models.NewQuantileSketch()
+	} else {
+		merged = prev.Clone()
+	}
+	for _, v := range observations {
+		merged.Add(v)
+	}
+	if sketch != nil {
+		merged.Merge(sketch)
 	}
-	return store
+	return merged
+}
+
+// sortMetricModels orders metrics by ID, series of the same ID by their labels.
+func sortMetricModels(metrics []models.MetricModel) {
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].ID != metrics[j].ID {
+			return metrics[i].ID < metrics[j].ID
+		}
+		return metrics[i].SeriesKey() < metrics[j].SeriesKey()
+	})
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"os"
 	"testing"
 )
 
@@ -12,10 +13,66 @@ func TestNewStorageFromConfig_MemStorage(t *testing.T) {
 		Restore:         false,
 	}
 
-	store := NewStorageFromConfig(cfg)
+	store, err := NewStorageFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewStorageFromConfig failed: %v", err)
+	}
 
 	_, ok := store.(*MemStorage)
 	if !ok {
 		t.Error("Expected MemStorage for empty DatabaseDSN")
 	}
 }
+
+func TestNewStorageFromConfig_BoltStorage(t *testing.T) {
+	cfg := &config{
+		BoltPath: t.TempDir() + "/metrics.db",
+	}
+
+	store, err := NewStorageFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewStorageFromConfig failed: %v", err)
+	}
+	defer store.ShutDown()
+
+	_, ok := store.(*BoltStorage)
+	if !ok {
+		t.Error("Expected BoltStorage for bolt path without DatabaseDSN")
+	}
+}
+
+func TestNewStorageFromConfig_UnwritableBoltPath(t *testing.T) {
+	file := t.TempDir() + "/file"
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config{
+		BoltPath: file + "/metrics.db",
+	}
+
+	if _, err := NewStorageFromConfig(cfg); err == nil {
+		t.Error("Expected error for bolt path under a file")
+	}
+}
+
+func TestNewStorageFromConfig_BadWALPath(t *testing.T) {
+	cfg := &config{
+		FileStoragePath: t.TempDir() + "/dump.json",
+		WALPath:         t.TempDir() + "/missing/metrics.wal",
+	}
+
+	if _, err := NewStorageFromConfig(cfg); err == nil {
+		t.Error("Expected error for WAL in a missing directory")
+	}
+}
+
+func TestNewStorageFromConfig_BadDSN(t *testing.T) {
+	cfg := &config{
+		DatabaseDSN: "host=localhost port=notaport",
+	}
+
+	_, err := NewStorageFromConfig(cfg)
+	if err == nil {
+		t.Error("Expected error for invalid DatabaseDSN")
+	}
+}
@@ -0,0 +1,429 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/etoneja/go-metrics/internal/models"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+// BoltOpenTimeout bounds waiting for the file lock held by another process.
+const BoltOpenTimeout = time.Second
+
+// boltBuckets maps metric types to buckets keyed by series key.
+var boltBuckets = map[string][]byte{
+	common.MetricTypeGauge:     []byte("gauges"),
+	common.MetricTypeCounter:   []byte("counters"),
+	common.MetricTypeHistogram: []byte("histograms"),
+	common.MetricTypeSummary:   []byte("summaries"),
+}
+
+// boltRecord is the stored state of a metric.
+type boltRecord struct {
+	Delta     *int64                 `json:"delta,omitempty"`
+	Value     *float64               `json:"value,omitempty"`
+	Histogram *models.Histogram      `json:"histogram,omitempty"`
+	Sketch    *models.QuantileSketch `json:"sketch,omitempty"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// BoltStorage keeps metrics in a single bbolt file. Every write is a
+// transaction that is synced to disk before it is acknowledged.
+type BoltStorage struct {
+	db      *bolt.DB
+	ttl     time.Duration
+	sweeper *metricSweeper
+}
+
+func NewBoltStorage(path string) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: BoltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open bolt file %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range boltBuckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("failed to create bucket %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to init bolt file %s: %w", path, err)
+	}
+
+	return &BoltStorage{db: db}, nil
+}
+
+func boltBucket(tx *bolt.Tx, mType string) (*bolt.Bucket, error) {
+	name, ok := boltBuckets[mType]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric type %s", mType)
+	}
+	return tx.Bucket(name), nil
+}
+
+func getBoltRecord(tx *bolt.Tx, mType string, key string) (*boltRecord, error) {
+	bucket, err := boltBucket(tx, mType)
+	if err != nil {
+		return nil, err
+	}
+	data := bucket.Get([]byte(key))
+	if data == nil {
+		return nil, fmt.Errorf("%s %s: %w", mType, key, ErrNotFound)
+	}
+
+	var rec boltRecord
+	err = json.Unmarshal(data, &rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s %s: %w", mType, key, err)
+	}
+	return &rec, nil
+}
+
+func putBoltRecord(tx *bolt.Tx, mType string, key string, rec *boltRecord) error {
+	bucket, err := boltBucket(tx, mType)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %s: %w", mType, key, err)
+	}
+	return bucket.Put([]byte(key), data)
+}
+
+// updateBoltMetric applies a metric update the way MemStorage does and
+// returns the stored state.
+func updateBoltMetric(tx *bolt.Tx, key string, m *models.MetricModel, now time.Time) (*boltRecord, error) {
+	prev, err := getBoltRecord(tx, m.MType, key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	rec := &boltRecord{UpdatedAt: now}
+	switch m.MType {
+	case common.MetricTypeGauge:
+		value := *m.Value
+		rec.Value = &value
+	case common.MetricTypeCounter:
+		delta := *m.Delta
+		if prev != nil {
+			delta += *prev.Delta
+		}
+		rec.Delta = &delta
+	case common.MetricTypeHistogram:
+		var stored *models.Histogram
+		if prev != nil {
+			stored = prev.Histogram
+		}
+		rec.Histogram, err = mergeHistogram(stored, m.Histogram)
+		if err != nil {
+			return nil, err
+		}
+	case common.MetricTypeSummary:
+		var stored *models.QuantileSketch
+		if prev != nil {
+			stored = prev.Sketch
+		}
+		rec.Sketch = mergeSummary(stored, m.Observations, m.Sketch)
+	}
+
+	err = putBoltRecord(tx, m.MType, key, rec)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// update runs a single metric update in its own transaction.
+func (bs *BoltStorage) update(ctx context.Context, key string, m *models.MetricModel) (*boltRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var rec *boltRecord
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		rec, err = updateBoltMetric(tx, key, m, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (bs *BoltStorage) get(ctx context.Context, mType string, key string) (*boltRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var rec *boltRecord
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		rec, err = getBoltRecord(tx, mType, key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (bs *BoltStorage) GetGauge(ctx context.Context, key string) (float64, error) {
+	rec, err := bs.get(ctx, common.MetricTypeGauge, key)
+	if err != nil {
+		return 0, err
+	}
+	return *rec.Value, nil
+}
+
+func (bs *BoltStorage) SetGauge(ctx context.Context, key string, value float64) (float64, error) {
+	rec, err := bs.update(ctx, key, models.NewMetricModel(key, common.MetricTypeGauge, 0, value))
+	if err != nil {
+		return 0, err
+	}
+	return *rec.Value, nil
+}
+
+func (bs *BoltStorage) GetCounter(ctx context.Context, key string) (int64, error) {
+	rec, err := bs.get(ctx, common.MetricTypeCounter, key)
+	if err != nil {
+		return 0, err
+	}
+	return *rec.Delta, nil
+}
+
+func (bs *BoltStorage) IncrementCounter(ctx context.Context, key string, value int64) (int64, error) {
+	rec, err := bs.update(ctx, key, models.NewMetricModel(key, common.MetricTypeCounter, value, 0))
+	if err != nil {
+		return 0, err
+	}
+	return *rec.Delta, nil
+}
+
+func (bs *BoltStorage) GetHistogram(ctx context.Context, key string) (*models.Histogram, error) {
+	rec, err := bs.get(ctx, common.MetricTypeHistogram, key)
+	if err != nil {
+		return nil, err
+	}
+	return rec.Histogram, nil
+}
+
+func (bs *BoltStorage) UpdateHistogram(ctx context.Context, key string, h *models.Histogram) (*models.Histogram, error) {
+	rec, err := bs.update(ctx, key, models.NewHistogramMetricModel(key, h))
+	if err != nil {
+		return nil, err
+	}
+	return rec.Histogram, nil
+}
+
+func (bs *BoltStorage) GetSummary(ctx context.Context, key string) (*models.QuantileSketch, error) {
+	rec, err := bs.get(ctx, common.MetricTypeSummary, key)
+	if err != nil {
+		return nil, err
+	}
+	return rec.Sketch, nil
+}
+
+func (bs *BoltStorage) UpdateSummary(ctx context.Context, key string, observations []float64) (*models.QuantileSketch, error) {
+	m := &models.MetricModel{ID: key, MType: common.MetricTypeSummary, Observations: observations}
+	rec, err := bs.update(ctx, key, m)
+	if err != nil {
+		return nil, err
+	}
+	return rec.Sketch, nil
+}
+
+func (bs *BoltStorage) DeleteMetric(ctx context.Context, mType string, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := boltBucket(tx, mType)
+		if err != nil {
+			return err
+		}
+		if bucket.Get([]byte(key)) == nil {
+			return fmt.Errorf("%s %s: %w", mType, key, ErrNotFound)
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+func (bs *BoltStorage) ResetCounter(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		rec, err := getBoltRecord(tx, common.MetricTypeCounter, key)
+		if err != nil {
+			return err
+		}
+		var zero int64
+		rec.Delta = &zero
+		rec.UpdatedAt = time.Now()
+		return putBoltRecord(tx, common.MetricTypeCounter, key, rec)
+	})
+}
+
+func (bs *BoltStorage) GetAll(ctx context.Context) ([]models.MetricModel, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	metrics := []models.MetricModel{}
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		for mType, name := range boltBuckets {
+			err := tx.Bucket(name).ForEach(func(k, v []byte) error {
+				var rec boltRecord
+				if err := json.Unmarshal(v, &rec); err != nil {
+					return fmt.Errorf("failed to decode %s %s: %w", mType, k, err)
+				}
+				m := newBoltMetricModel(mType, string(k), &rec)
+				m.UpdatedAt = &rec.UpdatedAt
+				m.ExpiresAt = expiresAt(rec.UpdatedAt, bs.ttl)
+				metrics = append(metrics, *m)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortMetricModels(metrics)
+	return metrics, nil
+}
+
+func newBoltMetricModel(mType string, key string, rec *boltRecord) *models.MetricModel {
+	switch mType {
+	case common.MetricTypeGauge:
+		return newSeriesMetricModel(key, mType, 0, *rec.Value)
+	case common.MetricTypeCounter:
+		return newSeriesMetricModel(key, mType, *rec.Delta, 0)
+	case common.MetricTypeHistogram:
+		return newSeriesHistogramModel(key, rec.Histogram)
+	default:
+		return newSeriesSummaryModel(key, rec.Sketch)
+	}
+}
+
+// BatchUpdate applies all metrics in one transaction, none of them is
+// stored if any fails.
+func (bs *BoltStorage) BatchUpdate(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	newMetrics := make([]models.MetricModel, 0, len(metrics))
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		for _, m := range metrics {
+			if _, ok := boltBuckets[m.MType]; !ok {
+				return fmt.Errorf("bad metric type %s", m.MType)
+			}
+
+			rec, err := updateBoltMetric(tx, m.SeriesKey(), &m, now)
+			if err != nil {
+				return err
+			}
+
+			var newMetric *models.MetricModel
+			switch m.MType {
+			case common.MetricTypeCounter:
+				newMetric = models.NewMetricModel(m.ID, m.MType, *rec.Delta, 0)
+			case common.MetricTypeGauge:
+				newMetric = models.NewMetricModel(m.ID, m.MType, 0, *rec.Value)
+			case common.MetricTypeHistogram:
+				newMetric = models.NewHistogramMetricModel(m.ID, rec.Histogram)
+			case common.MetricTypeSummary:
+				newMetric = models.NewSummaryMetricModel(m.ID, rec.Sketch)
+			}
+			newMetric.Labels = m.Labels
+			newMetrics = append(newMetrics, *newMetric)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newMetrics, nil
+}
+
+// ExpireMetrics removes metrics that were last updated before the given time.
+func (bs *BoltStorage) ExpireMetrics(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		for mType, name := range boltBuckets {
+			bucket := tx.Bucket(name)
+
+			var stale [][]byte
+			err := bucket.ForEach(func(k, v []byte) error {
+				var rec boltRecord
+				if err := json.Unmarshal(v, &rec); err != nil {
+					return fmt.Errorf("failed to decode %s %s: %w", mType, k, err)
+				}
+				if rec.UpdatedAt.Before(before) {
+					stale = append(stale, bytes.Clone(k))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, k := range stale {
+				if err := bucket.Delete(k); err != nil {
+					return err
+				}
+			}
+			expired += len(stale)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+func (bs *BoltStorage) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return bs.db.View(func(tx *bolt.Tx) error {
+		return nil
+	})
+}
+
+func (bs *BoltStorage) ShutDown() {
+	logger.Get().Info("Shutting down bolt storage")
+	if bs.sweeper != nil {
+		bs.sweeper.stop()
+	}
+	if err := bs.db.Close(); err != nil {
+		logger.Get().Error("Failed to close bolt file", zap.Error(err))
+	}
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBoltStorage(t *testing.T) (*BoltStorage, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "metrics.db")
+	storage, err := NewBoltStorage(path)
+	require.NoError(t, err)
+	return storage, path
+}
+
+func TestNewBoltStorage_Unwritable(t *testing.T) {
+	// A path under a regular file cannot be created, even by root.
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	storage, err := NewBoltStorage(filepath.Join(file, "metrics.db"))
+	assert.Error(t, err)
+	assert.Nil(t, storage)
+}
+
+func TestBoltStorage_GaugeAndCounter(t *testing.T) {
+	storage, _ := newTestBoltStorage(t)
+	defer storage.ShutDown()
+	ctx := context.Background()
+
+	_, err := storage.GetGauge(ctx, "Alloc")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = storage.GetCounter(ctx, "PollCount")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	value, err := storage.SetGauge(ctx, "Alloc", 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, value)
+
+	counter, err := storage.IncrementCounter(ctx, "PollCount", 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), counter)
+	counter, err = storage.IncrementCounter(ctx, "PollCount", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), counter)
+
+	require.NoError(t, storage.ResetCounter(ctx, "PollCount"))
+	counter, err = storage.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), counter)
+
+	require.NoError(t, storage.DeleteMetric(ctx, common.MetricTypeGauge, "Alloc"))
+	assert.ErrorIs(t, storage.DeleteMetric(ctx, common.MetricTypeGauge, "Alloc"), ErrNotFound)
+}
+
+func TestBoltStorage_HistogramAndSummary(t *testing.T) {
+	storage, _ := newTestBoltStorage(t)
+	defer storage.ShutDown()
+	ctx := context.Background()
+
+	h, err := storage.UpdateHistogram(ctx, "latency", &models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 0}, h.Counts)
+
+	h, err = storage.UpdateHistogram(ctx, "latency", &models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 1}, Sum: 2.5})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 1}, h.Counts)
+	assert.Equal(t, 3.0, h.Sum)
+
+	_, err = storage.UpdateHistogram(ctx, "latency", &models.Histogram{Bounds: []float64{2}, Counts: []uint64{1, 0}})
+	assert.Error(t, err)
+
+	sketch, err := storage.UpdateSummary(ctx, "size", []float64{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), sketch.Count)
+
+	sketch, err = storage.GetSummary(ctx, "size")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), sketch.Count)
+}
+
+func TestBoltStorage_BatchUpdateIsTransactional(t *testing.T) {
+	storage, _ := newTestBoltStorage(t)
+	defer storage.ShutDown()
+	ctx := context.Background()
+
+	_, err := storage.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("PollCount", common.MetricTypeCounter, 1, 0),
+		{ID: "bad", MType: "unknown"},
+	})
+	require.Error(t, err)
+
+	_, err = storage.GetCounter(ctx, "PollCount")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	metrics, err := storage.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("PollCount", common.MetricTypeCounter, 1, 0),
+		*models.NewMetricModel("PollCount", common.MetricTypeCounter, 2, 0),
+		*models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 4),
+	})
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+
+	counter, err := storage.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), counter)
+}
+
+func TestBoltStorage_PersistsAcrossReopen(t *testing.T) {
+	storage, path := newTestBoltStorage(t)
+	ctx := context.Background()
+
+	_, err := storage.IncrementCounter(ctx, models.SeriesKey("requests", map[string]string{"host": "a"}), 7)
+	require.NoError(t, err)
+	storage.ShutDown()
+
+	storage, err = NewBoltStorage(path)
+	require.NoError(t, err)
+	defer storage.ShutDown()
+
+	metrics, err := storage.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "requests", metrics[0].ID)
+	assert.Equal(t, map[string]string{"host": "a"}, metrics[0].Labels)
+	assert.Equal(t, int64(7), *metrics[0].Delta)
+	assert.NotNil(t, metrics[0].UpdatedAt)
+}
+
+func TestBoltStorage_ExpireMetrics(t *testing.T) {
+	storage, _ := newTestBoltStorage(t)
+	defer storage.ShutDown()
+	ctx := context.Background()
+
+	_, err := storage.SetGauge(ctx, "stale", 1)
+	require.NoError(t, err)
+	cutoff := time.Now()
+	_, err = storage.SetGauge(ctx, "fresh", 1)
+	require.NoError(t, err)
+
+	expired, err := storage.ExpireMetrics(ctx, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	_, err = storage.GetGauge(ctx, "stale")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = storage.GetGauge(ctx, "fresh")
+	assert.NoError(t, err)
+}
+
+func TestBoltStorage_ContextCancelled(t *testing.T) {
+	storage, _ := newTestBoltStorage(t)
+	defer storage.ShutDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := storage.SetGauge(ctx, "Alloc", 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, storage.Ping(ctx), context.Canceled)
+}
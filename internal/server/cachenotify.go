@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const (
+	// cacheNotifyChannel is the Postgres channel replicas publish changed
+	// series keys on.
+	cacheNotifyChannel = "metrics_cache"
+	// cacheNotifyMaxPayload keeps payloads below the 8000 bytes limit of NOTIFY.
+	cacheNotifyMaxPayload = 7500
+	// cacheNotifyEnvelope is the size reserved for the payload besides keys.
+	cacheNotifyEnvelope = 64
+
+	queryCacheNotify = "SELECT pg_notify($1, $2);"
+)
+
+// cacheNotification is the payload of a NOTIFY on cacheNotifyChannel.
+// Keys are series keys of all metric types, All drops the whole cache.
+type cacheNotification struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys,omitempty"`
+	All    bool     `json:"all,omitempty"`
+}
+
+// pgCacheNotifier invalidates caches of replicas sharing a database with
+// LISTEN/NOTIFY. It listens on a dedicated connection, while the connection
+// is down the cache is bypassed, since notifications may be lost.
+type pgCacheNotifier struct {
+	pool     *pgxpool.Pool
+	cache    *metricCache
+	origin   string
+	cancel   context.CancelFunc
+	doneChan chan struct{}
+}
+
+func startPGCacheNotifier(pool *pgxpool.Pool, cache *metricCache) *pgCacheNotifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &pgCacheNotifier{
+		pool:     pool,
+		cache:    cache,
+		origin:   newCacheOrigin(),
+		cancel:   cancel,
+		doneChan: make(chan struct{}),
+	}
+
+	cache.setSynced(false)
+
+	go func() {
+		defer close(n.doneChan)
+		n.run(ctx)
+	}()
+
+	return n
+}
+
+func newCacheOrigin() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// run listens until ctx is done and reconnects after connection errors.
+func (n *pgCacheNotifier) run(ctx context.Context) {
+	backoffSchedule := common.DefaultBackoffSchedule
+	attempt := 0
+	for {
+		listened, err := n.listen(ctx)
+		n.cache.setSynced(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if listened {
+			attempt = 0
+		}
+
+		delay := backoffSchedule[min(attempt, len(backoffSchedule)-1)]
+		attempt++
+		logger.Get().Warn("Cache invalidation listener failed, cache is bypassed",
+			zap.Duration("retryIn", delay),
+			zap.Error(err),
+		)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// listen receives notifications until an error, it reports whether the
+// listener was started.
+func (n *pgCacheNotifier) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.ConnectConfig(ctx, n.pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer func() {
+		if closeErr := conn.Close(context.Background()); closeErr != nil {
+			logger.Get().Warn("failed to close listener connection", zap.Error(closeErr))
+		}
+	}()
+
+	_, err = conn.Exec(ctx, "LISTEN "+cacheNotifyChannel)
+	if err != nil {
+		return false, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	n.cache.setSynced(true)
+	logger.Get().Info("Cache invalidation listener started")
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		n.handle(notification.Payload)
+	}
+}
+
+func (n *pgCacheNotifier) handle(payload string) {
+	var msg cacheNotification
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		logger.Get().Warn("Bad cache notification, dropping cache", zap.Error(err))
+		n.cache.invalidateAll()
+		return
+	}
+	if msg.Origin == n.origin {
+		return
+	}
+	if msg.All {
+		n.cache.invalidateAll()
+		return
+	}
+	n.cache.invalidate(msg.Keys)
+}
+
+// notify publishes keys in as many notifications as the payload limit needs.
+func (n *pgCacheNotifier) notify(ctx context.Context, keys []string) error {
+	if keys == nil {
+		return n.publish(ctx, cacheNotification{Origin: n.origin, All: true})
+	}
+
+	msg := cacheNotification{Origin: n.origin}
+	size := 0
+	for _, key := range keys {
+		encoded, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		if len(encoded)+cacheNotifyEnvelope > cacheNotifyMaxPayload {
+			// A single key does not fit, drop the caches instead.
+			return n.publish(ctx, cacheNotification{Origin: n.origin, All: true})
+		}
+		if size+len(encoded)+cacheNotifyEnvelope > cacheNotifyMaxPayload {
+			if err := n.publish(ctx, msg); err != nil {
+				return err
+			}
+			msg.Keys = nil
+			size = 0
+		}
+		msg.Keys = append(msg.Keys, key)
+		size += len(encoded) + 1
+	}
+	if len(msg.Keys) == 0 {
+		return nil
+	}
+	return n.publish(ctx, msg)
+}
+
+func (n *pgCacheNotifier) publish(ctx context.Context, msg cacheNotification) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = n.pool.Exec(ctx, queryCacheNotify, cacheNotifyChannel, string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to notify: %w", err)
+	}
+	return nil
+}
+
+func (n *pgCacheNotifier) stop() {
+	n.cancel()
+	<-n.doneChan
+}
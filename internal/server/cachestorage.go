@@ -0,0 +1,472 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/etoneja/go-metrics/internal/models"
+	"go.uber.org/zap"
+)
+
+var cacheMetricTypes = []string{
+	common.MetricTypeGauge,
+	common.MetricTypeCounter,
+	common.MetricTypeHistogram,
+	common.MetricTypeSummary,
+}
+
+// metricCache is a bounded LRU cache of metric values.
+//
+// Fills are rejected when the value may be stale: a read fill if any write
+// or invalidation happened since the read started, a write fill if another
+// write or an invalidation of the same metric overlapped it. Until synced is
+// set, e.g. while invalidations from other replicas may be missed, the cache
+// is bypassed.
+type metricCache struct {
+	mu    sync.Mutex
+	size  int
+	lru   *list.List
+	items map[metricKey]*list.Element
+
+	version    uint64
+	inflight   map[metricKey]int
+	conflicted map[metricKey]bool
+	synced     bool
+}
+
+type metricCacheEntry struct {
+	key   metricKey
+	value any
+}
+
+func newMetricCache(size int) *metricCache {
+	return &metricCache{
+		size:       size,
+		lru:        list.New(),
+		items:      make(map[metricKey]*list.Element),
+		inflight:   make(map[metricKey]int),
+		conflicted: make(map[metricKey]bool),
+		synced:     true,
+	}
+}
+
+// get returns the cached value of a metric.
+func (c *metricCache) get(mk metricKey) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.synced {
+		return nil, false
+	}
+	el, ok := c.items[mk]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*metricCacheEntry).value, true
+}
+
+// beginRead returns the version a read fill must be done with.
+func (c *metricCache) beginRead() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version
+}
+
+// fill caches a value read from the storage unless something changed since
+// beginRead returned version.
+func (c *metricCache) fill(mk metricKey, value any, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.synced && c.version == version && c.inflight[mk] == 0 {
+		c.put(mk, value)
+	}
+}
+
+// beginWrite drops the cached values of keys before they are written.
+// Every beginWrite must be followed by endWrite with the same keys.
+func (c *metricCache) beginWrite(keys []metricKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	for _, mk := range keys {
+		c.remove(mk)
+		c.inflight[mk]++
+		if c.inflight[mk] > 1 {
+			c.conflicted[mk] = true
+		}
+	}
+}
+
+// endWrite caches the written values, values is nil if the write failed
+// and holds nil for values that are not known after the write.
+func (c *metricCache) endWrite(keys []metricKey, values []any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, mk := range keys {
+		if values != nil && values[i] != nil && c.synced && !c.conflicted[mk] {
+			c.put(mk, values[i])
+		}
+		c.inflight[mk]--
+		if c.inflight[mk] == 0 {
+			delete(c.inflight, mk)
+			delete(c.conflicted, mk)
+		}
+	}
+}
+
+// invalidate drops the cached values of all metric types of the series keys.
+func (c *metricCache) invalidate(keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	for _, key := range keys {
+		for _, mType := range cacheMetricTypes {
+			mk := metricKey{mType: mType, id: key}
+			c.remove(mk)
+			if c.inflight[mk] > 0 {
+				c.conflicted[mk] = true
+			}
+		}
+	}
+}
+
+// invalidateAll drops all cached values.
+func (c *metricCache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clear()
+}
+
+// setSynced enables or disables the cache. The cache is cleared both ways,
+// since invalidations could be missed while it was not synced.
+func (c *metricCache) setSynced(synced bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.synced = synced
+	c.clear()
+}
+
+func (c *metricCache) clear() {
+	c.version++
+	c.lru.Init()
+	clear(c.items)
+	for mk := range c.inflight {
+		c.conflicted[mk] = true
+	}
+}
+
+func (c *metricCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *metricCache) put(mk metricKey, value any) {
+	if el, ok := c.items[mk]; ok {
+		el.Value.(*metricCacheEntry).value = value
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.items[mk] = c.lru.PushFront(&metricCacheEntry{key: mk, value: value})
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back().Value.(*metricCacheEntry).key)
+	}
+}
+
+func (c *metricCache) remove(mk metricKey) {
+	if el, ok := c.items[mk]; ok {
+		c.lru.Remove(el)
+		delete(c.items, mk)
+	}
+}
+
+// cacheNotifier tells other replicas sharing the storage which metrics were
+// changed, so they drop their cached values.
+type cacheNotifier interface {
+	// notify publishes the changed series keys, nil keys means all metrics.
+	notify(ctx context.Context, keys []string) error
+	stop()
+}
+
+// CachedStorage is a Storager decorator that keeps recently used metric
+// values in memory. Reads go through the cache, writes go to the wrapped
+// storage and then update the cache.
+//
+// History, rollups and silences are not cached, the wrapped storage must
+// implement HistoryReader, RollupReader and SilenceStorer to serve them.
+type CachedStorage struct {
+	store    Storager
+	cache    *metricCache
+	notifier cacheNotifier
+}
+
+// NewCachedStorage wraps store with a cache of up to size metric values.
+func NewCachedStorage(store Storager, size int) *CachedStorage {
+	return &CachedStorage{
+		store: store,
+		cache: newMetricCache(size),
+	}
+}
+
+// notify tells other replicas about changed series keys. The write is
+// already done, so a failure is only logged.
+func (cs *CachedStorage) notify(ctx context.Context, keys []string) {
+	if cs.notifier == nil {
+		return
+	}
+	err := cs.notifier.notify(context.WithoutCancel(ctx), keys)
+	if err != nil {
+		logger.Get().Error("Failed to notify cache invalidation", zap.Error(err))
+	}
+}
+
+// cachedRead returns the cached value of a metric or reads it from the
+// storage and caches it.
+func cachedRead[T any](cs *CachedStorage, mk metricKey, read func() (T, error)) (T, error) {
+	if value, ok := cs.cache.get(mk); ok {
+		return value.(T), nil
+	}
+
+	version := cs.cache.beginRead()
+	value, err := read()
+	if err != nil {
+		return value, err
+	}
+	cs.cache.fill(mk, value, version)
+	return value, nil
+}
+
+// cachedWrite writes a metric to the storage and caches the result.
+func cachedWrite[T any](cs *CachedStorage, ctx context.Context, mk metricKey, write func() (T, error)) (T, error) {
+	keys := []metricKey{mk}
+	cs.cache.beginWrite(keys)
+
+	value, err := write()
+	if err != nil {
+		cs.cache.endWrite(keys, nil)
+		return value, err
+	}
+	cs.cache.endWrite(keys, []any{value})
+	cs.notify(ctx, []string{mk.id})
+	return value, nil
+}
+
+func (cs *CachedStorage) GetGauge(ctx context.Context, key string) (float64, error) {
+	return cachedRead(cs, metricKey{mType: common.MetricTypeGauge, id: key}, func() (float64, error) {
+		return cs.store.GetGauge(ctx, key)
+	})
+}
+
+func (cs *CachedStorage) SetGauge(ctx context.Context, key string, value float64) (float64, error) {
+	return cachedWrite(cs, ctx, metricKey{mType: common.MetricTypeGauge, id: key}, func() (float64, error) {
+		return cs.store.SetGauge(ctx, key, value)
+	})
+}
+
+func (cs *CachedStorage) GetCounter(ctx context.Context, key string) (int64, error) {
+	return cachedRead(cs, metricKey{mType: common.MetricTypeCounter, id: key}, func() (int64, error) {
+		return cs.store.GetCounter(ctx, key)
+	})
+}
+
+func (cs *CachedStorage) IncrementCounter(ctx context.Context, key string, value int64) (int64, error) {
+	return cachedWrite(cs, ctx, metricKey{mType: common.MetricTypeCounter, id: key}, func() (int64, error) {
+		return cs.store.IncrementCounter(ctx, key, value)
+	})
+}
+
+// GetHistogram returns a copy of the cached histogram, so callers may modify it.
+func (cs *CachedStorage) GetHistogram(ctx context.Context, key string) (*models.Histogram, error) {
+	h, err := cachedRead(cs, metricKey{mType: common.MetricTypeHistogram, id: key}, func() (*models.Histogram, error) {
+		return cs.store.GetHistogram(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h.Clone(), nil
+}
+
+func (cs *CachedStorage) UpdateHistogram(ctx context.Context, key string, h *models.Histogram) (*models.Histogram, error) {
+	merged, err := cachedWrite(cs, ctx, metricKey{mType: common.MetricTypeHistogram, id: key}, func() (*models.Histogram, error) {
+		merged, err := cs.store.UpdateHistogram(ctx, key, h)
+		if err != nil {
+			return nil, err
+		}
+		return merged.Clone(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merged.Clone(), nil
+}
+
+// GetSummary returns the cached sketch, sketches are never modified in place.
+func (cs *CachedStorage) GetSummary(ctx context.Context, key string) (*models.QuantileSketch, error) {
+	return cachedRead(cs, metricKey{mType: common.MetricTypeSummary, id: key}, func() (*models.QuantileSketch, error) {
+		return cs.store.GetSummary(ctx, key)
+	})
+}
+
+func (cs *CachedStorage) UpdateSummary(ctx context.Context, key string, observations []float64) (*models.QuantileSketch, error) {
+	return cachedWrite(cs, ctx, metricKey{mType: common.MetricTypeSummary, id: key}, func() (*models.QuantileSketch, error) {
+		return cs.store.UpdateSummary(ctx, key, observations)
+	})
+}
+
+func (cs *CachedStorage) GetAll(ctx context.Context) ([]models.MetricModel, error) {
+	return cs.store.GetAll(ctx)
+}
+
+func (cs *CachedStorage) DeleteMetric(ctx context.Context, mType string, key string) error {
+	keys := []metricKey{{mType: mType, id: key}}
+	cs.cache.beginWrite(keys)
+	err := cs.store.DeleteMetric(ctx, mType, key)
+	cs.cache.endWrite(keys, nil)
+	if err != nil {
+		return err
+	}
+	cs.notify(ctx, []string{key})
+	return nil
+}
+
+func (cs *CachedStorage) ResetCounter(ctx context.Context, key string) error {
+	_, err := cachedWrite(cs, ctx, metricKey{mType: common.MetricTypeCounter, id: key}, func() (int64, error) {
+		return 0, cs.store.ResetCounter(ctx, key)
+	})
+	return err
+}
+
+// BatchUpdate caches the stored state of gauges, counters and histograms
+// of the batch. Summaries are only dropped from the cache, since results
+// carry their quantiles rather than sketches.
+func (cs *CachedStorage) BatchUpdate(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error) {
+	index := make(map[metricKey]int, len(metrics))
+	keys := make([]metricKey, 0, len(metrics))
+	ids := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		mk := metricKey{mType: m.MType, id: m.SeriesKey()}
+		if _, ok := index[mk]; ok {
+			continue
+		}
+		index[mk] = len(keys)
+		keys = append(keys, mk)
+		ids = append(ids, mk.id)
+	}
+
+	cs.cache.beginWrite(keys)
+	result, err := cs.store.BatchUpdate(ctx, metrics)
+	if err != nil {
+		cs.cache.endWrite(keys, nil)
+		return nil, err
+	}
+
+	values := make([]any, len(keys))
+	for i, m := range result {
+		var value any
+		switch m.MType {
+		case common.MetricTypeGauge:
+			value = *m.Value
+		case common.MetricTypeCounter:
+			value = *m.Delta
+		case common.MetricTypeHistogram:
+			value = m.Histogram.Clone()
+		default:
+			continue
+		}
+		// Results are in request order, so the last one of a metric holds
+		// its final state.
+		values[index[metricKey{mType: metrics[i].MType, id: metrics[i].SeriesKey()}]] = value
+	}
+	cs.cache.endWrite(keys, values)
+	cs.notify(ctx, ids)
+
+	return result, nil
+}
+
+func (cs *CachedStorage) GetHistory(ctx context.Context, mType string, key string, from, to time.Time) ([]models.MetricSample, error) {
+	historyReader, ok := cs.store.(HistoryReader)
+	if !ok {
+		return nil, errHistoryNotSupported
+	}
+	return historyReader.GetHistory(ctx, mType, key, from, to)
+}
+
+func (cs *CachedStorage) GetRollups(ctx context.Context, mType string, key string, resolution time.Duration, from, to time.Time) ([]models.MetricRollup, error) {
+	rollupReader, ok := cs.store.(RollupReader)
+	if !ok {
+		return nil, errRollupsNotSupported
+	}
+	return rollupReader.GetRollups(ctx, mType, key, resolution, from, to)
+}
+
+func (cs *CachedStorage) GetSilences(ctx context.Context) ([]models.Silence, error) {
+	silenceStorer, ok := cs.store.(SilenceStorer)
+	if !ok {
+		return nil, errSilencesNotSupported
+	}
+	return silenceStorer.GetSilences(ctx)
+}
+
+func (cs *CachedStorage) SaveSilence(ctx context.Context, s models.Silence) error {
+	silenceStorer, ok := cs.store.(SilenceStorer)
+	if !ok {
+		return errSilencesNotSupported
+	}
+	return silenceStorer.SaveSilence(ctx, s)
+}
+
+func (cs *CachedStorage) DeleteSilence(ctx context.Context, id string) error {
+	silenceStorer, ok := cs.store.(SilenceStorer)
+	if !ok {
+		return errSilencesNotSupported
+	}
+	return silenceStorer.DeleteSilence(ctx, id)
+}
+
+// ExpireMetrics expires metrics in the wrapped storage and drops the whole
+// cache if any metric was expired.
+func (cs *CachedStorage) ExpireMetrics(ctx context.Context, before time.Time) (int, error) {
+	expirer, ok := cs.store.(MetricExpirer)
+	if !ok {
+		return 0, nil
+	}
+
+	expired, err := expirer.ExpireMetrics(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	if expired > 0 {
+		cs.cache.invalidateAll()
+		cs.notify(ctx, nil)
+	}
+	return expired, nil
+}
+
+// SelfMetrics reports the size of the cache and metrics of the wrapped storage.
+func (cs *CachedStorage) SelfMetrics() []models.MetricModel {
+	var metrics []models.MetricModel
+	if reporter, ok := cs.store.(SelfMetricsReporter); ok {
+		metrics = reporter.SelfMetrics()
+	}
+	return append(metrics, *models.NewMetricModel("storage_cache_entries", common.MetricTypeGauge, 0, float64(cs.cache.len())))
+}
+
+func (cs *CachedStorage) Ping(ctx context.Context) error {
+	return cs.store.Ping(ctx)
+}
+
+func (cs *CachedStorage) ShutDown() {
+	if cs.notifier != nil {
+		cs.notifier.stop()
+	}
+	cs.store.ShutDown()
+}
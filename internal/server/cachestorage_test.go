@@ -0,0 +1,314 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingReadStorage counts reads that reach the wrapped storage.
+type countingReadStorage struct {
+	Storager
+	reads int
+}
+
+func (s *countingReadStorage) GetGauge(ctx context.Context, key string) (float64, error) {
+	s.reads++
+	return s.Storager.GetGauge(ctx, key)
+}
+
+func (s *countingReadStorage) GetCounter(ctx context.Context, key string) (int64, error) {
+	s.reads++
+	return s.Storager.GetCounter(ctx, key)
+}
+
+func newTestCachedStorage(t *testing.T, size int) (*CachedStorage, *countingReadStorage) {
+	t.Helper()
+	inner := &countingReadStorage{Storager: newTestMemStorage(t, &StorageConfig{
+		FileStoragePath: filepath.Join(t.TempDir(), "dump.json"),
+	})}
+	cs := NewCachedStorage(inner, size)
+	t.Cleanup(cs.ShutDown)
+	return cs, inner
+}
+
+func TestCachedStorage_ReadThrough(t *testing.T) {
+	cs, inner := newTestCachedStorage(t, 10)
+	ctx := context.Background()
+
+	_, err := inner.SetGauge(ctx, "g", 1.5)
+	require.NoError(t, err)
+
+	for range 3 {
+		value, err := cs.GetGauge(ctx, "g")
+		require.NoError(t, err)
+		assert.Equal(t, 1.5, value)
+	}
+	assert.Equal(t, 1, inner.reads)
+
+	_, err = cs.GetGauge(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = cs.GetGauge(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 3, inner.reads)
+}
+
+func TestCachedStorage_WriteThrough(t *testing.T) {
+	cs, inner := newTestCachedStorage(t, 10)
+	ctx := context.Background()
+
+	_, err := cs.SetGauge(ctx, "g", 2)
+	require.NoError(t, err)
+	_, err = cs.IncrementCounter(ctx, "c", 2)
+	require.NoError(t, err)
+	_, err = cs.IncrementCounter(ctx, "c", 3)
+	require.NoError(t, err)
+
+	result, err := cs.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("c", common.MetricTypeCounter, 1, 0),
+		*models.NewMetricModel("g2", common.MetricTypeGauge, 0, 7),
+		*models.NewMetricModel("c", common.MetricTypeCounter, 4, 0),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), *result[2].Delta)
+
+	gauge, err := cs.GetGauge(ctx, "g")
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, gauge)
+	counter, err := cs.GetCounter(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), counter)
+	gauge, err = cs.GetGauge(ctx, "g2")
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, gauge)
+	assert.Equal(t, 0, inner.reads)
+
+	require.NoError(t, cs.ResetCounter(ctx, "c"))
+	counter, err = cs.GetCounter(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), counter)
+
+	require.NoError(t, cs.DeleteMetric(ctx, common.MetricTypeGauge, "g"))
+	_, err = cs.GetGauge(ctx, "g")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCachedStorage_FailedBatchIsNotCached(t *testing.T) {
+	cs, inner := newTestCachedStorage(t, 10)
+	ctx := context.Background()
+
+	_, err := cs.SetGauge(ctx, "g", 1)
+	require.NoError(t, err)
+
+	_, err = cs.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("g", common.MetricTypeGauge, 0, 2),
+		{ID: "bad", MType: "invalid"},
+	})
+	require.Error(t, err)
+
+	gauge, err := cs.GetGauge(ctx, "g")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, gauge)
+	assert.Equal(t, 1, inner.reads)
+}
+
+func TestCachedStorage_Histogram(t *testing.T) {
+	cs, _ := newTestCachedStorage(t, 10)
+	ctx := context.Background()
+
+	_, err := cs.UpdateHistogram(ctx, "h", &models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5})
+	require.NoError(t, err)
+
+	h, err := cs.GetHistogram(ctx, "h")
+	require.NoError(t, err)
+	h.Counts[0] = 100
+
+	h, err = cs.GetHistogram(ctx, "h")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 0}, h.Counts)
+}
+
+func TestCachedStorage_Eviction(t *testing.T) {
+	cs, inner := newTestCachedStorage(t, 2)
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, err := cs.SetGauge(ctx, key, 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, cs.cache.len())
+
+	_, err := cs.GetGauge(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, 0, inner.reads)
+	_, err = cs.GetGauge(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, inner.reads)
+}
+
+func TestCachedStorage_Invalidate(t *testing.T) {
+	cs, inner := newTestCachedStorage(t, 10)
+	ctx := context.Background()
+
+	_, err := cs.SetGauge(ctx, "g", 1)
+	require.NoError(t, err)
+
+	// Another replica writes to the shared storage and notifies.
+	_, err = inner.SetGauge(ctx, "g", 2)
+	require.NoError(t, err)
+	cs.cache.invalidate([]string{"g"})
+
+	gauge, err := cs.GetGauge(ctx, "g")
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, gauge)
+}
+
+func TestCachedStorage_NotSyncedBypassesCache(t *testing.T) {
+	cs, inner := newTestCachedStorage(t, 10)
+	ctx := context.Background()
+
+	_, err := cs.SetGauge(ctx, "g", 1)
+	require.NoError(t, err)
+	cs.cache.setSynced(false)
+
+	for range 2 {
+		_, err = cs.GetGauge(ctx, "g")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, inner.reads)
+
+	cs.cache.setSynced(true)
+	for range 2 {
+		_, err = cs.GetGauge(ctx, "g")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, inner.reads)
+}
+
+func TestMetricCache_OverlappingWritesAreNotCached(t *testing.T) {
+	c := newMetricCache(10)
+	keys := []metricKey{{mType: common.MetricTypeCounter, id: "c"}}
+
+	c.beginWrite(keys)
+	c.beginWrite(keys)
+	c.endWrite(keys, []any{int64(2)})
+	c.endWrite(keys, []any{int64(1)})
+	_, ok := c.get(keys[0])
+	assert.False(t, ok)
+
+	c.beginWrite(keys)
+	c.endWrite(keys, []any{int64(3)})
+	value, ok := c.get(keys[0])
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), value)
+}
+
+func TestMetricCache_StaleReadIsNotCached(t *testing.T) {
+	c := newMetricCache(10)
+	mk := metricKey{mType: common.MetricTypeGauge, id: "g"}
+
+	version := c.beginRead()
+	c.invalidate([]string{"g"})
+	c.fill(mk, 1.0, version)
+	_, ok := c.get(mk)
+	assert.False(t, ok)
+
+	version = c.beginRead()
+	c.beginWrite([]metricKey{mk})
+	c.fill(mk, 1.0, version)
+	c.endWrite([]metricKey{mk}, []any{2.0})
+	value, ok := c.get(mk)
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, value)
+}
+
+func TestMetricCache_InvalidationDuringWrite(t *testing.T) {
+	c := newMetricCache(10)
+	keys := []metricKey{{mType: common.MetricTypeGauge, id: "g"}}
+
+	c.beginWrite(keys)
+	c.invalidate([]string{"g"})
+	c.endWrite(keys, []any{1.0})
+	_, ok := c.get(keys[0])
+	assert.False(t, ok)
+}
+
+func TestPGCacheNotifier_Handle(t *testing.T) {
+	c := newMetricCache(10)
+	n := &pgCacheNotifier{cache: c, origin: "self"}
+	gauge := metricKey{mType: common.MetricTypeGauge, id: "g"}
+	counter := metricKey{mType: common.MetricTypeCounter, id: "g"}
+	other := metricKey{mType: common.MetricTypeGauge, id: "other"}
+
+	fill := func() {
+		for _, mk := range []metricKey{gauge, counter, other} {
+			c.fill(mk, 1, c.beginRead())
+		}
+	}
+
+	fill()
+	n.handle(`{"origin":"self","keys":["g"]}`)
+	_, ok := c.get(gauge)
+	assert.True(t, ok, "own notifications are ignored")
+
+	n.handle(`{"origin":"replica","keys":["g"]}`)
+	_, ok = c.get(gauge)
+	assert.False(t, ok)
+	_, ok = c.get(counter)
+	assert.False(t, ok)
+	_, ok = c.get(other)
+	assert.True(t, ok)
+
+	fill()
+	n.handle(`{"origin":"replica","all":true}`)
+	assert.Equal(t, 0, c.len())
+
+	fill()
+	n.handle(`not json`)
+	assert.Equal(t, 0, c.len())
+}
+
+func TestCachedStorage_ReplicasShareDatabase(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	ctx := context.Background()
+
+	newReplica := func() *CachedStorage {
+		dbs, err := NewDBStorage(NewDBConfig(dsn))
+		require.NoError(t, err)
+		cs := NewCachedStorage(dbs, 10)
+		cs.notifier = startPGCacheNotifier(dbs.pool, cs.cache)
+		t.Cleanup(cs.ShutDown)
+		return cs
+	}
+	first, second := newReplica(), newReplica()
+	for _, cs := range []*CachedStorage{first, second} {
+		require.Eventually(t, func() bool {
+			cs.cache.mu.Lock()
+			defer cs.cache.mu.Unlock()
+			return cs.cache.synced
+		}, 10*time.Second, 10*time.Millisecond)
+	}
+
+	_, err := first.SetGauge(ctx, "cache_replica_gauge", 1)
+	require.NoError(t, err)
+	gauge, err := second.GetGauge(ctx, "cache_replica_gauge")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, gauge)
+
+	_, err = first.SetGauge(ctx, "cache_replica_gauge", 2)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		gauge, err := second.GetGauge(ctx, "cache_replica_gauge")
+		return err == nil && gauge == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
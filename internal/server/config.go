@@ -5,42 +5,169 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/etoneja/go-metrics/internal/common"
 )
 
 type config struct {
-	ServerAddress     string `env:"ADDRESS" json:"address"`
-	ServerGRPCAddress string `env:"GRPC_ADDRESS" json:"grpc_address"`
-	StoreInterval     uint   `env:"STORE_INTERVAL" json:"store_interval"`
-	FileStoragePath   string `env:"FILE_STORAGE_PATH" json:"store_file"`
-	Restore           bool   `env:"RESTORE" json:"restore"`
-	DatabaseDSN       string `env:"DATABASE_DSN" json:"database_dsn"`
-	HashKey           string `env:"KEY" json:"-"`
-	CryptoKey         string `env:"CRYPTO_KEY" json:"crypto_key"`
-	ConfigFile        string `env:"CONFIG" json:"-"`
-	TrustedSubnet     string `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
-	privateKey        *rsa.PrivateKey
+	ServerAddress       string `env:"ADDRESS" json:"address"`
+	ServerGRPCAddress   string `env:"GRPC_ADDRESS" json:"grpc_address"`
+	AdminAddress        string `env:"ADMIN_ADDRESS" json:"admin_address"`
+	StoreInterval       uint   `env:"STORE_INTERVAL" json:"store_interval"`
+	FileStoragePath     string `env:"FILE_STORAGE_PATH" json:"store_file"`
+	Restore             bool   `env:"RESTORE" json:"restore"`
+	DatabaseDSN         string `env:"DATABASE_DSN" json:"database_dsn"`
+	HashKey             string `env:"KEY" json:"-"`
+	CryptoKey           string `env:"CRYPTO_KEY" json:"crypto_key"`
+	ConfigFile          string `env:"CONFIG" json:"-"`
+	TrustedSubnet       string `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
+	HistorySize         uint   `env:"HISTORY_SIZE" json:"history_size"`
+	HistoryRetention    string `env:"HISTORY_RETENTION" json:"history_retention"`
+	CompactInterval     uint   `env:"HISTORY_COMPACT_INTERVAL" json:"history_compact_interval"`
+	MetricTTL           uint   `env:"METRIC_TTL" json:"metric_ttl"`
+	MigrateOnly         bool   `env:"MIGRATE_ONLY" json:"-"`
+	WALPath             string `env:"WAL_PATH" json:"wal_path"`
+	BoltPath            string `env:"BOLT_PATH" json:"bolt_path"`
+	CacheSize           uint   `env:"CACHE_SIZE" json:"cache_size"`
+	DBMaxConns          uint   `env:"DB_MAX_CONNS" json:"db_max_conns"`
+	DBMinConns          uint   `env:"DB_MIN_CONNS" json:"db_min_conns"`
+	DBMaxConnLifetime   uint   `env:"DB_MAX_CONN_LIFETIME" json:"db_max_conn_lifetime"`
+	DBMaxConnIdleTime   uint   `env:"DB_MAX_CONN_IDLE_TIME" json:"db_max_conn_idle_time"`
+	DBStmtTimeout       uint   `env:"DB_STATEMENT_TIMEOUT" json:"db_statement_timeout"`
+	DBConnectBackoff    string `env:"DB_CONNECT_BACKOFF" json:"db_connect_backoff"`
+	AlertRulesFile      string `env:"ALERT_RULES_FILE" json:"alert_rules_file"`
+	AlertInterval       uint   `env:"ALERT_INTERVAL" json:"alert_interval"`
+	AlertWebhooks       string `env:"ALERT_WEBHOOKS" json:"alert_webhooks"`
+	AlertOutboxPath     string `env:"ALERT_OUTBOX_PATH" json:"alert_outbox_path"`
+	StatsDAddress       string `env:"STATSD_ADDRESS" json:"statsd_address"`
+	StatsDFlushInterval uint   `env:"STATSD_FLUSH_INTERVAL" json:"statsd_flush_interval"`
+	CarbonAddress       string `env:"CARBON_ADDRESS" json:"carbon_address"`
+	CarbonMappingFile   string `env:"CARBON_MAPPING_FILE" json:"carbon_mapping_file"`
+	ShutdownDrainDelay  uint   `env:"SHUTDOWN_DRAIN_DELAY" json:"shutdown_drain_delay"`
+	privateKey          *rsa.PrivateKey
+	retentionPolicy     *RetentionPolicy
+	dbConnectBackoff    []time.Duration
+	alertRules          []*AlertRule
+	carbonMappings      []*GraphiteMapping
 }
 
 func (c *config) GetPrivateKey() *rsa.PrivateKey {
 	return c.privateKey
 }
 
+func (c *config) GetRetentionPolicy() *RetentionPolicy {
+	return c.retentionPolicy
+}
+
+func (c *config) GetAlertRules() []*AlertRule {
+	return c.alertRules
+}
+
+func (c *config) GetCarbonMappings() []*GraphiteMapping {
+	return c.carbonMappings
+}
+
+// GetAlertWebhooks returns the configured webhook URLs.
+func (c *config) GetAlertWebhooks() []string {
+	var urls []string
+	for _, webhook := range strings.Split(c.AlertWebhooks, ",") {
+		if webhook = strings.TrimSpace(webhook); webhook != "" {
+			urls = append(urls, webhook)
+		}
+	}
+	return urls
+}
+
+func (c *config) GetMetricTTL() time.Duration {
+	return time.Duration(c.MetricTTL) * time.Second
+}
+
+func (c *config) GetShutdownDrainDelay() time.Duration {
+	return time.Duration(c.ShutdownDrainDelay) * time.Second
+}
+
+// GetDBConfig returns the configuration of the database storage.
+func (c *config) GetDBConfig() *DBConfig {
+	dc := NewDBConfig(c.DatabaseDSN)
+	dc.MaxConns = int32(c.DBMaxConns)
+	dc.MinConns = int32(c.DBMinConns)
+	dc.MaxConnLifetime = time.Duration(c.DBMaxConnLifetime) * time.Second
+	dc.MaxConnIdleTime = time.Duration(c.DBMaxConnIdleTime) * time.Second
+	dc.StatementTimeout = time.Duration(c.DBStmtTimeout) * time.Second
+	if c.dbConnectBackoff != nil {
+		dc.ConnectBackoff = c.dbConnectBackoff
+	}
+	return dc
+}
+
+// parseBackoffSchedule parses comma separated durations, e.g. 1s,3s,5s.
+func parseBackoffSchedule(s string) ([]time.Duration, error) {
+	schedule := []time.Duration{}
+	if strings.TrimSpace(s) == "" {
+		return schedule, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid backoff schedule '%s': %w", s, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("invalid backoff schedule '%s': negative delay", s)
+		}
+		schedule = append(schedule, d)
+	}
+	return schedule, nil
+}
+
+func formatBackoffSchedule(schedule []time.Duration) string {
+	parts := make([]string, len(schedule))
+	for i, d := range schedule {
+		parts[i] = d.String()
+	}
+	return strings.Join(parts, ",")
+}
+
 func PrepareConfig() (*config, error) {
 	cfg := &config{
-		ServerAddress:     "localhost:8080",
-		ServerGRPCAddress: "",
-		StoreInterval:     300,
-		FileStoragePath:   "data.json",
-		Restore:           false,
-		DatabaseDSN:       "",
-		HashKey:           "",
-		CryptoKey:         "",
-		ConfigFile:        "",
-		TrustedSubnet:     "",
+		ServerAddress:       "localhost:8080",
+		ServerGRPCAddress:   "",
+		AdminAddress:        "",
+		StoreInterval:       300,
+		FileStoragePath:     "data.json",
+		Restore:             false,
+		DatabaseDSN:         "",
+		HashKey:             "",
+		CryptoKey:           "",
+		ConfigFile:          "",
+		TrustedSubnet:       "",
+		HistorySize:         DefaultHistorySize,
+		HistoryRetention:    DefaultHistoryRetention,
+		CompactInterval:     60,
+		MetricTTL:           0,
+		MigrateOnly:         false,
+		WALPath:             "",
+		BoltPath:            "",
+		CacheSize:           0,
+		DBMaxConns:          DefaultDBMaxConns,
+		DBMinConns:          DefaultDBMinConns,
+		DBMaxConnLifetime:   uint(DefaultDBMaxConnLifetime / time.Second),
+		DBMaxConnIdleTime:   uint(DefaultDBMaxConnIdleTime / time.Second),
+		DBStmtTimeout:       0,
+		DBConnectBackoff:    formatBackoffSchedule(common.DefaultBackoffSchedule),
+		AlertRulesFile:      "",
+		AlertInterval:       15,
+		AlertWebhooks:       "",
+		AlertOutboxPath:     "",
+		StatsDAddress:       "",
+		StatsDFlushInterval: 10,
+		CarbonAddress:       "",
+		CarbonMappingFile:   "",
+		ShutdownDrainDelay:  5,
 	}
 	parseFlags(cfg)
 
@@ -61,6 +188,36 @@ func PrepareConfig() (*config, error) {
 		return nil, err
 	}
 
+	retentionPolicy, err := ParseRetentionPolicy(cfg.HistoryRetention)
+	if err != nil {
+		return nil, err
+	}
+	cfg.retentionPolicy = retentionPolicy
+	if cfg.DatabaseDSN == "" && cfg.BoltPath == "" {
+		err = checkHistorySize(cfg.HistorySize, retentionPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dbConnectBackoff, err := parseBackoffSchedule(cfg.DBConnectBackoff)
+	if err != nil {
+		return nil, err
+	}
+	cfg.dbConnectBackoff = dbConnectBackoff
+
+	alertRules, err := LoadAlertRules(cfg.AlertRulesFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.alertRules = alertRules
+
+	carbonMappings, err := LoadGraphiteMappings(cfg.CarbonMappingFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.carbonMappings = carbonMappings
+
 	privateKey, err := common.LoadPrivateKey(cfg.CryptoKey)
 	if err != nil {
 		return nil, err
@@ -73,6 +230,7 @@ func PrepareConfig() (*config, error) {
 func parseFlags(cfg *config) {
 	flag.StringVar(&cfg.ServerAddress, "a", cfg.ServerAddress, "address and port to start http server")
 	flag.StringVar(&cfg.ServerGRPCAddress, "g", cfg.ServerGRPCAddress, "address and port to start grpc server")
+	flag.StringVar(&cfg.AdminAddress, "admin-address", cfg.AdminAddress, "address and port to serve server self metrics (empty disables)")
 	flag.UintVar(&cfg.StoreInterval, "i", cfg.StoreInterval, "store interval (seconds)")
 	flag.StringVar(&cfg.FileStoragePath, "f", cfg.FileStoragePath, "data dump file path")
 	flag.BoolVar(&cfg.Restore, "r", cfg.Restore, "restore dump (bool)")
@@ -82,6 +240,29 @@ func parseFlags(cfg *config) {
 	flag.StringVar(&cfg.ConfigFile, "c", "", "Config file path")
 	flag.StringVar(&cfg.ConfigFile, "config", "", "Config file path")
 	flag.StringVar(&cfg.TrustedSubnet, "t", cfg.TrustedSubnet, "Trusted subnet")
+	flag.UintVar(&cfg.HistorySize, "history-size", cfg.HistorySize, "samples kept per metric in memory history (0 disables)")
+	flag.StringVar(&cfg.HistoryRetention, "history-retention", cfg.HistoryRetention, "history retention, e.g. raw:24h,1m:30d,1h:365d (empty disables)")
+	flag.UintVar(&cfg.CompactInterval, "history-compact-interval", cfg.CompactInterval, "history compaction interval (seconds)")
+	flag.UintVar(&cfg.MetricTTL, "metric-ttl", cfg.MetricTTL, "evict metrics not updated for this long (seconds, 0 disables)")
+	flag.StringVar(&cfg.WALPath, "wal", cfg.WALPath, "write-ahead log path for memory storage (empty disables)")
+	flag.StringVar(&cfg.BoltPath, "bolt", cfg.BoltPath, "embedded bolt storage file path, used when no database DSN is set (empty disables)")
+	flag.UintVar(&cfg.CacheSize, "cache-size", cfg.CacheSize, "metric values cached in front of the database (0 disables)")
+	flag.UintVar(&cfg.DBMaxConns, "db-max-conns", cfg.DBMaxConns, "maximum database connections in the pool")
+	flag.UintVar(&cfg.DBMinConns, "db-min-conns", cfg.DBMinConns, "minimum database connections kept in the pool")
+	flag.UintVar(&cfg.DBMaxConnLifetime, "db-max-conn-lifetime", cfg.DBMaxConnLifetime, "database connection lifetime (seconds)")
+	flag.UintVar(&cfg.DBMaxConnIdleTime, "db-max-conn-idle-time", cfg.DBMaxConnIdleTime, "database connection idle time before it is closed (seconds)")
+	flag.UintVar(&cfg.DBStmtTimeout, "db-statement-timeout", cfg.DBStmtTimeout, "database statement timeout (seconds, 0 disables)")
+	flag.StringVar(&cfg.DBConnectBackoff, "db-connect-backoff", cfg.DBConnectBackoff, "delays between database connection attempts on start, e.g. 1s,3s,5s")
+	flag.StringVar(&cfg.AlertRulesFile, "alert-rules", cfg.AlertRulesFile, "alerting rules file path (empty disables)")
+	flag.UintVar(&cfg.AlertInterval, "alert-interval", cfg.AlertInterval, "alerting rules evaluation interval (seconds)")
+	flag.StringVar(&cfg.AlertWebhooks, "alert-webhooks", cfg.AlertWebhooks, "comma separated webhook URLs alerts are posted to (empty disables)")
+	flag.StringVar(&cfg.AlertOutboxPath, "alert-outbox", cfg.AlertOutboxPath, "file keeping undelivered alert notifications (empty keeps them in memory)")
+	flag.StringVar(&cfg.StatsDAddress, "statsd-address", cfg.StatsDAddress, "address and port to receive StatsD over UDP and TCP (empty disables)")
+	flag.UintVar(&cfg.StatsDFlushInterval, "statsd-flush-interval", cfg.StatsDFlushInterval, "StatsD aggregation interval (seconds)")
+	flag.StringVar(&cfg.CarbonAddress, "carbon-address", cfg.CarbonAddress, "address and port to receive Graphite carbon plaintext over TCP (empty disables)")
+	flag.StringVar(&cfg.CarbonMappingFile, "carbon-mapping", cfg.CarbonMappingFile, "file of rules mapping Graphite paths to metric IDs and labels (empty keeps paths as IDs)")
+	flag.UintVar(&cfg.ShutdownDrainDelay, "shutdown-drain-delay", cfg.ShutdownDrainDelay, "time readiness fails before listeners close on shutdown (seconds, 0 disables)")
+	flag.BoolVar(&cfg.MigrateOnly, "migrate-only", cfg.MigrateOnly, "apply database migrations and exit")
 	flag.Parse()
 }
 
@@ -94,6 +275,27 @@ func parseEnvOpts(cfg *config) error {
 }
 
 func validateConfig(cfg *config) error {
+	if cfg.MigrateOnly && cfg.DatabaseDSN == "" {
+		return fmt.Errorf("migrate-only mode requires a database DSN")
+	}
+	if cfg.DBMaxConns == 0 {
+		return fmt.Errorf("database max connections must be positive")
+	}
+	if cfg.DBMinConns > cfg.DBMaxConns {
+		return fmt.Errorf("database min connections %d exceed max connections %d", cfg.DBMinConns, cfg.DBMaxConns)
+	}
+	if cfg.AlertRulesFile != "" && cfg.AlertInterval == 0 {
+		return fmt.Errorf("alert interval must be positive")
+	}
+	if cfg.StatsDAddress != "" && cfg.StatsDFlushInterval == 0 {
+		return fmt.Errorf("statsd flush interval must be positive")
+	}
+	for _, webhook := range cfg.GetAlertWebhooks() {
+		u, err := url.Parse(webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid alert webhook URL '%s'", webhook)
+		}
+	}
 	if cfg.TrustedSubnet != "" {
 		_, _, err := net.ParseCIDR(cfg.TrustedSubnet)
 		if err != nil {
@@ -3,7 +3,10 @@ package server
 import (
 	"flag"
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 )
 
 // TestPrepareConfig_EnvPrecedence tests that environment variables override flag values
@@ -155,3 +158,242 @@ func TestPrepareConfig_BoolEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestPrepareConfig_HistoryRetention(t *testing.T) {
+	os.Args = []string{"test"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	cfg, err := PrepareConfig()
+	if err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+	if cfg.GetRetentionPolicy() == nil || len(cfg.GetRetentionPolicy().Tiers) != 2 {
+		t.Errorf("expected default retention policy, got %+v", cfg.GetRetentionPolicy())
+	}
+
+	os.Args = []string{"test"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	t.Setenv("HISTORY_RETENTION", "1m:1h")
+
+	_, err = PrepareConfig()
+	if err == nil {
+		t.Error("expected error for retention without raw entry")
+	}
+}
+
+func TestPrepareConfig_HistorySizeFitsRetention(t *testing.T) {
+	os.Args = []string{"test", "-history-retention", "raw:48h"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	_, err := PrepareConfig()
+	if err == nil {
+		t.Error("expected error for raw retention the default history size cannot hold")
+	}
+
+	os.Args = []string{"test", "-history-retention", "raw:48h", "-history-size", "17280"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	if _, err = PrepareConfig(); err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+
+	// The database keeps history itself.
+	os.Args = []string{"test", "-history-retention", "raw:48h", "-d", "flag-dsn"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	if _, err = PrepareConfig(); err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+}
+
+func TestPrepareConfig_MigrateOnly(t *testing.T) {
+	os.Args = []string{"test", "-migrate-only"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	_, err := PrepareConfig()
+	if err == nil {
+		t.Error("expected error for migrate-only without database DSN")
+	}
+
+	os.Args = []string{"test", "-migrate-only", "-d", "flag-dsn"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	cfg, err := PrepareConfig()
+	if err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+	if !cfg.MigrateOnly {
+		t.Error("expected migrate-only mode")
+	}
+}
+
+func TestPrepareConfig_DBPool(t *testing.T) {
+	os.Args = []string{"test", "-d", "postgres://localhost/metrics", "-db-max-conns", "20", "-db-statement-timeout", "5", "-db-connect-backoff", "100ms, 2s"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	cfg, err := PrepareConfig()
+	if err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+
+	dc := cfg.GetDBConfig()
+	if dc.MaxConns != 20 || dc.MinConns != DefaultDBMinConns {
+		t.Errorf("unexpected pool size %d/%d", dc.MinConns, dc.MaxConns)
+	}
+	if dc.MaxConnLifetime != DefaultDBMaxConnLifetime || dc.MaxConnIdleTime != DefaultDBMaxConnIdleTime {
+		t.Errorf("unexpected connection lifetime %v/%v", dc.MaxConnLifetime, dc.MaxConnIdleTime)
+	}
+	if dc.StatementTimeout != 5*time.Second {
+		t.Errorf("unexpected statement timeout %v", dc.StatementTimeout)
+	}
+	if !slices.Equal(dc.ConnectBackoff, []time.Duration{100 * time.Millisecond, 2 * time.Second}) {
+		t.Errorf("unexpected backoff schedule %v", dc.ConnectBackoff)
+	}
+
+	pc, err := dc.poolConfig()
+	if err != nil {
+		t.Fatalf("poolConfig failed: %v", err)
+	}
+	if pc.ConnConfig.RuntimeParams["statement_timeout"] != "5000" {
+		t.Errorf("unexpected statement_timeout %q", pc.ConnConfig.RuntimeParams["statement_timeout"])
+	}
+}
+
+func TestPrepareConfig_DBPoolInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"test", "-db-max-conns", "0"},
+		{"test", "-db-max-conns", "2", "-db-min-conns", "3"},
+		{"test", "-db-connect-backoff", "1s,soon"},
+	} {
+		os.Args = args
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+		_, err := PrepareConfig()
+		if err == nil {
+			t.Errorf("expected error for %v", args[1:])
+		}
+	}
+}
+
+func TestPrepareConfig_AlertRules(t *testing.T) {
+	path := t.TempDir() + "/rules.json"
+	if err := os.WriteFile(path, []byte(`{"rules": [{"name": "HighHeap", "expr": "gauge HeapAlloc > 500MB for 2m"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Args = []string{"test", "-alert-rules", path, "-alert-interval", "30"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	cfg, err := PrepareConfig()
+	if err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+	if len(cfg.GetAlertRules()) != 1 || cfg.GetAlertRules()[0].Name != "HighHeap" {
+		t.Errorf("unexpected alert rules %v", cfg.GetAlertRules())
+	}
+	if cfg.AlertInterval != 30 {
+		t.Errorf("unexpected alert interval %d", cfg.AlertInterval)
+	}
+
+	for _, args := range [][]string{
+		{"test", "-alert-rules", t.TempDir() + "/missing.json"},
+		{"test", "-alert-rules", path, "-alert-interval", "0"},
+	} {
+		os.Args = args
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+		if _, err := PrepareConfig(); err == nil {
+			t.Errorf("expected error for %v", args[1:])
+		}
+	}
+}
+
+func TestPrepareConfig_AlertWebhooks(t *testing.T) {
+	os.Args = []string{"test", "-alert-webhooks", "http://localhost:9000/hook, https://alerts.example.com/in"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	cfg, err := PrepareConfig()
+	if err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+	want := []string{"http://localhost:9000/hook", "https://alerts.example.com/in"}
+	if !slices.Equal(cfg.GetAlertWebhooks(), want) {
+		t.Errorf("unexpected webhooks %v", cfg.GetAlertWebhooks())
+	}
+
+	for _, webhooks := range []string{"localhost:9000", "ftp://example.com/in", "http://"} {
+		os.Args = []string{"test", "-alert-webhooks", webhooks}
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+		if _, err := PrepareConfig(); err == nil {
+			t.Errorf("expected error for %q", webhooks)
+		}
+	}
+}
+
+func TestPrepareConfig_ShutdownDrainDelay(t *testing.T) {
+	os.Args = []string{"test"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	cfg, err := PrepareConfig()
+	if err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+	if cfg.GetShutdownDrainDelay() != 5*time.Second {
+		t.Errorf("expected default drain delay 5s, got %v", cfg.GetShutdownDrainDelay())
+	}
+
+	t.Setenv("SHUTDOWN_DRAIN_DELAY", "0")
+	os.Args = []string{"test", "-shutdown-drain-delay", "15"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	cfg, err = PrepareConfig()
+	if err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+	if cfg.GetShutdownDrainDelay() != 0 {
+		t.Errorf("expected env to disable the drain delay, got %v", cfg.GetShutdownDrainDelay())
+	}
+}
+
+func TestPrepareConfig_StatsD(t *testing.T) {
+	os.Args = []string{"test", "-statsd-address", "localhost:8125"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	cfg, err := PrepareConfig()
+	if err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+	if cfg.StatsDAddress != "localhost:8125" || cfg.StatsDFlushInterval != 10 {
+		t.Errorf("unexpected statsd config %q %d", cfg.StatsDAddress, cfg.StatsDFlushInterval)
+	}
+
+	os.Args = []string{"test", "-statsd-address", "localhost:8125", "-statsd-flush-interval", "0"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	if _, err := PrepareConfig(); err == nil {
+		t.Error("expected error for zero flush interval")
+	}
+}
+
+func TestPrepareConfig_CarbonMappings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	if err := os.WriteFile(path, []byte(`{"mappings": [{"match": "servers.*.load", "name": "load", "labels": {"host": "$1"}}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Args = []string{"test", "-carbon-address", "localhost:2003", "-carbon-mapping", path}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	cfg, err := PrepareConfig()
+	if err != nil {
+		t.Fatalf("PrepareConfig failed: %v", err)
+	}
+	if len(cfg.GetCarbonMappings()) != 1 {
+		t.Errorf("expected 1 mapping, got %d", len(cfg.GetCarbonMappings()))
+	}
+
+	if err := os.WriteFile(path, []byte(`{"mappings": [{"match": "servers.*.load", "name": "load_$2"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	if _, err := PrepareConfig(); err == nil {
+		t.Error("expected error for invalid mapping")
+	}
+}
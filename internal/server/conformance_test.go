@@ -0,0 +1,112 @@
+package server_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/etoneja/go-metrics/internal/server"
+	"github.com/etoneja/go-metrics/internal/server/storagetest"
+	"github.com/jackc/pgx/v5"
+)
+
+// testDatabaseDSNEnv names the variable with the DSN of a disposable
+// Postgres-compatible database (e.g. a local container) for DBStorage tests.
+// Its tables are truncated before every test.
+const testDatabaseDSNEnv = "TEST_DATABASE_DSN"
+
+func newMemStorage(t *testing.T, sc *server.StorageConfig) *server.MemStorage {
+	t.Helper()
+	ms, err := server.NewMemStorageFromStorageConfig(sc)
+	if err != nil {
+		t.Fatalf("failed to create memstorage: %v", err)
+	}
+	return ms
+}
+
+func newBoltStorage(t *testing.T, path string) *server.BoltStorage {
+	t.Helper()
+	bs, err := server.NewBoltStorage(path)
+	if err != nil {
+		t.Fatalf("failed to create boltstorage: %v", err)
+	}
+	return bs
+}
+
+func TestMemStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) server.Storager {
+		return newMemStorage(t, &server.StorageConfig{
+			FileStoragePath: filepath.Join(t.TempDir(), "dump.json"),
+		})
+	})
+}
+
+func TestMemStorage_WAL_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) server.Storager {
+		dir := t.TempDir()
+		return newMemStorage(t, &server.StorageConfig{
+			StoreInterval:   3600,
+			FileStoragePath: filepath.Join(dir, "dump.json"),
+			WALPath:         filepath.Join(dir, "metrics.wal"),
+		})
+	})
+}
+
+func TestCachedStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) server.Storager {
+		return server.NewCachedStorage(newMemStorage(t, &server.StorageConfig{
+			FileStoragePath: filepath.Join(t.TempDir(), "dump.json"),
+		}), 100)
+	})
+}
+
+func TestInstrumentedStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) server.Storager {
+		return server.NewInstrumentedStorage(newMemStorage(t, &server.StorageConfig{
+			FileStoragePath: filepath.Join(t.TempDir(), "dump.json"),
+		}))
+	})
+}
+
+func TestBoltStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) server.Storager {
+		return newBoltStorage(t, filepath.Join(t.TempDir(), "metrics.db"))
+	})
+}
+
+func TestDBStorage_Conformance(t *testing.T) {
+	dsn := os.Getenv(testDatabaseDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseDSNEnv)
+	}
+
+	storagetest.Run(t, func(t *testing.T) server.Storager {
+		store, err := server.NewDBStorage(server.NewDBConfig(dsn))
+		if err != nil {
+			t.Fatalf("failed to connect to test database: %v", err)
+		}
+		truncateTestDatabase(t, dsn)
+		return store
+	})
+}
+
+func truncateTestDatabase(t *testing.T, dsn string) {
+	t.Helper()
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			t.Logf("failed to close test database connection: %v", err)
+		}
+	}()
+
+	_, err = conn.Exec(ctx, "TRUNCATE metrics, metric_samples, metric_rollups, histograms, summaries, silences;")
+	if err != nil {
+		t.Fatalf("failed to truncate test database: %v", err)
+	}
+}
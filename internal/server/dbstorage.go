@@ -3,10 +3,14 @@ package server
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
+	"slices"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/etoneja/go-metrics/internal/common"
@@ -18,41 +22,176 @@ import (
 	"go.uber.org/zap"
 )
 
-const MaxConns = 10
-const MinConns = 2
-const MaxConnLifetime = time.Hour
-const MaxConnIdleTime = time.Minute * 30
+// Defaults of DBConfig.
+const (
+	DefaultDBMaxConns        = 10
+	DefaultDBMinConns        = 2
+	DefaultDBMaxConnLifetime = time.Hour
+	DefaultDBMaxConnIdleTime = time.Minute * 30
+)
+
+// dbRollupMinGrace is the least time after its end a bucket of raw samples
+// is rolled up, it applies when the statement timeout is shorter or off.
+const dbRollupMinGrace = time.Minute
 
 const (
 	queryInsertGauge = `
-		INSERT INTO metrics (id, value) 
-		VALUES ($1, $2)
-		ON CONFLICT (id)
-		DO UPDATE SET
-			value = $2
+		WITH upsert AS (
+			INSERT INTO metrics (id, value) 
+			VALUES ($1, $2)
+			ON CONFLICT (id)
+			DO UPDATE SET
+				value = $2,
+				updated_at = now()
+			RETURNING value
+		)
+		INSERT INTO metric_samples (id, mtype, value)
+		SELECT $1, 'gauge', value FROM upsert
 		RETURNING value;
 	`
 	queryInsertCounter = `
-		INSERT INTO metrics (id, delta) 
-		VALUES ($1, $2)
+		WITH upsert AS (
+			INSERT INTO metrics (id, delta) 
+			VALUES ($1, $2)
+			ON CONFLICT (id)
+			DO UPDATE SET
+				delta = coalesce(metrics.delta, 0) + $2,
+				updated_at = now()
+			RETURNING delta
+		)
+		INSERT INTO metric_samples (id, mtype, delta, increment)
+		SELECT $1, 'counter', delta, $2 FROM upsert
+		RETURNING delta;
+	`
+	// queryUpsertGauges and queryUpsertCounters write aggregated batches,
+	// a key must not repeat within one call.
+	queryUpsertGauges = `
+		INSERT INTO metrics (id, value)
+		SELECT * FROM unnest($1::text[], $2::double precision[])
 		ON CONFLICT (id)
 		DO UPDATE SET
-			delta = coalesce(metrics.delta, 0) + $2
-		RETURNING delta;
+			value = EXCLUDED.value,
+			updated_at = now();
 	`
-	querySelectCounter      = "select delta from metrics where id = $1;"
-	querySelectGauge        = "select value from metrics where id = $1;"
-	querySelectAllMetrics   = "select id, delta, value from metrics;"
-	queryCreateMetricsTable = `
-		CREATE TABLE IF NOT EXISTS metrics (
-			id varchar(150) primary key,
-			delta bigint null,
-			value double precision null
-	);`
+	queryUpsertCounters = `
+		INSERT INTO metrics (id, delta)
+		SELECT * FROM unnest($1::text[], $2::bigint[])
+		ON CONFLICT (id)
+		DO UPDATE SET
+			delta = coalesce(metrics.delta, 0) + EXCLUDED.delta,
+			updated_at = now()
+		RETURNING id, delta;
+	`
+	queryInsertSamples = `
+		INSERT INTO metric_samples (id, mtype, value, delta, increment)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::double precision[], $4::bigint[], $5::bigint[]);
+	`
+	querySelectCounter    = "select delta from metrics where id = $1;"
+	querySelectGauge      = "select value from metrics where id = $1;"
+	querySelectAllMetrics = "select id, updated_at, delta, value from metrics;"
+	querySelectSamples    = "select ts, delta, value, increment from metric_samples where mtype = $1 and id = $2 and ts >= $3 and ts <= $4 order by ts;"
+	querySelectRollups    = `
+		select ts, count, min, max, avg, last, sum from metric_rollups
+		where mtype = $1 and id = $2 and resolution = $3 and ts >= $4 and ts <= $5
+		order by ts;
+	`
+	// queryRollupSamples aggregates raw samples of complete buckets that are
+	// newer than the last rollup of the resolution ($1 seconds) and older than $2.
+	queryRollupSamples = `
+		INSERT INTO metric_rollups (id, mtype, resolution, ts, count, min, max, avg, last, sum)
+		SELECT id, mtype, $1::bigint, to_timestamp(floor(extract(epoch from ts) / $1::bigint) * $1::bigint) AS bucket,
+			count(*), min(value), max(value), avg(value), (array_agg(value ORDER BY ts DESC))[1], sum(increment)
+		FROM metric_samples
+		WHERE ts >= coalesce(
+				(SELECT max(ts) FROM metric_rollups WHERE resolution = $1::bigint) + make_interval(secs => $1::bigint),
+				'-infinity'::timestamptz
+			)
+			AND ts < $2
+		GROUP BY id, mtype, bucket
+		ON CONFLICT (mtype, id, resolution, ts) DO NOTHING;
+	`
+	// queryRollupRollups aggregates rollups of resolution $2 seconds into
+	// rollups of resolution $1 seconds the same way as queryRollupSamples.
+	queryRollupRollups = `
+		INSERT INTO metric_rollups (id, mtype, resolution, ts, count, min, max, avg, last, sum)
+		SELECT id, mtype, $1::bigint, to_timestamp(floor(extract(epoch from ts) / $1::bigint) * $1::bigint) AS bucket,
+			sum(count), min(min), max(max), sum(avg * count) / sum(count), (array_agg(last ORDER BY ts DESC))[1], sum(sum)
+		FROM metric_rollups
+		WHERE resolution = $2::bigint
+			AND ts >= coalesce(
+				(SELECT max(ts) FROM metric_rollups WHERE resolution = $1::bigint) + make_interval(secs => $1::bigint),
+				'-infinity'::timestamptz
+			)
+			AND ts < $3
+		GROUP BY id, mtype, bucket
+		ON CONFLICT (mtype, id, resolution, ts) DO NOTHING;
+	`
+	// queryMergeHistogram adds bucket counts and sum to the stored histogram.
+	// No row is returned if the stored histogram has different bounds.
+	queryMergeHistogram = `
+		INSERT INTO histograms (id, bounds, counts, sum)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id)
+		DO UPDATE SET
+			counts = (
+				SELECT array_agg(a + b ORDER BY i)
+				FROM unnest(histograms.counts, EXCLUDED.counts) WITH ORDINALITY AS t(a, b, i)
+			),
+			sum = histograms.sum + EXCLUDED.sum,
+			updated_at = now()
+		WHERE histograms.bounds = EXCLUDED.bounds
+		RETURNING bounds, counts, sum;
+	`
+	querySelectHistogram        = "select bounds, counts, sum from histograms where id = $1;"
+	querySelectAllHistograms    = "select id, updated_at, bounds, counts, sum from histograms;"
+	queryInsertEmptySummary     = "INSERT INTO summaries (id, sketch) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING;"
+	querySelectSummaryForUpdate = "select sketch from summaries where id = $1 for update;"
+	queryUpdateSummary          = "UPDATE summaries SET sketch = $2, updated_at = now() WHERE id = $1;"
+	querySelectSummary          = "select sketch from summaries where id = $1;"
+	querySelectAllSummaries     = "select id, updated_at, sketch from summaries;"
+	queryDeleteGauge            = "UPDATE metrics SET value = NULL WHERE id = $1 AND value IS NOT NULL;"
+	queryDeleteCounter          = "UPDATE metrics SET delta = NULL WHERE id = $1 AND delta IS NOT NULL;"
+	queryDeleteEmptyMetric      = "DELETE FROM metrics WHERE id = $1 AND delta IS NULL AND value IS NULL;"
+	queryDeleteHistogram        = "DELETE FROM histograms WHERE id = $1;"
+	queryDeleteSummary          = "DELETE FROM summaries WHERE id = $1;"
+	queryDeleteMetricSamples    = "DELETE FROM metric_samples WHERE mtype = $1 AND id = $2;"
+	queryDeleteMetricRollups    = "DELETE FROM metric_rollups WHERE mtype = $1 AND id = $2;"
+	queryResetCounter           = `
+		WITH reset AS (
+			UPDATE metrics SET delta = 0, updated_at = now()
+			WHERE id = $1 AND delta IS NOT NULL
+			RETURNING delta
+		)
+		INSERT INTO metric_samples (id, mtype, delta, increment)
+		SELECT $1, 'counter', delta, 0 FROM reset;
+	`
+	queryDeleteSamples    = "DELETE FROM metric_samples WHERE ts < $1;"
+	queryDeleteRollups    = "DELETE FROM metric_rollups WHERE resolution = $1 AND ts < $2;"
+	queryExpireMetrics    = "DELETE FROM metrics WHERE updated_at < $1;"
+	queryExpireHistograms = "DELETE FROM histograms WHERE updated_at < $1;"
+	queryExpireSummaries  = "DELETE FROM summaries WHERE updated_at < $1;"
+	querySelectSilences   = "select id, metric_id, metric_type, starts_at, ends_at, author, comment from silences order by id;"
+	queryUpsertSilence    = `
+		INSERT INTO silences (id, metric_id, metric_type, starts_at, ends_at, author, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			metric_id = EXCLUDED.metric_id,
+			metric_type = EXCLUDED.metric_type,
+			starts_at = EXCLUDED.starts_at,
+			ends_at = EXCLUDED.ends_at,
+			author = EXCLUDED.author,
+			comment = EXCLUDED.comment;
+	`
+	queryDeleteSilence = "DELETE FROM silences WHERE id = $1;"
 )
 
 type DBStorage struct {
-	pool *pgxpool.Pool
+	pool      *pgxpool.Pool
+	ttl       time.Duration
+	compactor *historyCompactor
+	sweeper   *metricSweeper
+	// rollupGrace delays compaction of buckets, see DBConfig.rollupGrace.
+	rollupGrace time.Duration
 }
 
 func isDBRetryableError(err error) bool {
@@ -68,22 +207,68 @@ func isDBRetryableError(err error) bool {
 	return errors.As(err, &pgErr)
 }
 
-func NewDBStorage(connString string) *DBStorage {
-	ctx := context.Background()
-	config, err := pgxpool.ParseConfig(connString)
+// DBConfig configures the connection pool of DBStorage.
+type DBConfig struct {
+	DSN             string
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// StatementTimeout aborts statements running longer, zero disables it.
+	StatementTimeout time.Duration
+	// ConnectBackoff holds delays between connection attempts on start.
+	ConnectBackoff []time.Duration
+}
+
+// NewDBConfig returns the default configuration for dsn.
+func NewDBConfig(dsn string) *DBConfig {
+	return &DBConfig{
+		DSN:             dsn,
+		MaxConns:        DefaultDBMaxConns,
+		MinConns:        DefaultDBMinConns,
+		MaxConnLifetime: DefaultDBMaxConnLifetime,
+		MaxConnIdleTime: DefaultDBMaxConnIdleTime,
+		ConnectBackoff:  common.DefaultBackoffSchedule,
+	}
+}
+
+func (dc *DBConfig) poolConfig() (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(dc.DSN)
 	if err != nil {
-		logger.Get().Fatal("Unable to parse config", zap.Error(err))
+		return nil, fmt.Errorf("unable to parse DSN: %w", err)
 	}
 
-	config.MaxConns = MaxConns
-	config.MinConns = MinConns
-	config.MaxConnLifetime = MaxConnLifetime
-	config.MaxConnIdleTime = MaxConnIdleTime
+	config.MaxConns = dc.MaxConns
+	config.MinConns = dc.MinConns
+	config.MaxConnLifetime = dc.MaxConnLifetime
+	config.MaxConnIdleTime = dc.MaxConnIdleTime
+	if dc.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(dc.StatementTimeout.Milliseconds(), 10)
+	}
+	return config, nil
+}
+
+// rollupGrace is how long after a bucket ends it is rolled up. Samples are
+// stamped with the start of their transaction and become visible on
+// commit, a sample committed after its bucket was rolled up would be
+// behind the watermark and never aggregated.
+func (dc *DBConfig) rollupGrace() time.Duration {
+	return max(dc.StatementTimeout, dbRollupMinGrace)
+}
+
+// NewDBStorage connects to the database, retrying retryable errors with
+// the backoff schedule of dc, and applies migrations.
+func NewDBStorage(dc *DBConfig) (*DBStorage, error) {
+	ctx := context.Background()
+	config, err := dc.poolConfig()
+	if err != nil {
+		return nil, err
+	}
 
 	var pool *pgxpool.Pool
 	var poolErr error
 
-	backoffSchedule := common.DefaultBackoffSchedule
+	backoffSchedule := dc.ConnectBackoff
 	backoffTicker := common.GetBackoffTicker(ctx, backoffSchedule)
 	attemptNum := 0
 	for range backoffTicker {
@@ -97,61 +282,54 @@ func NewDBStorage(connString string) *DBStorage {
 					zap.String("attempt", attemptString),
 					zap.Error(poolErr),
 				)
-				pool.Close()
 				continue
 			}
-			logger.Get().Fatal("non-retryable error",
-				zap.String("attempt", attemptString),
-				zap.Error(poolErr),
-			)
+			return nil, fmt.Errorf("failed to create pool: %w", poolErr)
 		}
 
 		poolErr = pool.Ping(ctx)
 		if poolErr != nil {
+			pool.Close()
 			if isDBRetryableError(poolErr) {
 				logger.Get().Warn("retryable ping error, will retry",
 					zap.String("attempt", attemptString),
 					zap.Error(poolErr),
 				)
-				pool.Close()
 				continue
 			}
-			logger.Get().Fatal("non-retryable ping error",
-				zap.String("attempt", attemptString),
-				zap.Error(poolErr),
-			)
+			return nil, fmt.Errorf("failed to ping database: %w", poolErr)
 		}
 
 		break
 	}
 
 	if poolErr != nil {
-		logger.Get().Fatal("Failed to connect to DB after all attempts",
-			zap.Error(poolErr),
-		)
+		return nil, fmt.Errorf("failed to connect to DB after %d attempts: %w", attemptNum, poolErr)
 	}
 
-	dbs := &DBStorage{pool: pool}
+	dbs := &DBStorage{pool: pool, rollupGrace: dc.rollupGrace()}
 
 	err = dbs.runMigrations(ctx)
 	if err != nil {
-		logger.Get().Fatal("Failed to apply migrations",
-			zap.Error(err),
-		)
+		pool.Close()
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
 	}
-	return dbs
+	return dbs, nil
 }
 
-func (dbs *DBStorage) runMigrations(ctx context.Context) error {
-	logger.Get().Info("Running migrations")
-
-	_, err := dbs.pool.Exec(ctx, queryCreateMetricsTable)
-	if err != nil {
-		return fmt.Errorf("failed to create metrics table: %w", err)
+// SelfMetrics reports usage of the connection pool.
+func (dbs *DBStorage) SelfMetrics() []models.MetricModel {
+	stat := dbs.pool.Stat()
+	return []models.MetricModel{
+		*models.NewMetricModel("db_pool_acquired_conns", common.MetricTypeGauge, 0, float64(stat.AcquiredConns())),
+		*models.NewMetricModel("db_pool_idle_conns", common.MetricTypeGauge, 0, float64(stat.IdleConns())),
+		*models.NewMetricModel("db_pool_total_conns", common.MetricTypeGauge, 0, float64(stat.TotalConns())),
+		*models.NewMetricModel("db_pool_max_conns", common.MetricTypeGauge, 0, float64(stat.MaxConns())),
+		*models.NewMetricModel("db_pool_acquire_count", common.MetricTypeCounter, stat.AcquireCount(), 0),
+		*models.NewMetricModel("db_pool_empty_acquire_count", common.MetricTypeCounter, stat.EmptyAcquireCount(), 0),
+		*models.NewMetricModel("db_pool_canceled_acquire_count", common.MetricTypeCounter, stat.CanceledAcquireCount(), 0),
+		*models.NewMetricModel("db_pool_acquire_duration_seconds", common.MetricTypeGauge, 0, stat.AcquireDuration().Seconds()),
 	}
-
-	logger.Get().Info("Migrations completed successfully")
-	return err
 }
 
 func (dbs *DBStorage) GetGauge(ctx context.Context, key string) (float64, error) {
@@ -210,6 +388,212 @@ func (dbs *DBStorage) IncrementCounter(ctx context.Context, key string, value in
 	return newvalue, nil
 }
 
+// scanHistogram reads a histogram stored as bounds, counts and sum columns.
+func scanHistogram(row pgx.Row, dest ...any) (*models.Histogram, error) {
+	var bounds []float64
+	var counts []int64
+	var sum float64
+
+	err := row.Scan(append(dest, &bounds, &counts, &sum)...)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &models.Histogram{
+		Bounds: bounds,
+		Counts: make([]uint64, len(counts)),
+		Sum:    sum,
+	}
+	for i, c := range counts {
+		h.Counts[i] = uint64(c)
+	}
+	return h, nil
+}
+
+func histogramCounts(h *models.Histogram) []int64 {
+	counts := make([]int64, len(h.Counts))
+	for i, c := range h.Counts {
+		counts[i] = int64(c)
+	}
+	return counts
+}
+
+func (dbs *DBStorage) GetHistogram(ctx context.Context, key string) (*models.Histogram, error) {
+	h, err := scanHistogram(dbs.pool.QueryRow(ctx, querySelectHistogram, key))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s %s: %w", common.MetricTypeHistogram, key, ErrNotFound)
+		}
+		return nil, err
+	}
+	return h, nil
+}
+
+func (dbs *DBStorage) UpdateHistogram(ctx context.Context, key string, h *models.Histogram) (*models.Histogram, error) {
+	return mergeDBHistogram(ctx, dbs.pool, key, h)
+}
+
+type dbQueryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func mergeDBHistogram(ctx context.Context, db dbQueryRower, key string, h *models.Histogram) (*models.Histogram, error) {
+	merged, err := scanHistogram(db.QueryRow(ctx, queryMergeHistogram, key, h.Bounds, histogramCounts(h), h.Sum))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s %s: %w", common.MetricTypeHistogram, key, models.ErrHistogramBoundsMismatch)
+		}
+		return nil, err
+	}
+	return merged, nil
+}
+
+func scanSketch(row pgx.Row, dest ...any) (*models.QuantileSketch, error) {
+	var data []byte
+	err := row.Scan(append(dest, &data)...)
+	if err != nil {
+		return nil, err
+	}
+
+	sketch := models.NewQuantileSketch()
+	if err := json.Unmarshal(data, sketch); err != nil {
+		return nil, fmt.Errorf("failed to decode sketch: %w", err)
+	}
+	return sketch, nil
+}
+
+func (dbs *DBStorage) GetSummary(ctx context.Context, key string) (*models.QuantileSketch, error) {
+	sketch, err := scanSketch(dbs.pool.QueryRow(ctx, querySelectSummary, key))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s %s: %w", common.MetricTypeSummary, key, ErrNotFound)
+		}
+		return nil, err
+	}
+	return sketch, nil
+}
+
+func (dbs *DBStorage) UpdateSummary(ctx context.Context, key string, observations []float64) (*models.QuantileSketch, error) {
+	tx, err := dbs.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			logger.Get().Error("rollback error", zap.Error(err))
+		}
+	}()
+
+	sketch, err := mergeDBSummary(ctx, tx, key, observations, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return sketch, nil
+}
+
+// mergeDBSummary adds observations and sketch to the stored sketch of the
+// summary. The row is locked until tx ends, so concurrent updates are not lost.
+func mergeDBSummary(ctx context.Context, tx pgx.Tx, key string, observations []float64, sketch *models.QuantileSketch) (*models.QuantileSketch, error) {
+	empty, err := json.Marshal(models.NewQuantileSketch())
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, queryInsertEmptySummary, key, string(empty))
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := scanSketch(tx.QueryRow(ctx, querySelectSummaryForUpdate, key))
+	if err != nil {
+		return nil, err
+	}
+
+	merged := mergeSummary(prev, observations, sketch)
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, queryUpdateSummary, key, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// DeleteMetric removes a metric together with its history.
+func (dbs *DBStorage) DeleteMetric(ctx context.Context, mType string, key string) error {
+	var query string
+	switch mType {
+	case common.MetricTypeGauge:
+		query = queryDeleteGauge
+	case common.MetricTypeCounter:
+		query = queryDeleteCounter
+	case common.MetricTypeHistogram:
+		query = queryDeleteHistogram
+	case common.MetricTypeSummary:
+		query = queryDeleteSummary
+	default:
+		return fmt.Errorf("unknown metric type %s", mType)
+	}
+
+	tx, err := dbs.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			logger.Get().Error("rollback error", zap.Error(err))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, key)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s %s: %w", mType, key, ErrNotFound)
+	}
+
+	_, err = tx.Exec(ctx, queryDeleteEmptyMetric, key)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, queryDeleteMetricSamples, mType, key)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, queryDeleteMetricRollups, mType, key)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ResetCounter sets an existing counter to zero.
+func (dbs *DBStorage) ResetCounter(ctx context.Context, key string) error {
+	tag, err := dbs.pool.Exec(ctx, queryResetCounter, key)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s %s: %w", common.MetricTypeCounter, key, ErrNotFound)
+	}
+	return nil
+}
+
 func (dbs *DBStorage) GetAll(ctx context.Context) ([]models.MetricModel, error) {
 	rows, err := dbs.pool.Query(ctx, querySelectAllMetrics)
 
@@ -220,22 +604,25 @@ func (dbs *DBStorage) GetAll(ctx context.Context) ([]models.MetricModel, error)
 	metrics := []models.MetricModel{}
 
 	var id string
+	var updatedAt time.Time
 	var delta sql.NullInt64
 	var value sql.NullFloat64
 
 	for rows.Next() {
 
-		err = rows.Scan(&id, &delta, &value)
+		err = rows.Scan(&id, &updatedAt, &delta, &value)
 		if err != nil {
 			return nil, err
 		}
 
 		if delta.Valid {
-			metrics = append(metrics, *models.NewMetricModel(id, common.MetricTypeCounter, delta.Int64, 0))
+			m := newSeriesMetricModel(id, common.MetricTypeCounter, delta.Int64, 0)
+			metrics = append(metrics, *dbs.withUpdatedAt(m, updatedAt))
 		}
 
 		if value.Valid {
-			metrics = append(metrics, *models.NewMetricModel(id, common.MetricTypeGauge, 0, value.Float64))
+			m := newSeriesMetricModel(id, common.MetricTypeGauge, 0, value.Float64)
+			metrics = append(metrics, *dbs.withUpdatedAt(m, updatedAt))
 		}
 
 	}
@@ -245,16 +632,256 @@ func (dbs *DBStorage) GetAll(ctx context.Context) ([]models.MetricModel, error)
 		return nil, err
 	}
 
-	sort.Slice(metrics, func(i, j int) bool {
-		return metrics[i].ID < metrics[j].ID
-	})
+	histogramRows, err := dbs.pool.Query(ctx, querySelectAllHistograms)
+	if err != nil {
+		return nil, err
+	}
+	defer histogramRows.Close()
+
+	for histogramRows.Next() {
+		h, err := scanHistogram(histogramRows, &id, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, *dbs.withUpdatedAt(newSeriesHistogramModel(id, h), updatedAt))
+	}
+
+	err = histogramRows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	summaryRows, err := dbs.pool.Query(ctx, querySelectAllSummaries)
+	if err != nil {
+		return nil, err
+	}
+	defer summaryRows.Close()
+
+	for summaryRows.Next() {
+		sketch, err := scanSketch(summaryRows, &id, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if sketch.Count == 0 {
+			continue
+		}
+		metrics = append(metrics, *dbs.withUpdatedAt(newSeriesSummaryModel(id, sketch), updatedAt))
+	}
+
+	err = summaryRows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	sortMetricModels(metrics)
 
 	return metrics, nil
 
 }
 
+func (dbs *DBStorage) GetHistory(ctx context.Context, mType string, key string, from, to time.Time) ([]models.MetricSample, error) {
+	rows, err := dbs.pool.Query(ctx, querySelectSamples, mType, key, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := []models.MetricSample{}
+
+	var ts time.Time
+	var delta sql.NullInt64
+	var value sql.NullFloat64
+	var increment sql.NullInt64
+
+	for rows.Next() {
+		err = rows.Scan(&ts, &delta, &value, &increment)
+		if err != nil {
+			return nil, err
+		}
+		sample := models.NewMetricSample(ts, mType, delta.Int64, value.Float64)
+		if increment.Valid {
+			sample.Increment = &increment.Int64
+		}
+		samples = append(samples, sample)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+func (dbs *DBStorage) GetRollups(ctx context.Context, mType string, key string, resolution time.Duration, from, to time.Time) ([]models.MetricRollup, error) {
+	rows, err := dbs.pool.Query(ctx, querySelectRollups, mType, key, int64(resolution/time.Second), from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rollups := []models.MetricRollup{}
+
+	for rows.Next() {
+		var r models.MetricRollup
+		var minValue, maxValue, avgValue, lastValue sql.NullFloat64
+		var sum sql.NullInt64
+
+		err = rows.Scan(&r.Timestamp, &r.Count, &minValue, &maxValue, &avgValue, &lastValue, &sum)
+		if err != nil {
+			return nil, err
+		}
+
+		if mType == common.MetricTypeGauge {
+			r.Min, r.Max, r.Avg, r.Last = &minValue.Float64, &maxValue.Float64, &avgValue.Float64, &lastValue.Float64
+		} else {
+			r.Sum = &sum.Int64
+		}
+		rollups = append(rollups, r)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return rollups, nil
+}
+
+// withUpdatedAt sets the last update and expiry time of a listed metric.
+func (dbs *DBStorage) withUpdatedAt(m *models.MetricModel, updatedAt time.Time) *models.MetricModel {
+	m.UpdatedAt = &updatedAt
+	m.ExpiresAt = expiresAt(updatedAt, dbs.ttl)
+	return m
+}
+
+func (dbs *DBStorage) CompactHistory(ctx context.Context, policy *RetentionPolicy, now time.Time) error {
+	tx, err := dbs.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			logger.Get().Error("rollback error", zap.Error(err))
+		}
+	}()
+
+	var sourceSeconds int64
+	for _, tier := range policy.Tiers {
+		seconds := int64(tier.Resolution / time.Second)
+		cutoff := alignToResolution(now.Add(-dbs.rollupGrace), tier.Resolution)
+
+		if sourceSeconds == 0 {
+			_, err = tx.Exec(ctx, queryRollupSamples, seconds, cutoff)
+		} else {
+			_, err = tx.Exec(ctx, queryRollupRollups, seconds, sourceSeconds, cutoff)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to build %s rollups: %w", tier.Resolution, err)
+		}
+		sourceSeconds = seconds
+	}
+
+	for _, tier := range policy.Tiers {
+		_, err = tx.Exec(ctx, queryDeleteRollups, int64(tier.Resolution/time.Second), now.Add(-tier.Retention))
+		if err != nil {
+			return fmt.Errorf("failed to delete expired %s rollups: %w", tier.Resolution, err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, queryDeleteSamples, now.Add(-policy.Raw))
+	if err != nil {
+		return fmt.Errorf("failed to delete expired samples: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ExpireMetrics removes metrics that were last updated before the given time.
+// A gauge and a counter with the same id share their last update time.
+func (dbs *DBStorage) ExpireMetrics(ctx context.Context, before time.Time) (int, error) {
+	tx, err := dbs.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			logger.Get().Error("rollback error", zap.Error(err))
+		}
+	}()
+
+	expired := 0
+	for _, query := range []string{queryExpireMetrics, queryExpireHistograms, queryExpireSummaries} {
+		tag, err := tx.Exec(ctx, query, before)
+		if err != nil {
+			return 0, fmt.Errorf("failed to expire metrics: %w", err)
+		}
+		expired += int(tag.RowsAffected())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return expired, nil
+}
+
+// GetSilences returns all stored silences ordered by ID.
+func (dbs *DBStorage) GetSilences(ctx context.Context) ([]models.Silence, error) {
+	rows, err := dbs.pool.Query(ctx, querySelectSilences)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	silences := []models.Silence{}
+	for rows.Next() {
+		var s models.Silence
+		err = rows.Scan(&s.ID, &s.MetricID, &s.MType, &s.StartsAt, &s.EndsAt, &s.Author, &s.Comment)
+		if err != nil {
+			return nil, err
+		}
+		silences = append(silences, s)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return silences, nil
+}
+
+// SaveSilence creates or replaces the silence with the ID of s.
+func (dbs *DBStorage) SaveSilence(ctx context.Context, s models.Silence) error {
+	_, err := dbs.pool.Exec(ctx, queryUpsertSilence, s.ID, s.MetricID, s.MType, s.StartsAt, s.EndsAt, s.Author, s.Comment)
+	return err
+}
+
+// DeleteSilence removes a silence.
+func (dbs *DBStorage) DeleteSilence(ctx context.Context, id string) error {
+	tag, err := dbs.pool.Exec(ctx, queryDeleteSilence, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("silence %s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
 func (dbs *DBStorage) ShutDown() {
 	logger.Get().Info("Shutting down db storage")
+	if dbs.compactor != nil {
+		dbs.compactor.stop()
+	}
+	if dbs.sweeper != nil {
+		dbs.sweeper.stop()
+	}
 	dbs.pool.Close()
 }
 
@@ -263,58 +890,179 @@ func (dbs *DBStorage) Ping(ctx context.Context) error {
 	return err
 }
 
-func (dbs *DBStorage) BatchUpdate(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error) {
-	newMetrics := make([]models.MetricModel, 0, len(metrics))
+// dbBatch holds the gauges and counters of a batch aggregated by series
+// key, so each can be written with a single multi-row upsert. Histograms and
+// summaries are merged one by one.
+type dbBatch struct {
+	keys        []string
+	gaugeKeys   []string
+	gaugeValues []float64
+	counterKeys []string
+	counterSums []int64
+	others      []int
+}
+
+func newDBBatch(metrics []models.MetricModel) (*dbBatch, error) {
+	b := &dbBatch{keys: make([]string, len(metrics))}
+	gauges := make(map[string]float64)
+	counters := make(map[string]int64)
 
-	metricsCopy := make([]models.MetricModel, len(metrics))
-	copy(metricsCopy, metrics)
-	sort.Slice(metricsCopy, func(i, j int) bool {
-		return metricsCopy[i].ID < metricsCopy[j].ID
+	for i, m := range metrics {
+		key := m.SeriesKey()
+		b.keys[i] = key
+		switch m.MType {
+		case common.MetricTypeGauge:
+			gauges[key] = *m.Value
+		case common.MetricTypeCounter:
+			counters[key] += *m.Delta
+		case common.MetricTypeHistogram, common.MetricTypeSummary:
+			b.others = append(b.others, i)
+		default:
+			return nil, fmt.Errorf("unknown metric type %s", m.MType)
+		}
+	}
+
+	// Rows are written in key order to avoid deadlocks between concurrent
+	// batches.
+	b.gaugeKeys = slices.Sorted(maps.Keys(gauges))
+	b.gaugeValues = make([]float64, len(b.gaugeKeys))
+	for i, key := range b.gaugeKeys {
+		b.gaugeValues[i] = gauges[key]
+	}
+	b.counterKeys = slices.Sorted(maps.Keys(counters))
+	b.counterSums = make([]int64, len(b.counterKeys))
+	for i, key := range b.counterKeys {
+		b.counterSums[i] = counters[key]
+	}
+	sort.SliceStable(b.others, func(i, j int) bool {
+		return b.keys[b.others[i]] < b.keys[b.others[j]]
 	})
 
+	return b, nil
+}
+
+// dbSampleColumns holds history samples as columns for queryInsertSamples.
+type dbSampleColumns struct {
+	ids        []string
+	mtypes     []string
+	values     []*float64
+	deltas     []*int64
+	increments []*int64
+}
+
+// results fills the results of gauges and counters in request order from
+// the stored counter totals and returns their history samples. Every counter
+// gets the value it had right after its own delta was added, as if the batch
+// was applied metric by metric.
+func (b *dbBatch) results(metrics []models.MetricModel, totals map[string]int64, newMetrics []models.MetricModel) *dbSampleColumns {
+	running := make(map[string]int64, len(b.counterKeys))
+	for i, key := range b.counterKeys {
+		running[key] = totals[key] - b.counterSums[i]
+	}
+
+	samples := &dbSampleColumns{}
+	for i, m := range metrics {
+		key := b.keys[i]
+		var newMetric *models.MetricModel
+		switch m.MType {
+		case common.MetricTypeGauge:
+			value := *m.Value
+			newMetric = models.NewMetricModel(m.ID, m.MType, 0, value)
+			samples.add(key, m.MType, &value, nil, nil)
+		case common.MetricTypeCounter:
+			increment := *m.Delta
+			delta := running[key] + increment
+			running[key] = delta
+			newMetric = models.NewMetricModel(m.ID, m.MType, delta, 0)
+			samples.add(key, m.MType, nil, &delta, &increment)
+		default:
+			continue
+		}
+		newMetric.Labels = m.Labels
+		newMetrics[i] = *newMetric
+	}
+	return samples
+}
+
+func (c *dbSampleColumns) add(id string, mtype string, value *float64, delta *int64, increment *int64) {
+	c.ids = append(c.ids, id)
+	c.mtypes = append(c.mtypes, mtype)
+	c.values = append(c.values, value)
+	c.deltas = append(c.deltas, delta)
+	c.increments = append(c.increments, increment)
+}
+
+// BatchUpdate applies the batch in one transaction. Gauges and counters are
+// aggregated by series key and written with one upsert per type, their
+// history samples with one insert.
+func (dbs *DBStorage) BatchUpdate(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error) {
+	b, err := newDBBatch(metrics)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := dbs.pool.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func() {
-		if err = tx.Rollback(ctx); err != nil {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
 			logger.Get().Error("rollback error", zap.Error(err))
 		}
 	}()
 
-	gaugeStmt, err := tx.Prepare(ctx, "insert-gauge", queryInsertGauge)
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare gauge statement: %w", err)
+	if len(b.gaugeKeys) > 0 {
+		_, err = tx.Exec(ctx, queryUpsertGauges, b.gaugeKeys, b.gaugeValues)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert gauges: %w", err)
+		}
 	}
 
-	counterStmt, err := tx.Prepare(ctx, "insert-counter", queryInsertCounter)
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare counter statement: %w", err)
+	totals := make(map[string]int64, len(b.counterKeys))
+	if len(b.counterKeys) > 0 {
+		rows, err := tx.Query(ctx, queryUpsertCounters, b.counterKeys, b.counterSums)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert counters: %w", err)
+		}
+		var key string
+		var total int64
+		_, err = pgx.ForEachRow(rows, []any{&key, &total}, func() error {
+			totals[key] = total
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert counters: %w", err)
+		}
 	}
 
-	var row pgx.Row
-	for _, m := range metricsCopy {
-		switch m.MType {
-		case common.MetricTypeCounter:
-			row = tx.QueryRow(ctx, counterStmt.Name, m.ID, *m.Delta)
+	newMetrics := make([]models.MetricModel, len(metrics))
+	samples := b.results(metrics, totals, newMetrics)
+	if len(samples.ids) > 0 {
+		_, err = tx.Exec(ctx, queryInsertSamples, samples.ids, samples.mtypes, samples.values, samples.deltas, samples.increments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert samples: %w", err)
+		}
+	}
 
-			var newDelta int64
-			err := row.Scan(&newDelta)
+	for _, i := range b.others {
+		m := metrics[i]
+		switch m.MType {
+		case common.MetricTypeHistogram:
+			merged, err := mergeDBHistogram(ctx, tx, b.keys[i], m.Histogram)
 			if err != nil {
 				return nil, err
 			}
-			newMetrics = append(newMetrics, *models.NewMetricModel(m.ID, m.MType, newDelta, 0))
-		case common.MetricTypeGauge:
-			row := tx.QueryRow(ctx, gaugeStmt.Name, m.ID, *m.Value)
-
-			var newValue float64
-			err := row.Scan(&newValue)
+			newMetric := models.NewHistogramMetricModel(m.ID, merged)
+			newMetric.Labels = m.Labels
+			newMetrics[i] = *newMetric
+		case common.MetricTypeSummary:
+			merged, err := mergeDBSummary(ctx, tx, b.keys[i], m.Observations, m.Sketch)
 			if err != nil {
 				return nil, err
 			}
-			newMetrics = append(newMetrics, *models.NewMetricModel(m.ID, m.MType, 0, newValue))
-		default:
-			return nil, fmt.Errorf("unknown metric type %s", m.MType)
+			newMetric := models.NewSummaryMetricModel(m.ID, merged)
+			newMetric.Labels = m.Labels
+			newMetrics[i] = *newMetric
 		}
 	}
 
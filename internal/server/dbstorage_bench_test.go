@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// benchDBBatchSizes are the batch sizes of DBStorage benchmarks, from one
+// agent's flush to what a few hundred agents send in one flush interval.
+var benchDBBatchSizes = []int{100, 1000, 10000}
+
+// newBenchDBStorage connects to TEST_DATABASE_DSN, benchmarks are skipped
+// without it.
+func newBenchDBStorage(b *testing.B) *DBStorage {
+	b.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		b.Skip("TEST_DATABASE_DSN is not set")
+	}
+	dbs, err := NewDBStorage(NewDBConfig(dsn))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(dbs.ShutDown)
+	return dbs
+}
+
+// newBenchDBBatch returns gauges and counters of agents, every agent reports
+// the same metric names with its own label.
+func newBenchDBBatch(size int) []models.MetricModel {
+	metrics := make([]models.MetricModel, 0, size)
+	for i := range size / 2 {
+		agent := fmt.Sprint(i / benchBatchSize)
+		gauge := models.NewMetricModel(fmt.Sprintf("bench_gauge%d", i%benchBatchSize), common.MetricTypeGauge, 0, float64(i))
+		gauge.Labels = map[string]string{"agent": agent}
+		counter := models.NewMetricModel(fmt.Sprintf("bench_counter%d", i%benchBatchSize), common.MetricTypeCounter, 1, 0)
+		counter.Labels = map[string]string{"agent": agent}
+		metrics = append(metrics, *gauge, *counter)
+	}
+	return metrics
+}
+
+// batchUpdateRowByRow is the previous BatchUpdate that upserts every metric
+// with its own statement, kept as the baseline of the benchmark.
+func batchUpdateRowByRow(ctx context.Context, dbs *DBStorage, metrics []models.MetricModel) error {
+	order := make([]int, len(metrics))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return metrics[order[i]].SeriesKey() < metrics[order[j]].SeriesKey()
+	})
+
+	tx, err := dbs.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, i := range order {
+		m := metrics[i]
+		var row pgx.Row
+		if m.MType == common.MetricTypeCounter {
+			row = tx.QueryRow(ctx, queryInsertCounter, m.SeriesKey(), *m.Delta)
+		} else {
+			row = tx.QueryRow(ctx, queryInsertGauge, m.SeriesKey(), *m.Value)
+		}
+		var ignored any
+		if err := row.Scan(&ignored); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// BenchmarkDBStorage_BatchUpdate compares the bulk upsert with the previous
+// statement per metric at several batch sizes:
+//
+//	TEST_DATABASE_DSN=postgres://... go test \
+//	    -bench=BenchmarkDBStorage_BatchUpdate ./internal/server \
+//	    -benchmem \
+//	    -run=^$
+func BenchmarkDBStorage_BatchUpdate(b *testing.B) {
+	dbs := newBenchDBStorage(b)
+	ctx := context.Background()
+
+	for _, size := range benchDBBatchSizes {
+		batch := newBenchDBBatch(size)
+
+		b.Run(fmt.Sprintf("bulk/size=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := dbs.BatchUpdate(ctx, batch); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("row_by_row/size=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := batchUpdateRowByRow(ctx, dbs, batch); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"errors"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsDBRetryableError(t *testing.T) {
@@ -45,3 +50,53 @@ func TestIsDBRetryableError(t *testing.T) {
 		})
 	}
 }
+
+func TestDBConfig_RollupGrace(t *testing.T) {
+	dc := NewDBConfig("")
+	assert.Equal(t, dbRollupMinGrace, dc.rollupGrace(), "no statement timeout")
+	dc.StatementTimeout = 5 * time.Second
+	assert.Equal(t, dbRollupMinGrace, dc.rollupGrace())
+	dc.StatementTimeout = 10 * time.Minute
+	assert.Equal(t, 10*time.Minute, dc.rollupGrace())
+}
+
+func TestDBBatch_Results(t *testing.T) {
+	metrics := []models.MetricModel{
+		*models.NewMetricModel("c", common.MetricTypeCounter, 2, 0),
+		*models.NewMetricModel("g", common.MetricTypeGauge, 0, 1.5),
+		*models.NewMetricModel("c", common.MetricTypeCounter, 3, 0),
+		*models.NewHistogramMetricModel("h", &models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 0}}),
+		*models.NewMetricModel("g", common.MetricTypeGauge, 0, 2.5),
+		*models.NewMetricModel("a", common.MetricTypeCounter, 1, 0),
+	}
+
+	b, err := newDBBatch(metrics)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"g"}, b.gaugeKeys)
+	assert.Equal(t, []float64{2.5}, b.gaugeValues)
+	assert.Equal(t, []string{"a", "c"}, b.counterKeys)
+	assert.Equal(t, []int64{1, 5}, b.counterSums)
+	assert.Equal(t, []int{3}, b.others)
+
+	// The counter "c" was 10 before the batch.
+	newMetrics := make([]models.MetricModel, len(metrics))
+	samples := b.results(metrics, map[string]int64{"a": 1, "c": 15}, newMetrics)
+
+	assert.Equal(t, int64(12), *newMetrics[0].Delta)
+	assert.Equal(t, 1.5, *newMetrics[1].Value)
+	assert.Equal(t, int64(15), *newMetrics[2].Delta)
+	assert.Empty(t, newMetrics[3].MType)
+	assert.Equal(t, 2.5, *newMetrics[4].Value)
+	assert.Equal(t, int64(1), *newMetrics[5].Delta)
+
+	assert.Equal(t, []string{"c", "g", "c", "g", "a"}, samples.ids)
+	assert.Equal(t, int64(12), *samples.deltas[0])
+	assert.Equal(t, int64(3), *samples.increments[2])
+	assert.Nil(t, samples.values[0])
+	assert.Nil(t, samples.deltas[1])
+}
+
+func TestDBBatch_UnknownType(t *testing.T) {
+	_, err := newDBBatch([]models.MetricModel{{ID: "x", MType: "invalid"}})
+	assert.Error(t, err)
+}
@@ -44,6 +44,7 @@ func (bmw *BaseMiddleware) DecryptMiddleware(privateKey *rsa.PrivateKey) func(ht
 			keySize := 256
 			if len(encryptedData) < keySize {
 				bmw.logger.Error("Encrypted data too short")
+				selfMetrics.inc(selfDecryptFailures, nil)
 				http.Error(w, "Invalid encrypted data", http.StatusBadRequest)
 				return
 			}
@@ -60,6 +61,7 @@ func (bmw *BaseMiddleware) DecryptMiddleware(privateKey *rsa.PrivateKey) func(ht
 			)
 			if err != nil {
 				bmw.logger.Error("Failed to decrypt AES key", zap.Error(err))
+				selfMetrics.inc(selfDecryptFailures, nil)
 				http.Error(w, "Failed to decrypt AES key", http.StatusBadRequest)
 				return
 			}
@@ -67,6 +69,7 @@ func (bmw *BaseMiddleware) DecryptMiddleware(privateKey *rsa.PrivateKey) func(ht
 			decryptedData, err := common.DecryptAES(aesKey, encryptedPayload)
 			if err != nil {
 				bmw.logger.Error("Failed to decrypt data with AES", zap.Error(err))
+				selfMetrics.inc(selfDecryptFailures, nil)
 				http.Error(w, "Failed to decrypt data", http.StatusBadRequest)
 				return
 			}
@@ -76,6 +76,7 @@ func TestDecryptMiddleware_EmptyBody(t *testing.T) {
 }
 
 func TestDecryptMiddleware_ShortData(t *testing.T) {
+	sm := newTestSelfMetrics(t)
 	privKey, _ := rsa.GenerateKey(rand.Reader, 2048)
 	bmw := BaseMiddleware{logger: zap.NewNop()}
 	middleware := bmw.DecryptMiddleware(privKey)
@@ -92,6 +93,9 @@ func TestDecryptMiddleware_ShortData(t *testing.T) {
 	if rr.Code != http.StatusBadRequest {
 		t.Error("Expected 400 for short encrypted data")
 	}
+	if sm.counter(selfDecryptFailures, nil) != 1 {
+		t.Error("Expected decryption failure to be counted")
+	}
 }
 
 func TestDecryptMiddleware_Success(t *testing.T) {
@@ -5,3 +5,18 @@ import (
 )
 
 var ErrNotFound = errors.New("not found")
+
+var (
+	errHistoryNotSupported  = errors.New("history is not supported by storage")
+	errRollupsNotSupported  = errors.New("rollups are not supported by storage")
+	errSilencesNotSupported = errors.New("silences are not supported by storage")
+)
+
+var errInvalidSilence = errors.New("invalid silence")
+
+var errInvalidAck = errors.New("invalid acknowledgement")
+
+var (
+	errNotServing   = errors.New("server is not serving yet")
+	errShuttingDown = errors.New("server is shutting down")
+)
@@ -20,7 +20,7 @@ func ExampleBaseHandler_MetricBatchUpdateJSONHandler_basic() {
 
 	storage := NewMemStorage()
 	cfg := &config{}
-	router := NewRouter(storage, cfg)
+	router := NewRouter(storage, cfg, NewHealth(storage), NewAlertEngine(storage, nil))
 	server := httptest.NewServer(router)
 	defer server.Close()
 
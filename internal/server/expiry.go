@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/logger"
+	"go.uber.org/zap"
+)
+
+// maxExpirySweepPeriod caps the period of the expiry sweeper, so metrics do
+// not outlive their TTL by much when the TTL is long.
+const maxExpirySweepPeriod = time.Minute
+
+// expirySweepPeriod returns how often metrics with the given TTL are swept.
+func expirySweepPeriod(ttl time.Duration) time.Duration {
+	period := ttl / 2
+	if period > maxExpirySweepPeriod {
+		period = maxExpirySweepPeriod
+	}
+	if period < time.Second {
+		period = time.Second
+	}
+	return period
+}
+
+// expiresAt returns the time a metric updated at updatedAt expires,
+// nil if metrics do not expire.
+func expiresAt(updatedAt time.Time, ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	t := updatedAt.Add(ttl)
+	return &t
+}
+
+// metricSweeper periodically evicts metrics that were not updated for ttl.
+type metricSweeper struct {
+	target   MetricExpirer
+	ttl      time.Duration
+	stopChan chan struct{}
+	doneChan chan struct{}
+	stopOnce sync.Once
+}
+
+func startMetricSweeper(target MetricExpirer, ttl time.Duration) *metricSweeper {
+	ms := &metricSweeper{
+		target:   target,
+		ttl:      ttl,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+
+	ticker := time.NewTicker(expirySweepPeriod(ttl))
+
+	go func() {
+		defer close(ms.doneChan)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ms.sweep()
+			case <-ms.stopChan:
+				return
+			}
+		}
+	}()
+
+	return ms
+}
+
+func (ms *metricSweeper) sweep() {
+	expired, err := ms.target.ExpireMetrics(context.Background(), time.Now().Add(-ms.ttl))
+	if err != nil {
+		logger.Get().Error("Metric expiry error", zap.Error(err))
+		return
+	}
+	if expired > 0 {
+		logger.Get().Info("Expired stale metrics", zap.Int("count", expired))
+	}
+}
+
+func (ms *metricSweeper) stop() {
+	ms.stopOnce.Do(func() {
+		close(ms.stopChan)
+	})
+	<-ms.doneChan
+}
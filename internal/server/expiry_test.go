@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpirySweepPeriod(t *testing.T) {
+	assert.Equal(t, time.Second, expirySweepPeriod(time.Second))
+	assert.Equal(t, 5*time.Second, expirySweepPeriod(10*time.Second))
+	assert.Equal(t, maxExpirySweepPeriod, expirySweepPeriod(24*time.Hour))
+}
+
+func TestExpiresAt(t *testing.T) {
+	now := time.Now()
+
+	assert.Nil(t, expiresAt(now, 0))
+
+	expires := expiresAt(now, time.Minute)
+	require.NotNil(t, expires)
+	assert.Equal(t, now.Add(time.Minute), *expires)
+}
+
+type countingExpirer struct {
+	calls atomic.Int32
+}
+
+func (ce *countingExpirer) ExpireMetrics(ctx context.Context, before time.Time) (int, error) {
+	ce.calls.Add(1)
+	return 0, nil
+}
+
+func TestMetricSweeper(t *testing.T) {
+	target := &countingExpirer{}
+	sweeper := startMetricSweeper(target, time.Second)
+
+	require.Eventually(t, func() bool {
+		return target.calls.Load() > 0
+	}, 3*time.Second, 50*time.Millisecond)
+
+	sweeper.stop()
+	sweeper.stop()
+}
@@ -0,0 +1,421 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/etoneja/go-metrics/internal/models"
+	"go.uber.org/zap"
+)
+
+// graphiteBatchSize is the most metrics a connection writes at once, lines
+// are also written whenever the connection has no more buffered input.
+const graphiteBatchSize = 1000
+
+// graphiteMaxLineSize limits lines read from carbon connections, longer
+// lines close the connection.
+const graphiteMaxLineSize = 64 << 10
+
+// GraphiteMapping turns dotted Graphite paths into metric IDs and labels.
+//
+// Match is a dotted pattern where * matches exactly one path component.
+// Name and label values may refer to the components matched by the
+// wildcards as $1, $2 or ${1}:
+//
+//	{"match": "servers.*.cpu.*", "name": "cpu_$2", "labels": {"host": "$1"}}
+//
+// maps servers.web1.cpu.idle to cpu_idle{host="web1"}.
+type GraphiteMapping struct {
+	Match  string
+	Name   string
+	Labels map[string]string
+
+	parts []string
+}
+
+// graphiteMappingFile is the format of the mapping file.
+type graphiteMappingFile struct {
+	Mappings []struct {
+		Match  string            `json:"match"`
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	} `json:"mappings"`
+}
+
+var graphiteCaptureRe = regexp.MustCompile(`\$(?:\{(\d+)\}|(\d+))`)
+
+// LoadGraphiteMappings reads mapping rules from a JSON file like
+//
+//	{"mappings": [{"match": "servers.*.cpu.*", "name": "cpu_$2", "labels": {"host": "$1"}}]}
+//
+// An empty path means no mappings.
+func LoadGraphiteMappings(path string) ([]*GraphiteMapping, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read graphite mapping file %s: %w", path, err)
+	}
+	var file graphiteMappingFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse graphite mapping file %s: %w", path, err)
+	}
+
+	mappings := make([]*GraphiteMapping, 0, len(file.Mappings))
+	for _, m := range file.Mappings {
+		mapping, err := NewGraphiteMapping(m.Match, m.Name, m.Labels)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// NewGraphiteMapping checks a mapping rule, see GraphiteMapping.
+func NewGraphiteMapping(match, name string, labels map[string]string) (*GraphiteMapping, error) {
+	if match == "" || name == "" {
+		return nil, fmt.Errorf("graphite mapping %q: match and name are required", match)
+	}
+	if strings.Contains(name, " ") {
+		return nil, fmt.Errorf("graphite mapping %q: invalid name %q", match, name)
+	}
+	if err := models.ValidateID(graphiteCaptureRe.ReplaceAllString(name, "")); err != nil {
+		return nil, fmt.Errorf("graphite mapping %q: %w", match, err)
+	}
+	if err := models.ValidateLabels(labels); err != nil {
+		return nil, fmt.Errorf("graphite mapping %q: %w", match, err)
+	}
+
+	parts := strings.Split(match, ".")
+	wildcards := 0
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("graphite mapping %q: empty path component", match)
+		}
+		if part == "*" {
+			wildcards++
+		}
+	}
+	templates := []string{name}
+	for _, value := range labels {
+		templates = append(templates, value)
+	}
+	for _, template := range templates {
+		for _, capture := range graphiteCaptureRe.FindAllStringSubmatch(template, -1) {
+			n, _ := strconv.Atoi(capture[1] + capture[2])
+			if n < 1 || n > wildcards {
+				return nil, fmt.Errorf("graphite mapping %q: %s refers to no wildcard", match, capture[0])
+			}
+		}
+	}
+
+	return &GraphiteMapping{Match: match, Name: name, Labels: labels, parts: parts}, nil
+}
+
+// apply maps path if it matches.
+func (m *GraphiteMapping) apply(path []string) (string, map[string]string, bool) {
+	if len(path) != len(m.parts) {
+		return "", nil, false
+	}
+	var captures []string
+	for i, part := range m.parts {
+		switch part {
+		case "*":
+			captures = append(captures, path[i])
+		case path[i]:
+		default:
+			return "", nil, false
+		}
+	}
+
+	expand := func(template string) string {
+		return graphiteCaptureRe.ReplaceAllStringFunc(template, func(ref string) string {
+			n, _ := strconv.Atoi(strings.Trim(ref, "${}"))
+			return captures[n-1]
+		})
+	}
+	var labels map[string]string
+	if len(m.Labels) > 0 {
+		labels = make(map[string]string, len(m.Labels))
+		for name, value := range m.Labels {
+			labels[name] = expand(value)
+		}
+	}
+	return expand(m.Name), labels, true
+}
+
+// mapGraphitePath returns the metric ID and labels of a path by the first
+// matching mapping, unmatched paths are used as IDs as they are.
+func mapGraphitePath(mappings []*GraphiteMapping, path string) (string, map[string]string) {
+	parts := strings.Split(path, ".")
+	for _, m := range mappings {
+		if id, labels, ok := m.apply(parts); ok {
+			return id, labels
+		}
+	}
+	return path, nil
+}
+
+// parseGraphiteLine parses "path[;tag=value...] value [timestamp]". Tags
+// of the Graphite tagged series format become labels and are added to the
+// labels of mappings.
+func parseGraphiteLine(line string) (string, map[string]string, float64, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return "", nil, 0, errors.New("expected path, value and timestamp")
+	}
+
+	path, rawTags, _ := strings.Cut(fields[0], ";")
+	if path == "" {
+		return "", nil, 0, fmt.Errorf("invalid path %q", path)
+	}
+	if err := models.ValidateID(path); err != nil {
+		return "", nil, 0, err
+	}
+	var tags map[string]string
+	if rawTags != "" {
+		tags = make(map[string]string)
+		for _, tag := range strings.Split(rawTags, ";") {
+			name, value, ok := strings.Cut(tag, "=")
+			if !ok || value == "" {
+				return "", nil, 0, fmt.Errorf("invalid tag %q", tag)
+			}
+			tags[name] = value
+		}
+		if err := models.ValidateLabels(tags); err != nil {
+			return "", nil, 0, err
+		}
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", nil, 0, fmt.Errorf("invalid value %q", fields[1])
+	}
+	if len(fields) == 3 {
+		if _, err := strconv.ParseFloat(fields[2], 64); err != nil {
+			return "", nil, 0, fmt.Errorf("invalid timestamp %q", fields[2])
+		}
+	}
+
+	return path, tags, value, nil
+}
+
+// GraphiteServer receives the Graphite carbon plaintext protocol over TCP
+// and writes every line as a gauge. Paths are turned into metric IDs by
+// mappings. Values are stored as current, timestamps are only checked.
+type GraphiteServer struct {
+	store    Storager
+	mappings []*GraphiteMapping
+	subnet   *net.IPNet
+
+	listener net.Listener
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	stopping bool
+	wg       sync.WaitGroup
+}
+
+// StartGraphiteServer listens for carbon plaintext on cfg.CarbonAddress.
+func StartGraphiteServer(store Storager, cfg *config, serverErrChan chan<- error) (*GraphiteServer, error) {
+	subnet, err := parseTrustedSubnet(cfg.TrustedSubnet)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", cfg.CarbonAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen carbon on %s: %w", cfg.CarbonAddress, err)
+	}
+
+	gs := &GraphiteServer{
+		store:    store,
+		mappings: cfg.GetCarbonMappings(),
+		subnet:   subnet,
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	logger.Get().Info("Carbon server starting", zap.String("addr", cfg.CarbonAddress))
+	go gs.serve(serverErrChan)
+
+	return gs, nil
+}
+
+// StopGraphiteServer stops accepting connections and lets open ones write
+// what they received. Connections still open when shutdownCtx is done are
+// closed.
+func StopGraphiteServer(gs *GraphiteServer, shutdownCtx context.Context) {
+	if gs == nil {
+		return
+	}
+	logger.Get().Info("Stopping carbon server...")
+
+	gs.mu.Lock()
+	gs.stopping = true
+	_ = gs.listener.Close()
+	for conn := range gs.conns {
+		// Unblock reads, the connection writes its batch and exits.
+		_ = conn.SetReadDeadline(time.Now())
+	}
+	gs.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		gs.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		logger.Get().Info("Carbon server stopped gracefully")
+	case <-shutdownCtx.Done():
+		logger.Get().Warn("Carbon server forced to stop")
+		gs.mu.Lock()
+		for conn := range gs.conns {
+			_ = conn.Close()
+		}
+		gs.mu.Unlock()
+	}
+}
+
+func (gs *GraphiteServer) serve(serverErrChan chan<- error) {
+	for {
+		conn, err := gs.listener.Accept()
+		if err != nil {
+			gs.mu.Lock()
+			stopping := gs.stopping
+			gs.mu.Unlock()
+			if !stopping {
+				logger.Get().Error("Carbon server failed", zap.Error(err))
+				serverErrChan <- err
+			}
+			return
+		}
+		if !trustedSource(gs.subnet, conn.RemoteAddr(), logger.Get()) {
+			_ = conn.Close()
+			continue
+		}
+
+		gs.mu.Lock()
+		if gs.stopping {
+			gs.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		gs.conns[conn] = struct{}{}
+		gs.wg.Add(1)
+		gs.mu.Unlock()
+
+		go gs.serveConn(conn)
+	}
+}
+
+func (gs *GraphiteServer) serveConn(conn net.Conn) {
+	defer gs.wg.Done()
+	defer func() {
+		gs.mu.Lock()
+		delete(gs.conns, conn)
+		gs.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	var metrics []models.MetricModel
+	write := func() {
+		if len(metrics) == 0 {
+			return
+		}
+		if _, err := gs.store.BatchUpdate(context.Background(), metrics); err != nil {
+			logger.Get().Error("Failed to write carbon metrics",
+				zap.String("addr", conn.RemoteAddr().String()),
+				zap.Int("metrics", len(metrics)),
+				zap.Error(err))
+		}
+		metrics = nil
+	}
+	defer write()
+
+	reader := bufio.NewReader(conn)
+	lr := &graphiteLineReader{reader: reader}
+	scanner := bufio.NewScanner(lr)
+	scanner.Buffer(make([]byte, 4096), graphiteMaxLineSize)
+	scanner.Split(lr.scanLines)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			if m, parseErr := gs.metric(line); parseErr != nil {
+				logger.Get().Debug("Bad carbon line", zap.String("line", line), zap.Error(parseErr))
+			} else {
+				metrics = append(metrics, *m)
+			}
+		}
+		if len(metrics) >= graphiteBatchSize || reader.Buffered() == 0 {
+			write()
+		}
+	}
+	var netErr net.Error
+	if err := scanner.Err(); err != nil && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		logger.Get().Debug("Carbon connection closed",
+			zap.String("addr", conn.RemoteAddr().String()),
+			zap.Error(err))
+	}
+}
+
+// graphiteLineReader keeps the last read error of a connection, so a line
+// cut by an error other than EOF can be told from a final line.
+type graphiteLineReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (lr *graphiteLineReader) Read(p []byte) (int, error) {
+	n, err := lr.reader.Read(p)
+	lr.err = err
+	return n, err
+}
+
+// scanLines splits lines like bufio.ScanLines. A line cut by the deadline
+// of a stop is incomplete and dropped.
+func (lr *graphiteLineReader) scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && !errors.Is(lr.err, io.EOF) {
+		atEOF = false
+	}
+	return bufio.ScanLines(data, atEOF)
+}
+
+// metric maps a line to a gauge.
+func (gs *GraphiteServer) metric(line string) (*models.MetricModel, error) {
+	path, tags, value, err := parseGraphiteLine(line)
+	if err != nil {
+		return nil, err
+	}
+	id, labels := mapGraphitePath(gs.mappings, path)
+	if len(tags) > 0 {
+		if labels == nil {
+			labels = make(map[string]string, len(tags))
+		}
+		for name, tagValue := range tags {
+			labels[name] = tagValue
+		}
+	}
+
+	m := models.NewMetricModel(id, common.MetricTypeGauge, 0, value)
+	if len(labels) > 0 {
+		m.Labels = labels
+	}
+	return m, nil
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGraphiteMappings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"mappings": [
+		{"match": "servers.*.cpu.*", "name": "cpu_${2}", "labels": {"host": "$1"}},
+		{"match": "servers.*.*", "name": "$2", "labels": {"host": "$1"}}
+	]}`), 0o644))
+
+	mappings, err := LoadGraphiteMappings(path)
+	require.NoError(t, err)
+	require.Len(t, mappings, 2)
+
+	tests := []struct {
+		path       string
+		wantID     string
+		wantLabels map[string]string
+	}{
+		{"servers.web1.cpu.idle", "cpu_idle", map[string]string{"host": "web1"}},
+		{"servers.web1.load", "load", map[string]string{"host": "web1"}},
+		{"stats.requests", "stats.requests", nil},
+	}
+	for _, tt := range tests {
+		id, labels := mapGraphitePath(mappings, tt.path)
+		assert.Equal(t, tt.wantID, id, tt.path)
+		assert.Equal(t, tt.wantLabels, labels, tt.path)
+	}
+
+	mappings, err = LoadGraphiteMappings("")
+	require.NoError(t, err)
+	assert.Empty(t, mappings)
+	_, err = LoadGraphiteMappings(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestNewGraphiteMapping_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		match  string
+		id     string
+		labels map[string]string
+	}{
+		{"no match", "", "cpu", nil},
+		{"no name", "servers.*", "", nil},
+		{"bad name", "servers.*", "cpu{x}", nil},
+		{"empty component", "servers..cpu", "cpu", nil},
+		{"unknown capture", "servers.*", "cpu_$2", nil},
+		{"unknown label capture", "servers.*", "cpu", map[string]string{"host": "${0}"}},
+		{"bad label", "servers.*", "cpu", map[string]string{"1host": "$1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGraphiteMapping(tt.match, tt.id, tt.labels)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseGraphiteLine(t *testing.T) {
+	path, tags, value, err := parseGraphiteLine("servers.web1.load 1.5 1714557600")
+	require.NoError(t, err)
+	assert.Equal(t, "servers.web1.load", path)
+	assert.Nil(t, tags)
+	assert.Equal(t, 1.5, value)
+
+	path, tags, value, err = parseGraphiteLine("disk.used;host=web1;mount=data 42")
+	require.NoError(t, err)
+	assert.Equal(t, "disk.used", path)
+	assert.Equal(t, map[string]string{"host": "web1", "mount": "data"}, tags)
+	assert.Equal(t, 42.0, value)
+
+	for _, line := range []string{
+		"servers.web1.load",
+		"servers.web1.load abc 1714557600",
+		"servers.web1.load nan 1714557600",
+		"servers.web1.load 1 yesterday",
+		"servers.web1.load 1 1714557600 extra",
+		"disk.used;host 1",
+		"cpu{x} 1",
+	} {
+		_, _, _, err := parseGraphiteLine(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestGraphiteServer(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	mapping, err := NewGraphiteMapping("servers.*.cpu.*", "cpu_$2", map[string]string{"host": "$1"})
+	require.NoError(t, err)
+	gs, err := StartGraphiteServer(store, &config{
+		CarbonAddress:  "127.0.0.1:0",
+		TrustedSubnet:  "127.0.0.0/8",
+		carbonMappings: []*GraphiteMapping{mapping},
+	}, make(chan error, 1))
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", gs.listener.Addr().String())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write([]byte("servers.web1.cpu.idle 93.5 1714557600\nbad line\nrequests;env=prod 3 1714557600\n"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, err := store.GetGauge(ctx, `requests{env="prod"}`)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	idle, err := store.GetGauge(ctx, `cpu_idle{host="web1"}`)
+	require.NoError(t, err)
+	assert.Equal(t, 93.5, idle)
+
+	// A line sent right before the stop is still written.
+	_, err = conn.Write([]byte("last 1 1714557600\n"))
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	StopGraphiteServer(gs, shutdownCtx)
+	_, err = store.GetGauge(ctx, "last")
+	assert.NoError(t, err)
+
+	_, err = net.Dial("tcp", gs.listener.Addr().String())
+	assert.Error(t, err, "the listener is closed")
+}
+
+func TestGraphiteServer_LongLine(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	gs, err := StartGraphiteServer(store, &config{
+		CarbonAddress: "127.0.0.1:0",
+		TrustedSubnet: "127.0.0.0/8",
+	}, make(chan error, 1))
+	require.NoError(t, err)
+	defer StopGraphiteServer(gs, ctx)
+
+	conn, err := net.Dial("tcp", gs.listener.Addr().String())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write([]byte("before 1 1714557600\n" + strings.Repeat("x", graphiteMaxLineSize+1)))
+	require.NoError(t, err)
+
+	// The server drops the connection instead of buffering the line.
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	require.Error(t, err)
+	var netErr net.Error
+	assert.False(t, errors.As(err, &netErr) && netErr.Timeout(), "closed, not timed out: %v", err)
+	_, err = store.GetGauge(ctx, "before")
+	assert.NoError(t, err, "lines before the long one are written")
+}
+
+func TestGraphiteServer_UntrustedSource(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	gs, err := StartGraphiteServer(store, &config{
+		CarbonAddress: "127.0.0.1:0",
+		TrustedSubnet: "10.0.0.0/8",
+	}, make(chan error, 1))
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", gs.listener.Addr().String())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	_, _ = conn.Write([]byte("requests 3 1714557600\n"))
+
+	// The server closes untrusted connections without reading.
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+
+	StopGraphiteServer(gs, ctx)
+	_, err = store.GetGauge(ctx, "requests")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"errors"
 
+	"github.com/etoneja/go-metrics/internal/common"
 	"github.com/etoneja/go-metrics/internal/models"
 	"github.com/etoneja/go-metrics/internal/proto"
 	"go.uber.org/zap"
@@ -13,12 +15,14 @@ import (
 type GRPCServer struct {
 	proto.UnimplementedMetricsServiceServer
 	store  Storager
+	alerts *AlertEngine
 	logger *zap.Logger
 }
 
-func NewGRPCServer(store Storager, logger *zap.Logger) *GRPCServer {
+func NewGRPCServer(store Storager, alerts *AlertEngine, logger *zap.Logger) *GRPCServer {
 	return &GRPCServer{
 		store:  store,
+		alerts: alerts,
 		logger: logger,
 	}
 }
@@ -40,6 +44,9 @@ func (s *GRPCServer) BatchUpdate(ctx context.Context, req *proto.BatchUpdateRequ
 	}
 
 	updatedMetrics, err := s.store.BatchUpdate(ctx, metricModels)
+	if errors.Is(err, models.ErrHistogramBoundsMismatch) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		s.logger.Error("gRPC BatchUpdate failed", zap.Error(err))
 		return nil, status.Error(codes.Internal, "failed to update metrics")
@@ -73,3 +80,125 @@ func (s *GRPCServer) ListMetrics(ctx context.Context, req *proto.ListMetricsRequ
 		Metrics: grpcMetrics,
 	}, nil
 }
+
+func (s *GRPCServer) DeleteMetric(ctx context.Context, req *proto.DeleteMetricRequest) (*proto.DeleteMetricResponse, error) {
+	switch req.Type {
+	case common.MetricTypeGauge, common.MetricTypeCounter, common.MetricTypeHistogram, common.MetricTypeSummary:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown metric type: %s", req.Type)
+	}
+	if err := models.ValidateID(req.Id); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := models.ValidateLabels(req.Labels); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err := s.store.DeleteMetric(ctx, req.Type, models.SeriesKey(req.Id, req.Labels))
+	if errors.Is(err, ErrNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("gRPC DeleteMetric failed", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to delete metric")
+	}
+
+	return &proto.DeleteMetricResponse{}, nil
+}
+
+func (s *GRPCServer) ResetCounter(ctx context.Context, req *proto.ResetCounterRequest) (*proto.ResetCounterResponse, error) {
+	if err := models.ValidateID(req.Id); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := models.ValidateLabels(req.Labels); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err := s.store.ResetCounter(ctx, models.SeriesKey(req.Id, req.Labels))
+	if errors.Is(err, ErrNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("gRPC ResetCounter failed", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to reset counter")
+	}
+
+	return &proto.ResetCounterResponse{}, nil
+}
+
+func (s *GRPCServer) ListAlerts(ctx context.Context, req *proto.ListAlertsRequest) (*proto.ListAlertsResponse, error) {
+	return &proto.ListAlertsResponse{
+		Alerts: models.AlertsToGRPC(s.alerts.Alerts()),
+	}, nil
+}
+
+func (s *GRPCServer) AcknowledgeAlert(ctx context.Context, req *proto.AcknowledgeAlertRequest) (*proto.AcknowledgeAlertResponse, error) {
+	alert, err := s.alerts.Acknowledge(req.Rule, req.Author, req.Comment)
+	if errors.Is(err, errInvalidAck) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, ErrNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("gRPC AcknowledgeAlert failed", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to acknowledge alert")
+	}
+
+	return &proto.AcknowledgeAlertResponse{
+		Alert: models.AlertsToGRPC([]models.Alert{alert})[0],
+	}, nil
+}
+
+func (s *GRPCServer) CreateSilence(ctx context.Context, req *proto.CreateSilenceRequest) (*proto.CreateSilenceResponse, error) {
+	if req.Silence == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing silence")
+	}
+
+	silence, err := s.alerts.AddSilence(ctx, models.SilenceFromGRPC(req.Silence))
+	if errors.Is(err, errInvalidSilence) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, errSilencesNotSupported) {
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("gRPC CreateSilence failed", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to save silence")
+	}
+
+	return &proto.CreateSilenceResponse{
+		Silence: models.SilenceToGRPC(silence),
+	}, nil
+}
+
+func (s *GRPCServer) ListSilences(ctx context.Context, req *proto.ListSilencesRequest) (*proto.ListSilencesResponse, error) {
+	silences, err := s.alerts.Silences(ctx)
+	if errors.Is(err, errSilencesNotSupported) {
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("failed to get silences", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to get silences")
+	}
+
+	return &proto.ListSilencesResponse{
+		Silences: models.SilencesToGRPC(silences),
+	}, nil
+}
+
+func (s *GRPCServer) DeleteSilence(ctx context.Context, req *proto.DeleteSilenceRequest) (*proto.DeleteSilenceResponse, error) {
+	err := s.alerts.DeleteSilence(ctx, req.Id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, errSilencesNotSupported) {
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("gRPC DeleteSilence failed", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to delete silence")
+	}
+
+	return &proto.DeleteSilenceResponse{}, nil
+}
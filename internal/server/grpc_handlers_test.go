@@ -14,9 +14,11 @@ import (
 )
 
 type mockStore struct {
-	pingFunc        func(ctx context.Context) error
-	batchUpdateFunc func(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error)
-	getAllFunc      func(ctx context.Context) ([]models.MetricModel, error)
+	pingFunc         func(ctx context.Context) error
+	batchUpdateFunc  func(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error)
+	getAllFunc       func(ctx context.Context) ([]models.MetricModel, error)
+	deleteMetricFunc func(ctx context.Context, mType string, key string) error
+	resetCounterFunc func(ctx context.Context, key string) error
 }
 
 func (m *mockStore) GetGauge(ctx context.Context, key string) (float64, error) { return 0, nil }
@@ -27,8 +29,34 @@ func (m *mockStore) GetCounter(ctx context.Context, key string) (int64, error) {
 func (m *mockStore) IncrementCounter(ctx context.Context, key string, value int64) (int64, error) {
 	return 0, nil
 }
+func (m *mockStore) GetHistogram(ctx context.Context, key string) (*models.Histogram, error) {
+	return nil, nil
+}
+func (m *mockStore) UpdateHistogram(ctx context.Context, key string, h *models.Histogram) (*models.Histogram, error) {
+	return h, nil
+}
+func (m *mockStore) GetSummary(ctx context.Context, key string) (*models.QuantileSketch, error) {
+	return nil, nil
+}
+func (m *mockStore) UpdateSummary(ctx context.Context, key string, observations []float64) (*models.QuantileSketch, error) {
+	return models.NewQuantileSketch(), nil
+}
 func (m *mockStore) ShutDown() {}
 
+func (m *mockStore) DeleteMetric(ctx context.Context, mType string, key string) error {
+	if m.deleteMetricFunc != nil {
+		return m.deleteMetricFunc(ctx, mType, key)
+	}
+	return nil
+}
+
+func (m *mockStore) ResetCounter(ctx context.Context, key string) error {
+	if m.resetCounterFunc != nil {
+		return m.resetCounterFunc(ctx, key)
+	}
+	return nil
+}
+
 func (m *mockStore) Ping(ctx context.Context) error {
 	if m.pingFunc != nil {
 		return m.pingFunc(ctx)
@@ -79,7 +107,7 @@ func TestGRPCServer_Ping(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			logger := zaptest.NewLogger(t)
 			store := &mockStore{pingFunc: tt.pingFunc}
-			server := NewGRPCServer(store, logger)
+			server := NewGRPCServer(store, NewAlertEngine(store, nil), logger)
 
 			resp, err := server.Ping(context.Background(), &proto.PingRequest{})
 
@@ -162,7 +190,7 @@ func TestGRPCServer_BatchUpdate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			logger := zaptest.NewLogger(t)
 			store := &mockStore{batchUpdateFunc: tt.batchUpdateFunc}
-			server := NewGRPCServer(store, logger)
+			server := NewGRPCServer(store, NewAlertEngine(store, nil), logger)
 
 			req := &proto.BatchUpdateRequest{Metrics: tt.requestMetrics}
 			resp, err := server.BatchUpdate(context.Background(), req)
@@ -243,7 +271,7 @@ func TestGRPCServer_ListMetrics(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			logger := zaptest.NewLogger(t)
 			store := &mockStore{getAllFunc: tt.getAllFunc}
-			server := NewGRPCServer(store, logger)
+			server := NewGRPCServer(store, NewAlertEngine(store, nil), logger)
 
 			resp, err := server.ListMetrics(context.Background(), &proto.ListMetricsRequest{})
 
@@ -269,3 +297,103 @@ func TestGRPCServer_ListMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestGRPCServer_DeleteMetric(t *testing.T) {
+	tests := []struct {
+		name             string
+		req              *proto.DeleteMetricRequest
+		deleteMetricFunc func(ctx context.Context, mType string, key string) error
+		wantCode         codes.Code
+	}{
+		{
+			name: "successful delete",
+			req:  &proto.DeleteMetricRequest{Id: "Alloc", Type: common.MetricTypeGauge, Labels: map[string]string{"host": "a"}},
+			deleteMetricFunc: func(ctx context.Context, mType string, key string) error {
+				if mType != common.MetricTypeGauge || key != `Alloc{host="a"}` {
+					return errors.New("unexpected metric")
+				}
+				return nil
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "unknown type",
+			req:      &proto.DeleteMetricRequest{Id: "Alloc", Type: "unknown"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "bad labels",
+			req:      &proto.DeleteMetricRequest{Id: "Alloc", Type: common.MetricTypeGauge, Labels: map[string]string{"bad-name": "a"}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "series key as id",
+			req:      &proto.DeleteMetricRequest{Id: `Alloc{host="a"}`, Type: common.MetricTypeGauge},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "not found",
+			req:  &proto.DeleteMetricRequest{Id: "Alloc", Type: common.MetricTypeGauge},
+			deleteMetricFunc: func(ctx context.Context, mType string, key string) error {
+				return ErrNotFound
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "store error",
+			req:  &proto.DeleteMetricRequest{Id: "Alloc", Type: common.MetricTypeGauge},
+			deleteMetricFunc: func(ctx context.Context, mType string, key string) error {
+				return errors.New("storage error")
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockStore{deleteMetricFunc: tt.deleteMetricFunc}
+			server := NewGRPCServer(store, NewAlertEngine(store, nil), zaptest.NewLogger(t))
+
+			_, err := server.DeleteMetric(context.Background(), tt.req)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("Expected code %v, got %v (%v)", tt.wantCode, code, err)
+			}
+		})
+	}
+}
+
+func TestGRPCServer_ResetCounter(t *testing.T) {
+	tests := []struct {
+		name             string
+		id               string
+		resetCounterFunc func(ctx context.Context, key string) error
+		wantCode         codes.Code
+	}{
+		{name: "successful reset", id: "PollCount", wantCode: codes.OK},
+		{name: "series key as id", id: `PollCount{host="a"}`, wantCode: codes.InvalidArgument},
+		{
+			name:             "not found",
+			id:               "PollCount",
+			resetCounterFunc: func(ctx context.Context, key string) error { return ErrNotFound },
+			wantCode:         codes.NotFound,
+		},
+		{
+			name:             "store error",
+			id:               "PollCount",
+			resetCounterFunc: func(ctx context.Context, key string) error { return errors.New("storage error") },
+			wantCode:         codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockStore{resetCounterFunc: tt.resetCounterFunc}
+			server := NewGRPCServer(store, NewAlertEngine(store, nil), zaptest.NewLogger(t))
+
+			_, err := server.ResetCounter(context.Background(), &proto.ResetCounterRequest{Id: tt.id})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("Expected code %v, got %v (%v)", tt.wantCode, code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// metricsInterceptor counts calls and records their durations per method.
+func metricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		selfMetrics.inc(selfGRPCRequests, map[string]string{
+			selfLabelMethod: info.FullMethod,
+			selfLabelCode:   status.Code(err).String(),
+		})
+		selfMetrics.observeSince(selfGRPCRequestDuration, map[string]string{
+			selfLabelMethod: info.FullMethod,
+		}, start)
+
+		return resp, err
+	}
+}
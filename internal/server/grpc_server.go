@@ -9,20 +9,23 @@ import (
 	"github.com/etoneja/go-metrics/internal/proto"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func createGRPCServer(logger *zap.Logger, cfg *config) *grpc.Server {
 	return grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			loggingInterceptor(logger),
+			metricsInterceptor(),
 			TrustedSubnetInterceptor(cfg.TrustedSubnet, logger),
 		),
 	)
 }
 
-func registerServices(server *grpc.Server, store Storager, logger *zap.Logger) {
-	grpcMetricsServer := NewGRPCServer(store, logger)
+func registerServices(server *grpc.Server, store Storager, health *Health, alerts *AlertEngine, logger *zap.Logger) {
+	grpcMetricsServer := NewGRPCServer(store, alerts, logger)
 	proto.RegisterMetricsServiceServer(server, grpcMetricsServer)
+	healthpb.RegisterHealthServer(server, &grpcHealthServer{Server: health.grpc, health: health})
 }
 
 func startServing(server *grpc.Server, addr string, logger *zap.Logger, serverErrChan chan<- error) error {
@@ -43,9 +46,9 @@ func startServing(server *grpc.Server, addr string, logger *zap.Logger, serverEr
 	return nil
 }
 
-func StartGRPCServer(store Storager, logger *zap.Logger, cfg *config, serverErrChan chan<- error) (*grpc.Server, error) {
+func StartGRPCServer(store Storager, health *Health, alerts *AlertEngine, logger *zap.Logger, cfg *config, serverErrChan chan<- error) (*grpc.Server, error) {
 	grpcServer := createGRPCServer(logger, cfg)
-	registerServices(grpcServer, store, logger)
+	registerServices(grpcServer, store, health, alerts, logger)
 
 	if err := startServing(grpcServer, cfg.ServerGRPCAddress, logger, serverErrChan); err != nil {
 		return nil, err
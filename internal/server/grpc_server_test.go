@@ -12,7 +12,7 @@ func TestStartGRPCServer(t *testing.T) {
 	cfg := &config{ServerGRPCAddress: ":0"}
 	errChan := make(chan error, 1)
 
-	server, err := StartGRPCServer(store, logger, cfg, errChan)
+	server, err := StartGRPCServer(store, NewHealth(store), NewAlertEngine(store, nil), logger, cfg, errChan)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -7,13 +7,16 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 func TrustedSubnetInterceptor(allowedSubnet string, logger *zap.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-		if allowedSubnet == "" {
+		// Health checks come from load balancers, like HTTP probes they
+		// are allowed from anywhere.
+		if allowedSubnet == "" || info.FullMethod == healthpb.Health_Check_FullMethodName {
 			return handler(ctx, req)
 		}
 
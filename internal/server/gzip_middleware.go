@@ -16,10 +16,13 @@ var compressibleTypes = map[string]bool{
 }
 
 func isCompressibleContentType(contentType string) bool {
-	mimeType, _, err := mime.ParseMediaType(contentType)
+	mimeType, params, err := mime.ParseMediaType(contentType)
 	if err != nil {
 		return false
 	}
+	if mimeType == "text/plain" && params["version"] == prometheusTextVersion {
+		return true
+	}
 	res, ok := compressibleTypes[mimeType]
 	if !ok {
 		res = false
@@ -21,6 +21,7 @@ func TestIsCompressibleContentType(t *testing.T) {
 		{"text/html", true},
 		{"application/xml", false},
 		{"text/plain", false},
+		{"text/plain; version=0.0.4; charset=utf-8", true},
 		{"invalid", false},
 		{"", false},
 	}
@@ -1,12 +1,17 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/etoneja/go-metrics/internal/common"
 	"github.com/etoneja/go-metrics/internal/models"
@@ -32,7 +37,7 @@ func (bh *BaseHandler) MetricUpdateHandler() http.HandlerFunc {
 		metricName := chi.URLParam(r, "metricName")
 		metricValue := chi.URLParam(r, "metricValue")
 
-		if metricName == "" {
+		if metricName == "" || models.ValidateID(metricName) != nil {
 			http.Error(w, "Bad Request: bad metric name", http.StatusBadRequest)
 			return
 		}
@@ -122,6 +127,52 @@ func (bh *BaseHandler) MetricGetHandler() http.HandlerFunc {
 	}
 }
 
+// MetricDeleteHandler creates an HTTP handler that removes a metric and its history.
+//
+// The handler processes DELETE requests to /value/{metricType}/{metricName}.
+// When a hash key is configured the request must be signed: the HashSHA256
+// header carries the hash of the request path.
+//
+// Responses:
+//   - 200 OK: the metric was deleted
+//   - 400 Bad Request: bad metric type or request hash
+//   - 404 Not Found: the metric does not exist
+func (bh *BaseHandler) MetricDeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		metricType := chi.URLParam(r, "metricType")
+		metricName := chi.URLParam(r, "metricName")
+
+		switch metricType {
+		case common.MetricTypeGauge, common.MetricTypeCounter, common.MetricTypeHistogram, common.MetricTypeSummary:
+		default:
+			http.Error(w, "Bad Request: bad metric type", http.StatusBadRequest)
+			return
+		}
+
+		err := bh.store.DeleteMetric(r.Context(), metricType, metricName)
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			bh.logger.Error("failed to delete metric",
+				zap.String("metricType", metricType),
+				zap.String("metricName", metricName),
+				zap.Error(err),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		bh.logger.Info("metric deleted",
+			zap.String("metricType", metricType),
+			zap.String("metricName", metricName),
+		)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
 func (bh *BaseHandler) MetricListHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -143,12 +194,17 @@ func (bh *BaseHandler) MetricListHandler() http.HandlerFunc {
 
 		for _, m := range metrics {
 			var value string
-			if m.MType == common.MetricTypeCounter {
+			switch m.MType {
+			case common.MetricTypeCounter:
 				value = common.AnyToString(*m.Delta)
-			} else {
+			case common.MetricTypeHistogram:
+				value = formatHistogram(m.Histogram)
+			case common.MetricTypeSummary:
+				value = formatSummary(m.Summary)
+			default:
 				value = common.AnyToString(*m.Value)
 			}
-			bh.writeHTML(w, fmt.Sprintf("%s[%s]=%s\n", m.ID, m.MType, value))
+			bh.writeHTML(w, fmt.Sprintf("%s[%s]=%s\n", html.EscapeString(m.SeriesKey()), m.MType, value))
 		}
 
 		bh.writeHTML(w, "</pre></body></html>")
@@ -156,6 +212,112 @@ func (bh *BaseHandler) MetricListHandler() http.HandlerFunc {
 	}
 }
 
+// formatHistogram formats a histogram for the HTML list as
+// "count=3 sum=1.5 le(0.5)=1 le(1)=2 le(+Inf)=3" with cumulative bucket counts.
+func formatHistogram(h *models.Histogram) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "count=%d sum=%s", h.Count(), common.AnyToString(h.Sum))
+	var cumulative uint64
+	for i, c := range h.Counts {
+		cumulative += c
+		bound := "+Inf"
+		if i < len(h.Bounds) {
+			bound = common.AnyToString(h.Bounds[i])
+		}
+		fmt.Fprintf(&sb, " le(%s)=%d", bound, cumulative)
+	}
+	return sb.String()
+}
+
+// formatSummary formats a summary for the HTML list as
+// "count=3 sum=6 p50=2 p90=3 p99=3".
+func formatSummary(s *models.Summary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "count=%d sum=%s", s.Count, common.AnyToString(s.Sum))
+	for _, q := range models.DefaultSummaryQuantiles {
+		v, ok := s.Quantiles[models.FormatQuantile(q)]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&sb, " p%s=%s", common.AnyToString(q*100), common.AnyToString(v))
+	}
+	return sb.String()
+}
+
+// MetricPrometheusHandler creates an HTTP handler that exposes all stored metrics
+// in the Prometheus text exposition format.
+//
+// Metric IDs that are not valid Prometheus metric names are sanitized, see
+// sanitizePrometheusName. Metric labels are exposed as Prometheus labels.
+// Metrics the storage reports about itself, see SelfMetricsReporter, follow
+// the stored metrics.
+// Histograms are exposed as cumulative _bucket series with the le label
+// followed by _sum and _count series, summaries as series with the quantile
+// label followed by _sum and _count series.
+// If several series map to the same name and labels, or one name is used by
+// both a gauge and a counter, only the first one (in GetAll order) is exposed.
+//
+// Example response:
+//
+//	# TYPE PollCount counter
+//	PollCount{host="web1",instance="10.0.0.1"} 42
+//	# TYPE cpu_usage gauge
+//	cpu_usage 95.5
+func (bh *BaseHandler) MetricPrometheusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		metrics, err := bh.store.GetAll(ctx)
+		if err != nil {
+			bh.logger.Error("failed to get metrics",
+				zap.Error(err),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if reporter, ok := bh.store.(SelfMetricsReporter); ok {
+			metrics = append(metrics, reporter.SelfMetrics()...)
+		}
+
+		w.Header().Set("Content-Type", prometheusTextContentType)
+		w.WriteHeader(http.StatusOK)
+
+		skipped, err := writePrometheusText(w, metrics)
+		if err != nil {
+			bh.logger.Warn("write response failed", zap.Error(err))
+			return
+		}
+		for _, m := range skipped {
+			bh.logger.Warn("metric skipped in prometheus exposition",
+				zap.String("metricType", m.MType),
+				zap.String("metricName", m.ID),
+			)
+		}
+
+	}
+}
+
+// SelfMetricsHandler creates an HTTP handler that exposes metrics the server
+// records about itself, such as request counts and latencies per route, and
+// metrics the storage reports about itself, in the Prometheus text
+// exposition format.
+func (bh *BaseHandler) SelfMetricsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics := selfMetrics.snapshot()
+		if reporter, ok := bh.store.(SelfMetricsReporter); ok {
+			metrics = append(metrics, reporter.SelfMetrics()...)
+		}
+
+		w.Header().Set("Content-Type", prometheusTextContentType)
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := writePrometheusText(w, metrics); err != nil {
+			bh.logger.Warn("write response failed", zap.Error(err))
+		}
+	}
+}
+
 func (bh *BaseHandler) MetricUpdateJSONHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -181,7 +343,7 @@ func (bh *BaseHandler) MetricUpdateJSONHandler() http.HandlerFunc {
 				return
 			}
 
-			newValue, err := bh.store.SetGauge(ctx, metricModelRequest.ID, *metricModelRequest.Value)
+			newValue, err := bh.store.SetGauge(ctx, metricModelRequest.SeriesKey(), *metricModelRequest.Value)
 			if err != nil {
 				bh.logger.Error("failed to get metric",
 					zap.String("metricType", metricModelRequest.MType),
@@ -199,7 +361,7 @@ func (bh *BaseHandler) MetricUpdateJSONHandler() http.HandlerFunc {
 				return
 			}
 
-			newValue, err := bh.store.IncrementCounter(ctx, metricModelRequest.ID, *metricModelRequest.Delta)
+			newValue, err := bh.store.IncrementCounter(ctx, metricModelRequest.SeriesKey(), *metricModelRequest.Delta)
 			if err != nil {
 				bh.logger.Error("failed to get metric",
 					zap.String("metricType", metricModelRequest.MType),
@@ -211,11 +373,53 @@ func (bh *BaseHandler) MetricUpdateJSONHandler() http.HandlerFunc {
 			}
 
 			metricModelResponse = *models.NewMetricModel(metricModelRequest.ID, metricModelRequest.MType, newValue, 0)
+		case common.MetricTypeHistogram:
+			if metricModelRequest.Histogram == nil {
+				http.Error(w, "Bad Request: missing histogram", http.StatusBadRequest)
+				return
+			}
+
+			newValue, err := bh.store.UpdateHistogram(ctx, metricModelRequest.SeriesKey(), metricModelRequest.Histogram)
+			if errors.Is(err, models.ErrHistogramBoundsMismatch) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				bh.logger.Error("failed to get metric",
+					zap.String("metricType", metricModelRequest.MType),
+					zap.String("metricName", metricModelRequest.ID),
+					zap.Error(err),
+				)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			metricModelResponse = *models.NewHistogramMetricModel(metricModelRequest.ID, newValue)
+		case common.MetricTypeSummary:
+			if len(metricModelRequest.Observations) == 0 {
+				http.Error(w, "Bad Request: missing observations", http.StatusBadRequest)
+				return
+			}
+
+			newValue, err := bh.store.UpdateSummary(ctx, metricModelRequest.SeriesKey(), metricModelRequest.Observations)
+			if err != nil {
+				bh.logger.Error("failed to get metric",
+					zap.String("metricType", metricModelRequest.MType),
+					zap.String("metricName", metricModelRequest.ID),
+					zap.Error(err),
+				)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			metricModelResponse = *models.NewSummaryMetricModel(metricModelRequest.ID, newValue)
 		default:
 			http.Error(w, "Bad Request: bad metric type", http.StatusBadRequest)
 			return
 		}
 
+		metricModelResponse.Labels = metricModelRequest.Labels
+
 		resp, err := json.Marshal(metricModelResponse)
 		if err != nil {
 			bh.logger.Error("failed to marshal response",
@@ -253,11 +457,32 @@ func (bh *BaseHandler) MetricGetJSONHandler() http.HandlerFunc {
 			return
 		}
 
+		if err = models.ValidateID(metricGetRequestModel.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err = models.ValidateLabels(metricGetRequestModel.Labels); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		seriesKey := models.SeriesKey(metricGetRequestModel.ID, metricGetRequestModel.Labels)
+
+		if quantile := metricGetRequestModel.Quantile; quantile != nil {
+			if metricGetRequestModel.MType != common.MetricTypeSummary {
+				http.Error(w, "Bad Request: quantile is only supported by summary metrics", http.StatusBadRequest)
+				return
+			}
+			if err = models.ValidateQuantile(*quantile); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
 		ctx := r.Context()
 
 		switch metricGetRequestModel.MType {
 		case common.MetricTypeGauge:
-			value, errGetGauge := bh.store.GetGauge(ctx, metricGetRequestModel.ID)
+			value, errGetGauge := bh.store.GetGauge(ctx, seriesKey)
 			err = errGetGauge
 			if errors.Is(err, ErrNotFound) {
 				http.Error(w, err.Error(), http.StatusNotFound)
@@ -275,7 +500,7 @@ func (bh *BaseHandler) MetricGetJSONHandler() http.HandlerFunc {
 
 			metricModel = *models.NewMetricModel(metricGetRequestModel.ID, metricGetRequestModel.MType, 0, value)
 		case common.MetricTypeCounter:
-			value, errGetCounter := bh.store.GetCounter(ctx, metricGetRequestModel.ID)
+			value, errGetCounter := bh.store.GetCounter(ctx, seriesKey)
 			err = errGetCounter
 			if errors.Is(err, ErrNotFound) {
 				http.Error(w, err.Error(), http.StatusNotFound)
@@ -292,11 +517,54 @@ func (bh *BaseHandler) MetricGetJSONHandler() http.HandlerFunc {
 			}
 
 			metricModel = *models.NewMetricModel(metricGetRequestModel.ID, metricGetRequestModel.MType, value, 0)
+		case common.MetricTypeHistogram:
+			value, errGetHistogram := bh.store.GetHistogram(ctx, seriesKey)
+			err = errGetHistogram
+			if errors.Is(err, ErrNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				bh.logger.Error("failed to get metric",
+					zap.String("metricType", metricGetRequestModel.MType),
+					zap.String("metricName", metricGetRequestModel.ID),
+					zap.Error(err),
+				)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			metricModel = *models.NewHistogramMetricModel(metricGetRequestModel.ID, value)
+		case common.MetricTypeSummary:
+			value, errGetSummary := bh.store.GetSummary(ctx, seriesKey)
+			err = errGetSummary
+			if errors.Is(err, ErrNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				bh.logger.Error("failed to get metric",
+					zap.String("metricType", metricGetRequestModel.MType),
+					zap.String("metricName", metricGetRequestModel.ID),
+					zap.Error(err),
+				)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			metricModel = *models.NewSummaryMetricModel(metricGetRequestModel.ID, value)
+			if quantile := metricGetRequestModel.Quantile; quantile != nil {
+				estimate := value.Quantile(*quantile)
+				metricModel.Quantile = quantile
+				metricModel.Value = &estimate
+			}
 		default:
 			http.Error(w, "Bad Request: bad metric type", http.StatusBadRequest)
 			return
 		}
 
+		metricModel.Labels = metricGetRequestModel.Labels
+
 		resp, err := json.Marshal(metricModel)
 		if err != nil {
 			bh.logger.Error("failed to marshal response",
@@ -332,6 +600,270 @@ func (bh *BaseHandler) PingHandler() http.HandlerFunc {
 
 }
 
+// LivenessHandler creates an HTTP handler that answers 200 OK as long as the
+// process serves requests. Unlike PingHandler it does not touch the storage,
+// so a slow database does not get the process restarted.
+func (bh *BaseHandler) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// ReadinessHandler creates an HTTP handler that answers 200 OK when the
+// server should receive traffic and 503 Service Unavailable with the reason
+// otherwise, see Health.Ready.
+func (bh *BaseHandler) ReadinessHandler(health *Health) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := health.Ready(r.Context())
+		if err != nil {
+			bh.logger.Warn("server is not ready",
+				zap.Error(err),
+			)
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// AlertsHandler creates an HTTP handler that lists pending and firing
+// alerts as a JSON array ordered by rule name.
+//
+// Example response:
+//
+//	[
+//	  {
+//	    "rule": "HighHeap",
+//	    "expr": "gauge HeapAlloc > 500MB for 2m",
+//	    "state": "firing",
+//	    "value": 612368384,
+//	    "labels": {"severity": "page"},
+//	    "active_at": "2024-05-01T10:00:00Z",
+//	    "fired_at": "2024-05-01T10:02:00Z",
+//	    "silenced": true,
+//	    "silenced_by": ["9f86d081884c7d65"],
+//	    "acknowledged": {"author": "alice", "comment": "looking", "at": "2024-05-01T10:03:00Z"}
+//	  }
+//	]
+func (bh *BaseHandler) AlertsHandler(alerts *AlertEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := json.Marshal(alerts.Alerts())
+		if err != nil {
+			bh.logger.Error("failed to marshal response",
+				zap.Error(err),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(resp); err != nil {
+			bh.logger.Warn("write response failed", zap.Error(err))
+		}
+	}
+}
+
+// alertAckRequest is the body of an alert acknowledgement.
+type alertAckRequest struct {
+	Author  string `json:"author"`
+	Comment string `json:"comment"`
+}
+
+// AlertAckHandler creates an HTTP handler that acknowledges an alert, see
+// AlertEngine.Acknowledge.
+//
+// The handler processes POST requests to /alerts/{rule}/ack with the
+// acknowledgement in the request body:
+//
+//	{"author": "alice", "comment": "looking into it"}
+//
+// Responses:
+//   - 200 OK: the acknowledged alert
+//   - 400 Bad Request: invalid acknowledgement
+//   - 404 Not Found: the rule has no pending or firing alert
+func (bh *BaseHandler) AlertAckHandler(alerts *AlertEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		rule := chi.URLParam(r, "rule")
+
+		var ack alertAckRequest
+		if err := json.NewDecoder(r.Body).Decode(&ack); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		alert, err := alerts.Acknowledge(rule, ack.Author, ack.Comment)
+		if errors.Is(err, errInvalidAck) {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			bh.logger.Error("failed to acknowledge alert", zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		bh.logger.Info("alert acknowledged",
+			zap.String("rule", rule),
+			zap.String("author", ack.Author),
+		)
+
+		resp, err := json.Marshal(alert)
+		if err != nil {
+			bh.logger.Error("failed to marshal response",
+				zap.Error(err),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(resp); err != nil {
+			bh.logger.Warn("write response failed", zap.Error(err))
+		}
+	}
+}
+
+// SilenceCreateHandler creates an HTTP handler that adds an alert silence.
+//
+// The handler processes POST requests to /silences with a silence in the
+// request body, starts_at defaults to now:
+//
+//	{
+//	  "metric_id": "HeapAlloc",     // metric name or series key
+//	  "metric_type": "gauge",       // optional, any type if empty
+//	  "starts_at": "2024-05-01T10:00:00Z",
+//	  "ends_at": "2024-05-01T12:00:00Z",
+//	  "author": "alice",
+//	  "comment": "planned load test"
+//	}
+//
+// Responses:
+//   - 201 Created: the stored silence with its id
+//   - 400 Bad Request: invalid silence
+//   - 501 Not Implemented: the storage does not keep silences
+func (bh *BaseHandler) SilenceCreateHandler(alerts *AlertEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var silence models.Silence
+		if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		silence, err := alerts.AddSilence(r.Context(), silence)
+		if errors.Is(err, errInvalidSilence) {
+			http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, errSilencesNotSupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			bh.logger.Error("failed to save silence", zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		bh.logger.Info("silence created",
+			zap.String("id", silence.ID),
+			zap.String("metricID", silence.MetricID),
+			zap.String("author", silence.Author),
+		)
+
+		resp, err := json.Marshal(silence)
+		if err != nil {
+			bh.logger.Error("failed to marshal response",
+				zap.Error(err),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if _, err := w.Write(resp); err != nil {
+			bh.logger.Warn("write response failed", zap.Error(err))
+		}
+	}
+}
+
+// SilenceListHandler creates an HTTP handler that lists alert silences that
+// have not expired yet as a JSON array ordered by id.
+func (bh *BaseHandler) SilenceListHandler(alerts *AlertEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		silences, err := alerts.Silences(r.Context())
+		if errors.Is(err, errSilencesNotSupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			bh.logger.Error("failed to get silences", zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		resp, err := json.Marshal(silences)
+		if err != nil {
+			bh.logger.Error("failed to marshal response",
+				zap.Error(err),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(resp); err != nil {
+			bh.logger.Warn("write response failed", zap.Error(err))
+		}
+	}
+}
+
+// SilenceDeleteHandler creates an HTTP handler that removes an alert
+// silence.
+//
+// The handler processes DELETE requests to /silences/{id}.
+//
+// Responses:
+//   - 200 OK: the silence was deleted
+//   - 404 Not Found: the silence does not exist
+//   - 501 Not Implemented: the storage does not keep silences
+func (bh *BaseHandler) SilenceDeleteHandler(alerts *AlertEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		id := chi.URLParam(r, "id")
+
+		err := alerts.DeleteSilence(r.Context(), id)
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, errSilencesNotSupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			bh.logger.Error("failed to delete silence",
+				zap.String("id", id),
+				zap.Error(err),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		bh.logger.Info("silence deleted", zap.String("id", id))
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
 // MetricBatchUpdateJSONHandler creates an HTTP handler for batch updating metrics in JSON format.
 //
 // The handler processes POST requests to /updates/ with a JSON array of metrics in the request body:
@@ -339,14 +871,21 @@ func (bh *BaseHandler) PingHandler() http.HandlerFunc {
 //	[
 //	  {
 //	    "id": "string",         // metric identifier
-//	    "type": "gauge|counter", // metric type
+//	    "type": "gauge|counter|histogram|summary", // metric type
 //	    "value": number,        // value for gauge metrics
-//	    "delta": number         // value for counter metrics
+//	    "delta": number,        // value for counter metrics
+//	    "histogram": {          // observations for histogram metrics, merged into stored ones
+//	      "bounds": [number],   // bucket upper bounds, must match the stored histogram
+//	      "counts": [number],   // observations per bucket, one more than bounds (+Inf)
+//	      "sum": number         // sum of observed values
+//	    },
+//	    "observations": [number], // raw observations for summary metrics
+//	    "labels": {"k": "v"}    // optional labels, a series is identified by id and labels
 //	  }
 //	]
 //
 // Responses:
-//   - 200 OK: Returns the updated metrics array
+//   - 200 OK: Returns the updated metrics in request order, counters with their accumulated value
 //   - 400 Bad Request: Invalid JSON format or malformed data
 //   - 500 Internal Server Error: Server-side processing error
 //
@@ -401,3 +940,325 @@ func (bh *BaseHandler) MetricBatchUpdateJSONHandler() http.HandlerFunc {
 	}
 
 }
+
+// InfluxWriteHandler creates an HTTP handler that ingests InfluxDB line
+// protocol, e.g. from Telegraf.
+//
+// The handler processes POST requests to /write with one point per line,
+// mapped to metrics as described by influxMetrics. The precision query
+// parameter (ns, u, ms, s, m, h) gives the unit of timestamps. Valid lines
+// are written even if other lines fail to parse.
+//
+// The whole body is parsed before anything is written, and the valid lines
+// are written by a single BatchUpdate. A 500 response therefore means that
+// nothing was stored and the request can be retried as a whole, which
+// Telegraf does, without applying counter increments twice.
+//
+// Responses:
+//   - 204 No Content: every line was written
+//   - 400 Bad Request: JSON {"error": ...} with an "unable to parse" entry
+//     per bad line, prefixed with "partial write:" if other lines were
+//     written
+//   - 413 Request Entity Too Large: body over influxMaxBodySize after
+//     decompression, no line was written
+//   - 500 Internal Server Error: JSON {"error": ...} with the storage error,
+//     no line was written
+//
+// Example request:
+//
+//	curl -X POST "http://localhost:8080/write?precision=s" \
+//	  --data-binary 'cpu,host=web1 usage_idle=93.5,usage_user=4.1 1714557600'
+func (bh *BaseHandler) InfluxWriteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		precision, ok := influxPrecisions[r.URL.Query().Get("precision")]
+		if !ok {
+			bh.writeInfluxError(w, http.StatusBadRequest, "invalid precision "+strconv.Quote(r.URL.Query().Get("precision")))
+			return
+		}
+
+		ctx := r.Context()
+
+		var metrics []models.MetricModel
+		var lineErrors []string
+		lines, points := 0, 0
+
+		scanner := bufio.NewScanner(http.MaxBytesReader(w, r.Body, influxMaxBodySize))
+		scanner.Buffer(make([]byte, 64<<10), influxMaxLineSize)
+		for scanner.Scan() {
+			lines++
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			point, err := parseInfluxLine(line)
+			var pointMetrics []models.MetricModel
+			if err == nil {
+				pointMetrics, err = influxMetrics(point, precision)
+			}
+			if err != nil {
+				lineErrors = append(lineErrors, fmt.Sprintf("unable to parse '%s': %s (line %d)", line, err, lines))
+				continue
+			}
+			points++
+			metrics = append(metrics, pointMetrics...)
+		}
+		if err := scanner.Err(); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				bh.writeInfluxError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			bh.writeInfluxError(w, http.StatusBadRequest, "failed to read body: "+err.Error())
+			return
+		}
+
+		// One write for the whole body: a failed request stored nothing, so
+		// retrying it does not apply counter increments twice.
+		if len(metrics) > 0 {
+			if _, err := bh.store.BatchUpdate(ctx, metrics); err != nil {
+				bh.logger.Error("failed to write influx metrics", zap.Error(err))
+				bh.writeInfluxError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
+		bh.logger.Debug("influx write",
+			zap.Int("lines", lines),
+			zap.Int("points", points),
+			zap.Int("metrics", len(metrics)),
+			zap.Int("errors", len(lineErrors)),
+		)
+
+		if len(lineErrors) > 0 {
+			if len(lineErrors) > influxMaxErrors {
+				dropped := len(lineErrors) - influxMaxErrors
+				lineErrors = append(lineErrors[:influxMaxErrors], fmt.Sprintf("and %d more", dropped))
+			}
+			msg := strings.Join(lineErrors, "\n")
+			if points > 0 {
+				msg = "partial write: " + msg
+			}
+			bh.writeInfluxError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeInfluxError writes an error the way InfluxDB reports them.
+func (bh *BaseHandler) writeInfluxError(w http.ResponseWriter, status int, msg string) {
+	resp, err := json.Marshal(map[string]string{"error": msg})
+	if err != nil {
+		bh.logger.Error("failed to marshal response",
+			zap.Error(err),
+		)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(resp); err != nil {
+		bh.logger.Warn("write response failed", zap.Error(err))
+	}
+}
+
+// RemoteWriteHandler creates an HTTP handler that receives Prometheus
+// remote_write 1.0 requests, so Prometheus servers and agents can use the
+// server as a long-term sink.
+//
+// The handler processes POST requests to /api/v1/write with a snappy
+// compressed protobuf WriteRequest body, mapped to metrics as described by
+// remoteWriteMetrics. Valid series are written even if others are invalid.
+// Prometheus retries 5xx responses and drops the request on 4xx.
+//
+// Responses:
+//   - 204 No Content: every series was written
+//   - 400 Bad Request: undecodable body, or invalid series that were left out
+//   - 413 Request Entity Too Large: body over remoteWriteMaxSize
+//   - 415 Unsupported Media Type: not snappy, or remote_write 2.0
+//   - 500 Internal Server Error: storage error
+//
+// Example Prometheus configuration:
+//
+//	remote_write:
+//	  - url: http://localhost:8080/api/v1/write
+func (bh *BaseHandler) RemoteWriteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if encoding := r.Header.Get("Content-Encoding"); encoding != "snappy" {
+			http.Error(w, "unsupported content encoding "+strconv.Quote(encoding), http.StatusUnsupportedMediaType)
+			return
+		}
+		if strings.Contains(r.Header.Get("Content-Type"), remoteWriteV2ContentType) {
+			http.Error(w, "remote write 2.0 is not supported", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, remoteWriteMaxSize+1))
+		if err != nil {
+			http.Error(w, "failed to read body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(body) > remoteWriteMaxSize {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		req, err := decodeRemoteWrite(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		metrics, stats, seriesErrors := remoteWriteMetrics(req)
+
+		ctx := r.Context()
+		for start := 0; start < len(metrics); start += remoteWriteBatchSize {
+			end := min(start+remoteWriteBatchSize, len(metrics))
+			if _, err := bh.store.BatchUpdate(ctx, metrics[start:end]); err != nil {
+				bh.logger.Error("failed to write remote write metrics", zap.Error(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		bh.logger.Info("remote write",
+			zap.Int("series", stats.series),
+			zap.Int("samples", stats.samples),
+			zap.Int("skipped_samples", stats.skipped),
+			zap.Int("metrics", len(metrics)),
+			zap.Int("invalid_series", len(seriesErrors)),
+		)
+
+		if len(seriesErrors) > 0 {
+			http.Error(w, errors.Join(seriesErrors...).Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func parseTimeQueryParam(r *http.Request, name string, def time.Time) (time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	ts, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad %s parameter: %w", name, err)
+	}
+	return ts, nil
+}
+
+// MetricHistoryHandler creates an HTTP handler that returns stored samples of a metric.
+//
+// The handler processes GET requests to /history/{metricType}/{metricName}.
+// Optional query parameters from and to limit the time range (RFC 3339),
+// by default all samples up to now are returned. With the resolution query
+// parameter (e.g. 1m, 1h) rollups of that resolution are returned instead
+// of raw samples.
+//
+// Responses:
+//   - 200 OK: JSON array of samples (or rollups) ordered by time
+//   - 400 Bad Request: bad metric type, time range or resolution
+//   - 501 Not Implemented: the storage does not keep history
+//
+// Example request:
+//
+//	curl "http://localhost:8080/history/gauge/HeapAlloc?from=2025-01-02T15:00:00Z"
+//
+// Example response:
+//
+//	[
+//	  {
+//	    "timestamp": "2025-01-02T15:00:02Z",
+//	    "value": 1024
+//	  }
+//	]
+func (bh *BaseHandler) MetricHistoryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		metricType := chi.URLParam(r, "metricType")
+		metricName := chi.URLParam(r, "metricName")
+
+		if metricType != common.MetricTypeGauge && metricType != common.MetricTypeCounter {
+			http.Error(w, "Bad Request: bad metric type", http.StatusBadRequest)
+			return
+		}
+
+		historyReader, ok := bh.store.(HistoryReader)
+		if !ok {
+			http.Error(w, "history is not supported by storage", http.StatusNotImplemented)
+			return
+		}
+
+		from, err := parseTimeQueryParam(r, "from", time.Time{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeQueryParam(r, "to", time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if from.After(to) {
+			http.Error(w, "Bad Request: from is after to", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+
+		var samples any
+		if rawResolution := r.URL.Query().Get("resolution"); rawResolution != "" {
+			resolution, parseErr := parseRetentionDuration(rawResolution)
+			if parseErr != nil || resolution <= 0 {
+				http.Error(w, "Bad Request: bad resolution", http.StatusBadRequest)
+				return
+			}
+			rollupReader, ok := bh.store.(RollupReader)
+			if !ok {
+				http.Error(w, "rollups are not supported by storage", http.StatusNotImplemented)
+				return
+			}
+			samples, err = rollupReader.GetRollups(ctx, metricType, metricName, resolution, from, to)
+		} else {
+			samples, err = historyReader.GetHistory(ctx, metricType, metricName, from, to)
+		}
+		// Wrapping storages implement the readers and report the wrapped
+		// storage's lack of support as an error.
+		if errors.Is(err, errHistoryNotSupported) || errors.Is(err, errRollupsNotSupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			bh.logger.Error("failed to get metric history",
+				zap.String("metricType", metricType),
+				zap.String("metricName", metricName),
+				zap.Error(err),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		resp, err := json.Marshal(samples)
+		if err != nil {
+			bh.logger.Error("failed to marshal response",
+				zap.Error(err),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(resp); err != nil {
+			bh.logger.Warn("write response failed", zap.Error(err))
+		}
+
+	}
+}
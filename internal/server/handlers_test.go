@@ -1,8 +1,11 @@
 package server
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -10,11 +13,20 @@ import (
 	"testing"
 
 	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/etoneja/go-metrics/internal/proto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	protobuf "google.golang.org/protobuf/proto"
 )
 
+// newTestRouter returns the router of a ready server without alerting rules.
+func newTestRouter(store Storager, cfg *config) http.Handler {
+	health := NewHealth(store)
+	health.SetServing()
+	return NewRouter(store, cfg, health, NewAlertEngine(store, nil))
+}
+
 func TestBaseHandler_writeHTML(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	handler := &BaseHandler{
@@ -116,6 +128,15 @@ func TestMetricUpdateHandler(t *testing.T) {
 				statusCode: http.StatusBadRequest,
 			},
 		},
+		{
+			name:   "braces in metric name",
+			store:  NewMemStorage(),
+			uri:    "/update/gauge/fake%7Bhost=%22a%22%7D/1",
+			method: http.MethodPost,
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
 		{
 			name:   "success gauge",
 			store:  NewMemStorage(),
@@ -139,7 +160,7 @@ func TestMetricUpdateHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cfg := &config{}
-			server := httptest.NewServer(NewRouter(tt.store, cfg))
+			server := httptest.NewServer(newTestRouter(tt.store, cfg))
 			defer server.Close()
 
 			req, err := http.NewRequest(tt.method, server.URL+tt.uri, nil)
@@ -228,7 +249,7 @@ func TestMetricGetHandler(t *testing.T) {
 			}
 
 			cfg := &config{}
-			server := httptest.NewServer(NewRouter(store, cfg))
+			server := httptest.NewServer(newTestRouter(store, cfg))
 			defer server.Close()
 
 			req, err := http.NewRequest(tt.method, server.URL+tt.uri, nil)
@@ -309,7 +330,7 @@ func TestMetricListHandler(t *testing.T) {
 			}
 
 			cfg := &config{}
-			server := httptest.NewServer(NewRouter(store, cfg))
+			server := httptest.NewServer(newTestRouter(store, cfg))
 			defer server.Close()
 
 			req, err := http.NewRequest("GET", server.URL+"/", nil)
@@ -340,6 +361,83 @@ func TestMetricListHandler(t *testing.T) {
 	}
 }
 
+func TestMetricPrometheusHandler_SelfMetrics(t *testing.T) {
+	mem := NewMemStorage()
+	if _, err := mem.SetGauge(context.Background(), "Alloc", 1); err != nil {
+		t.Fatalf("SetGauge failed: %v", err)
+	}
+	store := NewCachedStorage(mem, 10)
+
+	server := httptest.NewServer(newTestRouter(store, &config{}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Logf("Failed to close response body: %v", err)
+		}
+	}()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "# TYPE Alloc gauge\nAlloc 1\n# TYPE storage_cache_entries gauge\nstorage_cache_entries 0\n", string(body))
+}
+
+func TestMetricPrometheusHandler(t *testing.T) {
+	store := NewMemStorage()
+	if _, err := store.SetGauge(context.Background(), "Heap.Alloc", 123.45); err != nil {
+		t.Fatalf("SetGauge failed: %v", err)
+	}
+	if _, err := store.IncrementCounter(context.Background(), "PollCount", 42); err != nil {
+		t.Fatalf("IncrementCounter failed: %v", err)
+	}
+
+	cfg := &config{}
+	server := httptest.NewServer(newTestRouter(store, cfg))
+	defer server.Close()
+
+	t.Run("plain", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/metrics", nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Logf("Failed to close response body: %v", err)
+			}
+		}()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, prometheusTextContentType, resp.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "# TYPE Heap_Alloc gauge\nHeap_Alloc 123.45\n# TYPE PollCount counter\nPollCount 42\n", string(body))
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/metrics", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Logf("Failed to close response body: %v", err)
+			}
+		}()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+		gz, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(gz)
+		assert.Contains(t, string(body), "PollCount 42")
+	})
+}
+
 func TestMetricUpdateJSONHandler(t *testing.T) {
 	type want struct {
 		statusCode int
@@ -382,6 +480,15 @@ func TestMetricUpdateJSONHandler(t *testing.T) {
 				statusCode: http.StatusBadRequest,
 			},
 		},
+		{
+			name:   "braces in metric id",
+			method: http.MethodPost,
+			uri:    "/update/",
+			body:   `{"id":"cpu{host=\"a\"}","type":"gauge","value":23.5}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
 		{
 			name:   "missing gauge value",
 			method: http.MethodPost,
@@ -433,7 +540,7 @@ func TestMetricUpdateJSONHandler(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			store := NewMemStorage()
 			cfg := &config{}
-			server := httptest.NewServer(NewRouter(store, cfg))
+			server := httptest.NewServer(newTestRouter(store, cfg))
 			defer server.Close()
 
 			req, err := http.NewRequest(tt.method, server.URL+tt.uri, strings.NewReader(tt.body))
@@ -578,7 +685,7 @@ func TestMetricGetJSONHandler(t *testing.T) {
 			}
 
 			cfg := &config{}
-			server := httptest.NewServer(NewRouter(store, cfg))
+			server := httptest.NewServer(newTestRouter(store, cfg))
 			defer server.Close()
 
 			req, err := http.NewRequest("POST", server.URL+"/value/", strings.NewReader(tt.body))
@@ -637,7 +744,7 @@ func TestPingHandler(t *testing.T) {
 				tt.prepare(store)
 			}
 			cfg := &config{}
-			server := httptest.NewServer(NewRouter(store, cfg))
+			server := httptest.NewServer(newTestRouter(store, cfg))
 			defer server.Close()
 
 			req, err := http.NewRequest("GET", server.URL+"/ping", nil)
@@ -656,3 +763,558 @@ func TestPingHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricHistoryHandler(t *testing.T) {
+	type want struct {
+		statusCode int
+		samples    int
+	}
+
+	tests := []struct {
+		name  string
+		store Storager
+		uri   string
+		want  want
+	}{
+		{
+			name:  "gauge history",
+			store: NewMemStorage(),
+			uri:   "/history/gauge/gauge1",
+			want: want{
+				statusCode: http.StatusOK,
+				samples:    2,
+			},
+		},
+		{
+			name:  "counter history",
+			store: NewMemStorage(),
+			uri:   "/history/counter/counter1",
+			want: want{
+				statusCode: http.StatusOK,
+				samples:    1,
+			},
+		},
+		{
+			name:  "unknown metric",
+			store: NewMemStorage(),
+			uri:   "/history/gauge/nonexistent",
+			want: want{
+				statusCode: http.StatusOK,
+				samples:    0,
+			},
+		},
+		{
+			name:  "range in the past",
+			store: NewMemStorage(),
+			uri:   "/history/gauge/gauge1?from=2000-01-01T00:00:00Z&to=2000-01-02T00:00:00Z",
+			want: want{
+				statusCode: http.StatusOK,
+				samples:    0,
+			},
+		},
+		{
+			name:  "bad metric type",
+			store: NewMemStorage(),
+			uri:   "/history/faketype/gauge1",
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:  "bad from",
+			store: NewMemStorage(),
+			uri:   "/history/gauge/gauge1?from=yesterday",
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:  "from after to",
+			store: NewMemStorage(),
+			uri:   "/history/gauge/gauge1?from=2000-01-02T00:00:00Z&to=2000-01-01T00:00:00Z",
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:  "rollups",
+			store: NewMemStorage(),
+			uri:   "/history/gauge/gauge1?resolution=1m",
+			want: want{
+				statusCode: http.StatusOK,
+				samples:    0,
+			},
+		},
+		{
+			name:  "bad resolution",
+			store: NewMemStorage(),
+			uri:   "/history/gauge/gauge1?resolution=often",
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:  "storage without history",
+			store: &mockStore{},
+			uri:   "/history/gauge/gauge1",
+			want: want{
+				statusCode: http.StatusNotImplemented,
+			},
+		},
+		{
+			name:  "cached storage without history",
+			store: NewCachedStorage(&mockStore{}, 10),
+			uri:   "/history/gauge/gauge1",
+			want: want{
+				statusCode: http.StatusNotImplemented,
+			},
+		},
+		{
+			name:  "cached storage without rollups",
+			store: NewCachedStorage(&mockStore{}, 10),
+			uri:   "/history/gauge/gauge1?resolution=1m",
+			want: want{
+				statusCode: http.StatusNotImplemented,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := tt.store.SetGauge(ctx, "gauge1", 1)
+			require.NoError(t, err)
+			_, err = tt.store.SetGauge(ctx, "gauge1", 2)
+			require.NoError(t, err)
+			_, err = tt.store.IncrementCounter(ctx, "counter1", 3)
+			require.NoError(t, err)
+
+			cfg := &config{}
+			server := httptest.NewServer(newTestRouter(tt.store, cfg))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + tt.uri)
+			require.NoError(t, err)
+			defer func() {
+				if err := resp.Body.Close(); err != nil {
+					t.Logf("Failed to close response body: %v", err)
+				}
+			}()
+
+			assert.Equal(t, tt.want.statusCode, resp.StatusCode)
+
+			if tt.want.statusCode == http.StatusOK {
+				var samples []models.MetricSample
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&samples))
+				assert.Len(t, samples, tt.want.samples)
+			}
+		})
+	}
+}
+
+func TestMetricHistoryHandler_InstrumentedBolt(t *testing.T) {
+	// main always wraps the storage, bolt keeps no history.
+	bolt, _ := newTestBoltStorage(t)
+	store := NewInstrumentedStorage(bolt)
+	defer store.ShutDown()
+	server := httptest.NewServer(newTestRouter(store, &config{}))
+	defer server.Close()
+
+	for _, uri := range []string{"/history/gauge/gauge1", "/history/gauge/gauge1?resolution=1m"} {
+		resp, err := http.Get(server.URL + uri)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusNotImplemented, resp.StatusCode, uri)
+	}
+}
+
+func TestMetricJSONHandlers_Labels(t *testing.T) {
+	store := NewMemStorage()
+	cfg := &config{}
+	server := httptest.NewServer(newTestRouter(store, cfg))
+	defer server.Close()
+
+	post := func(uri string, body string) (int, string) {
+		resp, err := http.Post(server.URL+uri, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Logf("Failed to close response body: %v", err)
+			}
+		}()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	status, body := post("/update/", `{"id":"Alloc","type":"gauge","value":1,"labels":{"host":"a"}}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id":"Alloc","type":"gauge","value":1,"labels":{"host":"a"}}`, body)
+
+	status, _ = post("/updates/", `[{"id":"Alloc","type":"gauge","value":2,"labels":{"host":"b"}}]`)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, body = post("/value/", `{"id":"Alloc","type":"gauge","labels":{"host":"a"}}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id":"Alloc","type":"gauge","value":1,"labels":{"host":"a"}}`, body)
+
+	status, _ = post("/value/", `{"id":"Alloc","type":"gauge"}`)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = post("/value/", `{"id":"Alloc","type":"gauge","labels":{"bad-name":"a"}}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = post("/update/", `{"id":"Alloc","type":"gauge","value":1,"labels":{"bad-name":"a"}}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestMetricJSONHandlers_Histogram(t *testing.T) {
+	store := NewMemStorage()
+	cfg := &config{}
+	server := httptest.NewServer(newTestRouter(store, cfg))
+	defer server.Close()
+
+	post := func(uri string, body string) (int, string) {
+		resp, err := http.Post(server.URL+uri, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Logf("Failed to close response body: %v", err)
+			}
+		}()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	status, body := post("/update/", `{"id":"latency","type":"histogram","histogram":{"bounds":[0.5,1],"counts":[1,0,0],"sum":0.1}}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id":"latency","type":"histogram","histogram":{"bounds":[0.5,1],"counts":[1,0,0],"sum":0.1}}`, body)
+
+	status, _ = post("/updates/", `[{"id":"latency","type":"histogram","histogram":{"bounds":[0.5,1],"counts":[0,1,1],"sum":2.9}}]`)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, body = post("/value/", `{"id":"latency","type":"histogram"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id":"latency","type":"histogram","histogram":{"bounds":[0.5,1],"counts":[1,1,1],"sum":3}}`, body)
+
+	status, _ = post("/update/", `{"id":"latency","type":"histogram","histogram":{"bounds":[2],"counts":[1,0],"sum":1}}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = post("/updates/", `[{"id":"latency","type":"histogram","histogram":{"bounds":[2],"counts":[1,0],"sum":1}}]`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = post("/update/", `{"id":"latency","type":"histogram","histogram":{"bounds":[1],"counts":[1],"sum":1}}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	resp, err := http.Get(server.URL + "/")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(resp.Body)
+	require.NoError(t, resp.Body.Close())
+	assert.Contains(t, string(data), "latency[histogram]=count=3 sum=3 le(0.5)=1 le(1)=2 le(+Inf)=3")
+}
+
+func TestMetricJSONHandlers_Summary(t *testing.T) {
+	store := NewMemStorage()
+	cfg := &config{}
+	server := httptest.NewServer(newTestRouter(store, cfg))
+	defer server.Close()
+
+	post := func(uri string, body string) (int, string) {
+		resp, err := http.Post(server.URL+uri, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Logf("Failed to close response body: %v", err)
+			}
+		}()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	status, body := post("/update/", `{"id":"latency","type":"summary","observations":[2]}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id":"latency","type":"summary","summary":{"count":1,"sum":2,"quantiles":{"0.5":2,"0.9":2,"0.99":2}}}`, body)
+
+	status, _ = post("/updates/", `[{"id":"latency","type":"summary","observations":[2,2,2]}]`)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, body = post("/value/", `{"id":"latency","type":"summary"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id":"latency","type":"summary","summary":{"count":4,"sum":8,"quantiles":{"0.5":2,"0.9":2,"0.99":2}}}`, body)
+
+	status, body = post("/value/", `{"id":"latency","type":"summary","quantile":0.75}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"id":"latency","type":"summary","value":2,"quantile":0.75,"summary":{"count":4,"sum":8,"quantiles":{"0.5":2,"0.9":2,"0.99":2}}}`, body)
+
+	status, _ = post("/value/", `{"id":"latency","type":"summary","quantile":1.5}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = post("/value/", `{"id":"Alloc","type":"gauge","quantile":0.5}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = post("/update/", `{"id":"latency","type":"summary","observations":[]}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	resp, err := http.Get(server.URL + "/")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(resp.Body)
+	require.NoError(t, resp.Body.Close())
+	assert.Contains(t, string(data), "latency[summary]=count=4 sum=8 p50=2 p90=2 p99=2")
+}
+
+func TestMetricDeleteHandler(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	_, err := store.SetGauge(ctx, "Alloc", 1)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(newTestRouter(store, &config{}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		uri      string
+		wantCode int
+	}{
+		{name: "delete gauge", uri: "/value/gauge/Alloc", wantCode: http.StatusOK},
+		{name: "already deleted", uri: "/value/gauge/Alloc", wantCode: http.StatusNotFound},
+		{name: "bad type", uri: "/value/unknown/Alloc", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodDelete, server.URL+tt.uri, nil)
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+
+	_, err = store.GetGauge(ctx, "Alloc")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestInfluxWriteHandler(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	server := httptest.NewServer(newTestRouter(store, &config{}))
+	defer server.Close()
+
+	post := func(t *testing.T, uri string, body io.Reader, gzipped bool) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, server.URL+uri, body)
+		require.NoError(t, err)
+		if gzipped {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Logf("Failed to close response body: %v", err)
+			}
+		}()
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("write", func(t *testing.T) {
+		code, _ := post(t, "/write?precision=s", strings.NewReader(
+			"# telegraf\ncpu,host=web1 usage_idle=93.5 1714557600\n\nrequests,metric_type=counter total=2i\nrequests,metric_type=counter total=3i\n",
+		), false)
+		assert.Equal(t, http.StatusNoContent, code)
+
+		idle, err := store.GetGauge(ctx, `cpu_usage_idle{host="web1"}`)
+		require.NoError(t, err)
+		assert.Equal(t, 93.5, idle)
+		total, err := store.GetCounter(ctx, "requests_total")
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+	})
+
+	t.Run("partial write", func(t *testing.T) {
+		code, body := post(t, "/write", strings.NewReader("mem used=1i\nmem used=\nmem free=2i\n"), false)
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		assert.Equal(t, "partial write: unable to parse 'mem used=': invalid field \"used\": missing value (line 2)", resp["error"])
+		_, err := store.GetGauge(ctx, "mem_free")
+		assert.NoError(t, err, "valid lines are written")
+	})
+
+	t.Run("nothing written", func(t *testing.T) {
+		code, body := post(t, "/write", strings.NewReader("mem\n"), false)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Contains(t, body, `"error":"unable to parse 'mem'`)
+	})
+
+	t.Run("bad precision", func(t *testing.T) {
+		code, _ := post(t, "/write?precision=d", strings.NewReader("mem used=1i\n"), false)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("large gzipped batch", func(t *testing.T) {
+		var buf strings.Builder
+		gz := gzip.NewWriter(&buf)
+		for i := range 10010 {
+			_, err := fmt.Fprintf(gz, "batch,n=%d value=%di\n", i, i)
+			require.NoError(t, err)
+		}
+		require.NoError(t, gz.Close())
+
+		code, _ := post(t, "/write", strings.NewReader(buf.String()), true)
+		assert.Equal(t, http.StatusNoContent, code)
+		value, err := store.GetGauge(ctx, `batch{n="10009"}`)
+		require.NoError(t, err)
+		assert.Equal(t, 10009.0, value)
+	})
+
+	t.Run("gzipped body over limit", func(t *testing.T) {
+		var buf strings.Builder
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte("bomb value=1i\n"))
+		require.NoError(t, err)
+		comment := []byte("#" + strings.Repeat("x", 1<<10) + "\n")
+		for written := 0; written <= influxMaxBodySize; written += len(comment) {
+			_, err := gz.Write(comment)
+			require.NoError(t, err)
+		}
+		require.NoError(t, gz.Close())
+		require.Less(t, buf.Len(), influxMaxBodySize/100)
+
+		code, _ := post(t, "/write", strings.NewReader(buf.String()), true)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		_, err = store.GetGauge(ctx, "bomb")
+		assert.ErrorIs(t, err, ErrNotFound, "nothing is written")
+	})
+}
+
+func TestInfluxWriteHandler_StorageErrorWritesNothing(t *testing.T) {
+	store := &failingBatchStorage{Storager: NewMemStorage()}
+	ctx := context.Background()
+	server := httptest.NewServer(newTestRouter(store, &config{}))
+	defer server.Close()
+
+	post := func(t *testing.T, body string) int {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/write", "text/plain", strings.NewReader(body))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+
+	// The first batch update succeeds, the second fails.
+	require.Equal(t, http.StatusNoContent, post(t, "requests,metric_type=counter value=1i\n"))
+	var body strings.Builder
+	for range 10010 {
+		body.WriteString("requests,metric_type=counter value=1i\n")
+	}
+	store.fail = true
+	assert.Equal(t, http.StatusInternalServerError, post(t, body.String()))
+	assert.Equal(t, 2, store.calls, "the body is written at once")
+	requests, err := store.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), requests, "nothing of the failed body is stored")
+
+	// So the retry of the client applies the increments once.
+	store.fail = false
+	assert.Equal(t, http.StatusNoContent, post(t, body.String()))
+	requests, err = store.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10011), requests)
+}
+
+func TestRemoteWriteHandler(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	server := httptest.NewServer(newTestRouter(store, &config{TrustedSubnet: "10.0.0.0/8"}))
+	defer server.Close()
+
+	post := func(t *testing.T, req *proto.RemoteWriteRequest, header map[string]string) (int, string) {
+		t.Helper()
+		data, err := protobuf.Marshal(req)
+		require.NoError(t, err)
+		httpReq, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/write", bytes.NewReader(snappyEncode(data)))
+		require.NoError(t, err)
+		httpReq.Header.Set("Content-Encoding", "snappy")
+		httpReq.Header.Set("Content-Type", "application/x-protobuf")
+		httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+		httpReq.Header.Set("X-Real-IP", "10.0.0.1")
+		for name, value := range header {
+			httpReq.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(httpReq)
+		require.NoError(t, err)
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Logf("Failed to close response body: %v", err)
+			}
+		}()
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(respBody)
+	}
+
+	t.Run("write", func(t *testing.T) {
+		code, _ := post(t, &proto.RemoteWriteRequest{Timeseries: []*proto.RemoteTimeSeries{
+			remoteSeries([]string{"__name__", "node_load1", "instance", "web1:9100"}, remoteSample(0.5, 1000), remoteSample(0.7, 2000)),
+			remoteSeries([]string{"__name__", "up"}, remoteSample(1, 1000)),
+		}}, nil)
+		assert.Equal(t, http.StatusNoContent, code)
+
+		load, err := store.GetGauge(ctx, `node_load1{instance="web1:9100"}`)
+		require.NoError(t, err)
+		assert.Equal(t, 0.7, load)
+		up, err := store.GetGauge(ctx, "up")
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, up)
+	})
+
+	t.Run("invalid series", func(t *testing.T) {
+		code, body := post(t, &proto.RemoteWriteRequest{Timeseries: []*proto.RemoteTimeSeries{
+			remoteSeries([]string{"job", "node"}, remoteSample(1, 1000)),
+			remoteSeries([]string{"__name__", "valid"}, remoteSample(1, 1000)),
+		}}, nil)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Contains(t, body, `invalid metric name ""`)
+		_, err := store.GetGauge(ctx, "valid")
+		assert.NoError(t, err, "valid series are written")
+	})
+
+	t.Run("not snappy", func(t *testing.T) {
+		code, _ := post(t, &proto.RemoteWriteRequest{}, map[string]string{"Content-Encoding": "zstd"})
+		assert.Equal(t, http.StatusUnsupportedMediaType, code)
+	})
+
+	t.Run("remote write 2.0", func(t *testing.T) {
+		code, _ := post(t, &proto.RemoteWriteRequest{}, map[string]string{
+			"Content-Type": "application/x-protobuf;proto=io.prometheus.write.v2.Request",
+		})
+		assert.Equal(t, http.StatusUnsupportedMediaType, code)
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		code, _ := post(t, &proto.RemoteWriteRequest{Timeseries: []*proto.RemoteTimeSeries{
+			remoteSeries([]string{"__name__", "untrusted"}, remoteSample(1, 1000)),
+		}}, map[string]string{"X-Real-IP": "192.168.0.1"})
+		assert.Equal(t, http.StatusForbidden, code)
+		_, err := store.GetGauge(ctx, "untrusted")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("corrupt body", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/write", strings.NewReader("not snappy"))
+		require.NoError(t, err)
+		req.Header.Set("Content-Encoding", "snappy")
+		req.Header.Set("X-Real-IP", "10.0.0.1")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -23,6 +23,19 @@ func (bmw *BaseMiddleware) HashMiddleware(hashKey string) func(http.Handler) htt
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 
+			// Deletes have no body, so the request path is signed instead
+			// and the signature is required as deletes cannot be undone.
+			if r.Method == http.MethodDelete && hashKey != "" && (r.Body == nil || r.ContentLength == 0) {
+				expectedHash := common.ComputeHash(hashKey, []byte(r.URL.Path))
+				if !common.CompareHashes(r.Header.Get(common.HashHeaderKey), expectedHash) {
+					selfMetrics.inc(selfHashFailures, nil)
+					http.Error(w, "Invalid request hash", http.StatusBadRequest)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			if r.Body == nil || r.ContentLength == 0 {
 				next.ServeHTTP(w, r)
 				return
@@ -48,6 +61,7 @@ func (bmw *BaseMiddleware) HashMiddleware(hashKey string) func(http.Handler) htt
 
 			expectedHash := common.ComputeHash(hashKey, body)
 			if !common.CompareHashes(requestHash, expectedHash) {
+				selfMetrics.inc(selfHashFailures, nil)
 				http.Error(w, "Invalid request hash", http.StatusBadRequest)
 				return
 			}
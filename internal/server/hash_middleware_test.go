@@ -63,6 +63,7 @@ func TestHashMiddleware_NoRequestHash(t *testing.T) {
 }
 
 func TestHashMiddleware_InvalidHash(t *testing.T) {
+	sm := newTestSelfMetrics(t)
 	bmw := BaseMiddleware{logger: zap.NewNop()}
 	middleware := bmw.HashMiddleware("secret")
 	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -78,6 +79,9 @@ func TestHashMiddleware_InvalidHash(t *testing.T) {
 	if rr.Code != http.StatusBadRequest {
 		t.Error("Expected 400 for invalid hash")
 	}
+	if sm.counter(selfHashFailures, nil) != 1 {
+		t.Error("Expected hash failure to be counted")
+	}
 }
 
 func TestHashMiddleware_ValidHash(t *testing.T) {
@@ -157,3 +161,36 @@ func TestHashMiddleware_EmptyResponse(t *testing.T) {
 		t.Error("Should not set hash header for empty response")
 	}
 }
+
+func TestHashMiddleware_Delete(t *testing.T) {
+	bmw := BaseMiddleware{logger: zap.NewNop()}
+	handler := bmw.HashMiddleware("secret")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		hash     string
+		wantCode int
+	}{
+		{name: "missing hash", hash: "", wantCode: http.StatusBadRequest},
+		{name: "invalid hash", hash: common.ComputeHash("secret", []byte("/value/gauge/Other")), wantCode: http.StatusBadRequest},
+		{name: "valid hash", hash: common.ComputeHash("secret", []byte("/value/gauge/Alloc")), wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/value/gauge/Alloc", nil)
+			if tt.hash != "" {
+				req.Header.Set(common.HashHeaderKey, tt.hash)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/proto"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Health tracks whether the server should receive traffic.
+//
+// The server is live as long as it answers. It is ready after SetServing
+// until SetShuttingDown, while the storage answers pings. Storages restore
+// dumps and apply migrations before they are returned, so SetServing is
+// called once the storage is created and the listeners are started.
+type Health struct {
+	store        Storager
+	serving      atomic.Bool
+	shuttingDown atomic.Bool
+	grpc         *health.Server
+}
+
+// healthServices are the gRPC services reported by the health service, the
+// empty name stands for the server as a whole.
+var healthServices = []string{"", proto.MetricsService_ServiceDesc.ServiceName}
+
+// NewHealth returns the health of a server backed by store, not ready until
+// SetServing is called.
+func NewHealth(store Storager) *Health {
+	h := &Health{
+		store: store,
+		grpc:  health.NewServer(),
+	}
+	h.setGRPCStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+func (h *Health) setGRPCStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, service := range healthServices {
+		h.grpc.SetServingStatus(service, status)
+	}
+}
+
+// SetServing marks the server ready to receive traffic.
+func (h *Health) SetServing() {
+	h.serving.Store(true)
+	h.setGRPCStatus(healthpb.HealthCheckResponse_SERVING)
+}
+
+// SetShuttingDown marks the server not ready for good, so load balancers
+// drain it while in-flight requests complete.
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+	h.grpc.Shutdown()
+}
+
+// Drain marks the server shutting down and waits for delay before
+// returning, so load balancers see the readiness checks fail while the
+// listeners still accept requests. The listeners are closed after it.
+func (h *Health) Drain(delay time.Duration) {
+	h.SetShuttingDown()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// Ready returns the reason the server is not ready, or nil.
+func (h *Health) Ready(ctx context.Context) error {
+	if h.shuttingDown.Load() {
+		return errShuttingDown
+	}
+	if !h.serving.Load() {
+		return errNotServing
+	}
+	return h.store.Ping(ctx)
+}
+
+// grpcHealthServer is the standard gRPC health service. Watch streams the
+// serving state, Check also pings the storage like the HTTP readiness check.
+type grpcHealthServer struct {
+	*health.Server
+	health *Health
+}
+
+func (s *grpcHealthServer) Check(ctx context.Context, in *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	resp, err := s.Server.Check(ctx, in)
+	if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return resp, err
+	}
+	if s.health.Ready(ctx) != nil {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return resp, nil
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// failingPingStorage is a storage that cannot be reached.
+type failingPingStorage struct {
+	Storager
+}
+
+func (s *failingPingStorage) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestHealth_Ready(t *testing.T) {
+	store := NewMemStorage()
+	health := NewHealth(store)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, health.Ready(ctx), errNotServing)
+
+	health.SetServing()
+	assert.NoError(t, health.Ready(ctx))
+
+	health.SetShuttingDown()
+	assert.ErrorIs(t, health.Ready(ctx), errShuttingDown)
+
+	health.SetServing()
+	assert.ErrorIs(t, health.Ready(ctx), errShuttingDown, "shutdown is final")
+
+	unreachable := NewHealth(&failingPingStorage{Storager: store})
+	unreachable.SetServing()
+	assert.Error(t, unreachable.Ready(ctx))
+}
+
+func TestRouter_HealthEndpoints(t *testing.T) {
+	store := NewMemStorage()
+	health := NewHealth(store)
+	// Probes are not signed and come without X-Real-IP.
+	cfg := &config{HashKey: "secret", TrustedSubnet: "10.0.0.0/8"}
+	server := httptest.NewServer(NewRouter(store, cfg, health, NewAlertEngine(store, nil)))
+	defer server.Close()
+
+	get := func(path string) int {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz"))
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz"))
+
+	health.SetServing()
+	assert.Equal(t, http.StatusOK, get("/readyz"))
+	assert.Equal(t, http.StatusForbidden, get("/ping"), "API is still limited to the trusted subnet")
+
+	health.SetShuttingDown()
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz"))
+	assert.Equal(t, http.StatusOK, get("/healthz"))
+}
+
+func TestHealth_Drain(t *testing.T) {
+	store := NewMemStorage()
+	health := NewHealth(store)
+	health.SetServing()
+	server := httptest.NewServer(NewRouter(store, &config{}, health, NewAlertEngine(store, nil)))
+	defer server.Close()
+
+	drained := make(chan struct{})
+	go func() {
+		health.Drain(300 * time.Millisecond)
+		close(drained)
+	}()
+
+	// Readiness fails while the listener still serves requests.
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(server.URL + "/readyz")
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+	select {
+	case <-drained:
+		t.Fatal("Drain returned before the delay")
+	default:
+	}
+	check, err := health.grpc.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check.GetStatus())
+	resp, err := http.Get(server.URL + "/value/gauge/missing")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "the API still answers")
+
+	<-drained
+}
+
+func TestGRPCHealthServer_Check(t *testing.T) {
+	store := NewMemStorage()
+	health := NewHealth(store)
+	server := &grpcHealthServer{Server: health.grpc, health: health}
+	ctx := context.Background()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := server.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.GetStatus()
+	}
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+
+	health.SetServing()
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(proto.MetricsService_ServiceDesc.ServiceName))
+
+	_, err := server.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown.Service"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	health.SetShuttingDown()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+
+	unreachable := NewHealth(&failingPingStorage{Storager: store})
+	unreachable.SetServing()
+	server = &grpcHealthServer{Server: unreachable.grpc, health: unreachable}
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+}
+
+func TestTrustedSubnetInterceptor_HealthCheck(t *testing.T) {
+	interceptor := TrustedSubnetInterceptor("10.0.0.0/8", zap.NewNop())
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+
+	resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: healthpb.Health_Check_FullMethodName}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/service.Method"}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
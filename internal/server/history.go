@@ -0,0 +1,276 @@
+package server
+
+import (
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+)
+
+// DefaultHistorySize holds the raw samples DefaultHistoryRetention keeps
+// for a metric reported every historyReportInterval.
+const DefaultHistorySize = 8640
+
+// historyReportInterval is the report interval memory history is sized
+// for, the default of the agent.
+const historyReportInterval = 10 * time.Second
+
+type metricKey struct {
+	mType string
+	id    string
+}
+
+// sampleRing is a ring buffer of metric samples ordered by time. It grows
+// up to its capacity as samples arrive, so rarely reported metrics do not
+// hold memory sized for a full retention period.
+type sampleRing struct {
+	samples  []models.MetricSample
+	capacity int
+	start    int
+	size     int
+}
+
+func newSampleRing(capacity int) *sampleRing {
+	return &sampleRing{capacity: capacity}
+}
+
+func (r *sampleRing) push(s models.MetricSample) {
+	if r.capacity <= 0 {
+		return
+	}
+	if r.size == len(r.samples) && len(r.samples) < r.capacity {
+		if r.start != 0 || len(r.samples) == cap(r.samples) {
+			// Copy in time order so the new sample goes last.
+			grown := make([]models.MetricSample, r.size, min(max(2*r.size, 8), r.capacity))
+			n := copy(grown, r.samples[r.start:])
+			copy(grown[n:], r.samples[:r.start])
+			r.samples, r.start = grown, 0
+		}
+		r.samples = append(r.samples, s)
+		r.size++
+		return
+	}
+	if r.size < len(r.samples) {
+		r.samples[(r.start+r.size)%len(r.samples)] = s
+		r.size++
+		return
+	}
+	r.samples[r.start] = s
+	r.start = (r.start + 1) % len(r.samples)
+}
+
+// dropBefore removes samples older than t.
+func (r *sampleRing) dropBefore(t time.Time) {
+	for r.size > 0 && r.samples[r.start].Timestamp.Before(t) {
+		r.samples[r.start] = models.MetricSample{}
+		r.start = (r.start + 1) % len(r.samples)
+		r.size--
+	}
+}
+
+func (r *sampleRing) rangeSamples(from, to time.Time) []models.MetricSample {
+	result := make([]models.MetricSample, 0)
+	for i := 0; i < r.size; i++ {
+		s := r.samples[(r.start+i)%len(r.samples)]
+		if s.Timestamp.Before(from) || s.Timestamp.After(to) {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// rollupSeries holds rollups of one resolution. Buckets starting before
+// next are complete and are not aggregated again.
+type rollupSeries struct {
+	resolution time.Duration
+	buckets    []models.MetricRollup
+	next       time.Time
+}
+
+// sampleToRollup converts a single sample into a rollup of one element,
+// so raw samples and rollups can be aggregated the same way.
+func sampleToRollup(mType string, s models.MetricSample) models.MetricRollup {
+	r := models.MetricRollup{Timestamp: s.Timestamp, Count: 1}
+	switch mType {
+	case common.MetricTypeGauge:
+		r.Min, r.Max, r.Avg, r.Last = s.Value, s.Value, s.Value, s.Value
+	case common.MetricTypeCounter:
+		var sum int64
+		if s.Increment != nil {
+			sum = *s.Increment
+		}
+		r.Sum = &sum
+	}
+	return r
+}
+
+func mergeRollup(dst *models.MetricRollup, src models.MetricRollup) {
+	if dst.Count == 0 {
+		ts := dst.Timestamp
+		*dst = src
+		dst.Timestamp = ts
+		return
+	}
+
+	if src.Min != nil && *src.Min < *dst.Min {
+		dst.Min = src.Min
+	}
+	if src.Max != nil && *src.Max > *dst.Max {
+		dst.Max = src.Max
+	}
+	if src.Avg != nil {
+		avg := (*dst.Avg*float64(dst.Count) + *src.Avg*float64(src.Count)) / float64(dst.Count+src.Count)
+		dst.Avg = &avg
+	}
+	if src.Last != nil {
+		dst.Last = src.Last
+	}
+	if src.Sum != nil {
+		sum := *dst.Sum + *src.Sum
+		dst.Sum = &sum
+	}
+	dst.Count += src.Count
+}
+
+// aggregateRollups groups time ordered items in [from, to) into buckets of resolution.
+func aggregateRollups(items []models.MetricRollup, resolution time.Duration, from, to time.Time) []models.MetricRollup {
+	var result []models.MetricRollup
+	for _, item := range items {
+		if item.Timestamp.Before(from) || !item.Timestamp.Before(to) {
+			continue
+		}
+		bucket := alignToResolution(item.Timestamp, resolution)
+		if len(result) == 0 || !result[len(result)-1].Timestamp.Equal(bucket) {
+			result = append(result, models.MetricRollup{Timestamp: bucket})
+		}
+		mergeRollup(&result[len(result)-1], item)
+	}
+	return result
+}
+
+// memHistory keeps a bounded ring buffer of samples and rollups per metric.
+// It is not safe for concurrent use, callers must synchronize access.
+type memHistory struct {
+	size    int
+	rings   map[metricKey]*sampleRing
+	rollups map[metricKey][]*rollupSeries
+}
+
+func newMemHistory(size int) *memHistory {
+	return &memHistory{
+		size:    size,
+		rings:   make(map[metricKey]*sampleRing),
+		rollups: make(map[metricKey][]*rollupSeries),
+	}
+}
+
+func (h *memHistory) record(mType string, id string, sample models.MetricSample) {
+	if h.size <= 0 {
+		return
+	}
+	key := metricKey{mType: mType, id: id}
+	ring, ok := h.rings[key]
+	if !ok {
+		ring = newSampleRing(h.size)
+		h.rings[key] = ring
+	}
+	ring.push(sample)
+}
+
+func (h *memHistory) get(mType string, id string, from, to time.Time) []models.MetricSample {
+	ring, ok := h.rings[metricKey{mType: mType, id: id}]
+	if !ok {
+		return []models.MetricSample{}
+	}
+	return ring.rangeSamples(from, to)
+}
+
+func (h *memHistory) getRollups(mType string, id string, resolution time.Duration, from, to time.Time) []models.MetricRollup {
+	result := []models.MetricRollup{}
+	for _, series := range h.rollups[metricKey{mType: mType, id: id}] {
+		if series.resolution != resolution {
+			continue
+		}
+		for _, b := range series.buckets {
+			if b.Timestamp.Before(from) || b.Timestamp.After(to) {
+				continue
+			}
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// delete removes samples and rollups of a metric.
+func (h *memHistory) delete(mType string, id string) {
+	key := metricKey{mType: mType, id: id}
+	delete(h.rings, key)
+	delete(h.rollups, key)
+}
+
+// compact builds rollups of complete buckets and drops data that is out of
+// the retention policy.
+func (h *memHistory) compact(policy *RetentionPolicy, now time.Time) {
+	keys := make(map[metricKey]struct{}, len(h.rings)+len(h.rollups))
+	for key := range h.rings {
+		keys[key] = struct{}{}
+	}
+	for key := range h.rollups {
+		keys[key] = struct{}{}
+	}
+
+	for key := range keys {
+		ring := h.rings[key]
+
+		series := h.rollups[key]
+		if len(series) != len(policy.Tiers) {
+			series = make([]*rollupSeries, len(policy.Tiers))
+			for i, tier := range policy.Tiers {
+				series[i] = &rollupSeries{resolution: tier.Resolution}
+			}
+		}
+
+		var source []models.MetricRollup
+		if ring != nil {
+			for _, s := range ring.rangeSamples(time.Time{}, now) {
+				source = append(source, sampleToRollup(key.mType, s))
+			}
+		}
+
+		empty := true
+		for i, tier := range policy.Tiers {
+			rs := series[i]
+			cutoff := alignToResolution(now, tier.Resolution)
+			if rs.next.Before(cutoff) {
+				rs.buckets = append(rs.buckets, aggregateRollups(source, tier.Resolution, rs.next, cutoff)...)
+				rs.next = cutoff
+			}
+			source = rs.buckets
+
+			expired := now.Add(-tier.Retention)
+			n := 0
+			for n < len(rs.buckets) && rs.buckets[n].Timestamp.Before(expired) {
+				n++
+			}
+			rs.buckets = rs.buckets[n:]
+			if len(rs.buckets) > 0 {
+				empty = false
+			}
+		}
+
+		if ring != nil {
+			ring.dropBefore(now.Add(-policy.Raw))
+			if ring.size > 0 {
+				empty = false
+			}
+		}
+
+		if empty {
+			delete(h.rings, key)
+			delete(h.rollups, key)
+			continue
+		}
+		h.rollups[key] = series
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleRing_Push(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ring := newSampleRing(3)
+
+	for i := 0; i < 5; i++ {
+		ring.push(models.NewMetricSample(base.Add(time.Duration(i)*time.Second), common.MetricTypeGauge, 0, float64(i)))
+	}
+
+	samples := ring.rangeSamples(time.Time{}, base.Add(time.Hour))
+	require.Len(t, samples, 3)
+	assert.Equal(t, 2.0, *samples[0].Value)
+	assert.Equal(t, 3.0, *samples[1].Value)
+	assert.Equal(t, 4.0, *samples[2].Value)
+}
+
+func TestSampleRing_GrowsAfterDrop(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ring := newSampleRing(20)
+	push := func(i int) {
+		ring.push(models.NewMetricSample(base.Add(time.Duration(i)*time.Second), common.MetricTypeGauge, 0, float64(i)))
+	}
+
+	for i := range 8 {
+		push(i)
+	}
+	assert.Len(t, ring.samples, 8, "the ring grows with its samples")
+
+	// Refill after a drop so the ring wraps, then grow past it.
+	ring.dropBefore(base.Add(3 * time.Second))
+	for i := 8; i < 25; i++ {
+		push(i)
+	}
+
+	samples := ring.rangeSamples(time.Time{}, base.Add(time.Hour))
+	require.Len(t, samples, 20)
+	for i, s := range samples {
+		assert.Equal(t, float64(i+5), *s.Value)
+	}
+	assert.LessOrEqual(t, cap(ring.samples), 20)
+}
+
+func TestSampleRing_RangeSamples(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ring := newSampleRing(10)
+
+	for i := 0; i < 5; i++ {
+		ring.push(models.NewMetricSample(base.Add(time.Duration(i)*time.Minute), common.MetricTypeCounter, int64(i), 0))
+	}
+
+	samples := ring.rangeSamples(base.Add(time.Minute), base.Add(3*time.Minute))
+	require.Len(t, samples, 3)
+	assert.Equal(t, int64(1), *samples[0].Delta)
+	assert.Equal(t, int64(3), *samples[2].Delta)
+}
+
+func TestMemHistory_Disabled(t *testing.T) {
+	h := newMemHistory(0)
+	h.record(common.MetricTypeGauge, "g", models.NewMetricSample(time.Now(), common.MetricTypeGauge, 0, 1))
+
+	assert.Empty(t, h.get(common.MetricTypeGauge, "g", time.Time{}, time.Now()))
+}
+
+func TestMemHistory_SeparatesTypes(t *testing.T) {
+	h := newMemHistory(10)
+	now := time.Now()
+	h.record(common.MetricTypeGauge, "m", models.NewMetricSample(now, common.MetricTypeGauge, 0, 1))
+	h.record(common.MetricTypeCounter, "m", models.NewMetricSample(now, common.MetricTypeCounter, 5, 0))
+
+	gauges := h.get(common.MetricTypeGauge, "m", time.Time{}, now)
+	require.Len(t, gauges, 1)
+	assert.Equal(t, 1.0, *gauges[0].Value)
+
+	counters := h.get(common.MetricTypeCounter, "m", time.Time{}, now)
+	require.Len(t, counters, 1)
+	assert.Equal(t, int64(5), *counters[0].Delta)
+}
+
+func sampleAt(ts time.Time, mType string, delta int64, value float64) models.MetricSample {
+	return models.NewMetricSample(ts, mType, delta, value)
+}
+
+func counterSampleAt(ts time.Time, increment int64) models.MetricSample {
+	return models.NewCounterSample(ts, 0, increment)
+}
+
+func TestAggregateRollups(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []models.MetricRollup{
+		sampleToRollup(common.MetricTypeGauge, sampleAt(base, common.MetricTypeGauge, 0, 1)),
+		sampleToRollup(common.MetricTypeGauge, sampleAt(base.Add(30*time.Second), common.MetricTypeGauge, 0, 5)),
+		sampleToRollup(common.MetricTypeGauge, sampleAt(base.Add(90*time.Second), common.MetricTypeGauge, 0, 2)),
+	}
+
+	rollups := aggregateRollups(items, time.Minute, time.Time{}, base.Add(2*time.Minute))
+	require.Len(t, rollups, 2)
+	assert.Equal(t, base, rollups[0].Timestamp.UTC())
+	assert.Equal(t, int64(2), rollups[0].Count)
+	assert.Equal(t, 3.0, *rollups[0].Avg)
+	assert.Equal(t, 5.0, *rollups[0].Last)
+	assert.Equal(t, base.Add(time.Minute), rollups[1].Timestamp.UTC())
+
+	rollups = aggregateRollups(items, time.Minute, base.Add(time.Minute), base.Add(time.Minute))
+	assert.Empty(t, rollups)
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+)
+
+const (
+	// influxMaxLineSize limits lines of line protocol requests.
+	influxMaxLineSize = 1 << 20
+	// influxMaxBodySize limits the decoded size of line protocol requests,
+	// which are held in memory until they are written.
+	influxMaxBodySize = 32 << 20
+	// influxMaxErrors is the number of line errors reported in a response.
+	influxMaxErrors = 100
+	// influxTypeTag is the tag that makes the fields of a point counters.
+	influxTypeTag = "metric_type"
+	// influxValueField is the field stored under the bare measurement name.
+	influxValueField = "value"
+)
+
+// influxPrecisions maps the precision query parameter to the unit of
+// timestamps.
+var influxPrecisions = map[string]time.Duration{
+	"":   time.Nanosecond,
+	"n":  time.Nanosecond,
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// influxField is a field of a point, strings are parsed but not stored.
+// Integer fields keep their exact value in intValue, float64 cannot hold
+// every int64.
+type influxField struct {
+	key      string
+	value    float64
+	intValue int64
+	integer  bool
+	str      bool
+}
+
+// influxPoint is a parsed line of InfluxDB line protocol:
+//
+//	measurement[,tag=value...] field=value[,field=value...] [timestamp]
+type influxPoint struct {
+	measurement string
+	tags        map[string]string
+	fields      []influxField
+	timestamp   int64
+	hasTime     bool
+}
+
+// parseInfluxLine parses a line of line protocol. Commas, spaces and equal
+// signs in names are escaped with a backslash, string field values are
+// double quoted.
+func parseInfluxLine(line string) (*influxPoint, error) {
+	p := &influxPoint{}
+
+	measurement, i := readInfluxToken(line, 0, ", ")
+	if measurement == "" {
+		return nil, errors.New("missing measurement")
+	}
+	p.measurement = measurement
+
+	for i < len(line) && line[i] == ',' {
+		var key, value string
+		key, i = readInfluxToken(line, i+1, "=, ")
+		if key == "" || i >= len(line) || line[i] != '=' {
+			return nil, errors.New("missing tag key")
+		}
+		value, i = readInfluxToken(line, i+1, ", ")
+		if value == "" {
+			return nil, fmt.Errorf("missing tag value for %q", key)
+		}
+		if p.tags == nil {
+			p.tags = make(map[string]string)
+		}
+		p.tags[key] = value
+	}
+
+	if i >= len(line) || line[i] != ' ' {
+		return nil, errors.New("missing fields")
+	}
+	for {
+		var key string
+		key, i = readInfluxToken(line, i+1, "=, ")
+		if key == "" || i >= len(line) || line[i] != '=' {
+			return nil, errors.New("missing field key")
+		}
+		field, next, err := parseInfluxFieldValue(line, i+1)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %w", key, err)
+		}
+		field.key = key
+		p.fields = append(p.fields, field)
+		i = next
+		if i >= len(line) || line[i] != ',' {
+			break
+		}
+	}
+
+	rest := strings.TrimSpace(line[i:])
+	if i < len(line) && line[i] != ' ' {
+		return nil, fmt.Errorf("unexpected %q after fields", line[i:])
+	}
+	if rest != "" {
+		ts, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", rest)
+		}
+		p.timestamp, p.hasTime = ts, true
+	}
+
+	return p, nil
+}
+
+// readInfluxToken reads an unquoted token from i up to the first unescaped
+// character of stops and returns it unescaped with the index of the stop.
+func readInfluxToken(line string, i int, stops string) (string, int) {
+	var b strings.Builder
+	for ; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' && i+1 < len(line) && strings.IndexByte(`,= \`, line[i+1]) >= 0 {
+			i++
+			b.WriteByte(line[i])
+			continue
+		}
+		if strings.IndexByte(stops, c) >= 0 {
+			break
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), i
+}
+
+// parseInfluxFieldValue parses the field value at i and returns the index
+// after it.
+func parseInfluxFieldValue(line string, i int) (influxField, int, error) {
+	if i < len(line) && line[i] == '"' {
+		for j := i + 1; j < len(line); j++ {
+			switch line[j] {
+			case '\\':
+				j++
+			case '"':
+				return influxField{str: true}, j + 1, nil
+			}
+		}
+		return influxField{}, 0, errors.New("unterminated string")
+	}
+
+	raw, next := readInfluxToken(line, i, ", ")
+	if raw == "" {
+		return influxField{}, 0, errors.New("missing value")
+	}
+
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return influxField{value: 1}, next, nil
+	case "f", "F", "false", "False", "FALSE":
+		return influxField{value: 0}, next, nil
+	}
+
+	switch raw[len(raw)-1] {
+	case 'i':
+		v, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return influxField{}, 0, fmt.Errorf("invalid integer %q", raw)
+		}
+		return influxField{value: float64(v), intValue: v, integer: true}, next, nil
+	case 'u':
+		v, err := strconv.ParseUint(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return influxField{}, 0, fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		if v > math.MaxInt64 {
+			return influxField{}, 0, fmt.Errorf("unsigned integer %q overflows int64", raw)
+		}
+		return influxField{value: float64(v), intValue: int64(v), integer: true}, next, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return influxField{}, 0, fmt.Errorf("invalid number %q", raw)
+	}
+	return influxField{value: v}, next, nil
+}
+
+// influxMetrics maps a point to metrics. Every numeric or boolean field
+// becomes a gauge named measurement_field, or measurement for the field
+// "value", with tags as labels. Points tagged metric_type=counter hold
+// counter increments instead, which must be integers. String fields are
+// skipped. The timestamp is checked but values are stored as current.
+func influxMetrics(p *influxPoint, precision time.Duration) ([]models.MetricModel, error) {
+	if p.hasTime {
+		if p.timestamp > math.MaxInt64/int64(precision) || p.timestamp < math.MinInt64/int64(precision) {
+			return nil, fmt.Errorf("timestamp %d out of range", p.timestamp)
+		}
+	}
+
+	mType := common.MetricTypeGauge
+	labels := make(map[string]string, len(p.tags))
+	for key, value := range p.tags {
+		if key == influxTypeTag {
+			mType = value
+			continue
+		}
+		labels[sanitizePrometheusLabelName(key)] = value
+	}
+	switch mType {
+	case common.MetricTypeGauge, common.MetricTypeCounter:
+	default:
+		return nil, fmt.Errorf("unsupported %s %q", influxTypeTag, mType)
+	}
+
+	metrics := make([]models.MetricModel, 0, len(p.fields))
+	for _, field := range p.fields {
+		if field.str {
+			continue
+		}
+		id := p.measurement
+		if field.key != influxValueField {
+			id += "_" + field.key
+		}
+		if err := models.ValidateID(id); err != nil {
+			return nil, err
+		}
+
+		var m *models.MetricModel
+		if mType == common.MetricTypeCounter {
+			if !field.integer {
+				return nil, fmt.Errorf("counter field %q is not an integer", field.key)
+			}
+			m = models.NewMetricModel(id, mType, field.intValue, 0)
+		} else {
+			m = models.NewMetricModel(id, mType, 0, field.value)
+		}
+		if len(labels) > 0 {
+			m.Labels = labels
+		}
+		metrics = append(metrics, *m)
+	}
+	return metrics, nil
+}
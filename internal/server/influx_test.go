@@ -0,0 +1,126 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInfluxLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    *influxPoint
+		wantErr bool
+	}{
+		{
+			name: "fields and timestamp",
+			line: "cpu usage_idle=93.5,cores=8i 1714557600000000000",
+			want: &influxPoint{
+				measurement: "cpu",
+				fields: []influxField{
+					{key: "usage_idle", value: 93.5},
+					{key: "cores", value: 8, intValue: 8, integer: true},
+				},
+				timestamp: 1714557600000000000,
+				hasTime:   true,
+			},
+		},
+		{
+			name: "tags",
+			line: "cpu,host=web1,cpu=cpu0 usage_idle=93.5",
+			want: &influxPoint{
+				measurement: "cpu",
+				tags:        map[string]string{"host": "web1", "cpu": "cpu0"},
+				fields:      []influxField{{key: "usage_idle", value: 93.5}},
+			},
+		},
+		{
+			name: "escapes",
+			line: `disk\ io,path=C:\\\ data\,x free=1u`,
+			want: &influxPoint{
+				measurement: "disk io",
+				tags:        map[string]string{"path": `C:\ data,x`},
+				fields:      []influxField{{key: "free", value: 1, intValue: 1, integer: true}},
+			},
+		},
+		{
+			name: "strings and booleans",
+			line: `system uptime_format="1 day, 2:03",up=true,load=0.5`,
+			want: &influxPoint{
+				measurement: "system",
+				fields: []influxField{
+					{key: "uptime_format", str: true},
+					{key: "up", value: 1},
+					{key: "load", value: 0.5},
+				},
+			},
+		},
+		{name: "no fields", line: "cpu", wantErr: true},
+		{name: "no field value", line: "cpu usage=", wantErr: true},
+		{name: "bad number", line: "cpu usage=abc", wantErr: true},
+		{name: "bad integer", line: "cpu usage=1.5i", wantErr: true},
+		{name: "unsigned overflow", line: "cpu usage=18446744073709551615u", wantErr: true},
+		{name: "unterminated string", line: `cpu msg="oops`, wantErr: true},
+		{name: "bad timestamp", line: "cpu usage=1 yesterday", wantErr: true},
+		{name: "empty tag value", line: "cpu,host= usage=1", wantErr: true},
+		{name: "no measurement", line: ",host=a usage=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			point, err := parseInfluxLine(tt.line)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, point)
+		})
+	}
+}
+
+func TestInfluxMetrics(t *testing.T) {
+	point, err := parseInfluxLine(`mem,host=web1,data-center=eu used=512i,value=0.5,name="x"`)
+	require.NoError(t, err)
+	metrics, err := influxMetrics(point, time.Nanosecond)
+	require.NoError(t, err)
+
+	labels := map[string]string{"host": "web1", "data_center": "eu"}
+	used := models.NewMetricModel("mem_used", common.MetricTypeGauge, 0, 512)
+	used.Labels = labels
+	value := models.NewMetricModel("mem", common.MetricTypeGauge, 0, 0.5)
+	value.Labels = labels
+	assert.Equal(t, []models.MetricModel{*used, *value}, metrics)
+
+	point, err = parseInfluxLine("requests,metric_type=counter total=3i")
+	require.NoError(t, err)
+	metrics, err = influxMetrics(point, time.Nanosecond)
+	require.NoError(t, err)
+	assert.Equal(t, []models.MetricModel{*models.NewMetricModel("requests_total", common.MetricTypeCounter, 3, 0)}, metrics)
+
+	// Integers beyond the precision of float64 are kept exact.
+	point, err = parseInfluxLine("requests,metric_type=counter value=9007199254740993i,max=9223372036854775807u")
+	require.NoError(t, err)
+	metrics, err = influxMetrics(point, time.Nanosecond)
+	require.NoError(t, err)
+	assert.Equal(t, []models.MetricModel{
+		*models.NewMetricModel("requests", common.MetricTypeCounter, 9007199254740993, 0),
+		*models.NewMetricModel("requests_max", common.MetricTypeCounter, 9223372036854775807, 0),
+	}, metrics)
+
+	for _, line := range []string{
+		"requests,metric_type=counter total=3.5",
+		"requests,metric_type=histogram total=3i",
+		"requests usage=1 9223372036854775807",
+	} {
+		point, err := parseInfluxLine(line)
+		require.NoError(t, err)
+		_, err = influxMetrics(point, time.Second)
+		assert.Error(t, err, line)
+	}
+}
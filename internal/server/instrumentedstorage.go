@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/models"
+)
+
+// InstrumentedStorage is a Storager decorator that records latencies and
+// errors of storage operations and sizes of batches to the self metrics.
+type InstrumentedStorage struct {
+	store Storager
+}
+
+// NewInstrumentedStorage wraps store with self instrumentation.
+func NewInstrumentedStorage(store Storager) *InstrumentedStorage {
+	return &InstrumentedStorage{store: store}
+}
+
+// instrumented runs a storage operation and records its duration. Missing
+// metrics are an expected result of reads and are not counted as errors.
+func instrumented[T any](operation string, call func() (T, error)) (T, error) {
+	labels := map[string]string{selfLabelStorageOperation: operation}
+	start := time.Now()
+
+	value, err := call()
+	selfMetrics.observeSince(selfStorageDuration, labels, start)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		selfMetrics.inc(selfStorageErrors, labels)
+	}
+	return value, err
+}
+
+// instrumentedNoValue is instrumented for operations that return only an error.
+func instrumentedNoValue(operation string, call func() error) error {
+	_, err := instrumented(operation, func() (struct{}, error) {
+		return struct{}{}, call()
+	})
+	return err
+}
+
+func (is *InstrumentedStorage) GetGauge(ctx context.Context, key string) (float64, error) {
+	return instrumented("GetGauge", func() (float64, error) {
+		return is.store.GetGauge(ctx, key)
+	})
+}
+
+func (is *InstrumentedStorage) SetGauge(ctx context.Context, key string, value float64) (float64, error) {
+	return instrumented("SetGauge", func() (float64, error) {
+		return is.store.SetGauge(ctx, key, value)
+	})
+}
+
+func (is *InstrumentedStorage) GetCounter(ctx context.Context, key string) (int64, error) {
+	return instrumented("GetCounter", func() (int64, error) {
+		return is.store.GetCounter(ctx, key)
+	})
+}
+
+func (is *InstrumentedStorage) IncrementCounter(ctx context.Context, key string, value int64) (int64, error) {
+	return instrumented("IncrementCounter", func() (int64, error) {
+		return is.store.IncrementCounter(ctx, key, value)
+	})
+}
+
+func (is *InstrumentedStorage) GetHistogram(ctx context.Context, key string) (*models.Histogram, error) {
+	return instrumented("GetHistogram", func() (*models.Histogram, error) {
+		return is.store.GetHistogram(ctx, key)
+	})
+}
+
+func (is *InstrumentedStorage) UpdateHistogram(ctx context.Context, key string, h *models.Histogram) (*models.Histogram, error) {
+	return instrumented("UpdateHistogram", func() (*models.Histogram, error) {
+		return is.store.UpdateHistogram(ctx, key, h)
+	})
+}
+
+func (is *InstrumentedStorage) GetSummary(ctx context.Context, key string) (*models.QuantileSketch, error) {
+	return instrumented("GetSummary", func() (*models.QuantileSketch, error) {
+		return is.store.GetSummary(ctx, key)
+	})
+}
+
+func (is *InstrumentedStorage) UpdateSummary(ctx context.Context, key string, observations []float64) (*models.QuantileSketch, error) {
+	return instrumented("UpdateSummary", func() (*models.QuantileSketch, error) {
+		return is.store.UpdateSummary(ctx, key, observations)
+	})
+}
+
+func (is *InstrumentedStorage) GetAll(ctx context.Context) ([]models.MetricModel, error) {
+	return instrumented("GetAll", func() ([]models.MetricModel, error) {
+		return is.store.GetAll(ctx)
+	})
+}
+
+func (is *InstrumentedStorage) DeleteMetric(ctx context.Context, mType string, key string) error {
+	return instrumentedNoValue("DeleteMetric", func() error {
+		return is.store.DeleteMetric(ctx, mType, key)
+	})
+}
+
+func (is *InstrumentedStorage) ResetCounter(ctx context.Context, key string) error {
+	return instrumentedNoValue("ResetCounter", func() error {
+		return is.store.ResetCounter(ctx, key)
+	})
+}
+
+// BatchUpdate also records the number of metrics in the batch.
+func (is *InstrumentedStorage) BatchUpdate(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error) {
+	selfMetrics.observe(selfBatchSize, nil, batchSizeBounds, float64(len(metrics)))
+	return instrumented("BatchUpdate", func() ([]models.MetricModel, error) {
+		return is.store.BatchUpdate(ctx, metrics)
+	})
+}
+
+func (is *InstrumentedStorage) GetHistory(ctx context.Context, mType string, key string, from, to time.Time) ([]models.MetricSample, error) {
+	historyReader, ok := is.store.(HistoryReader)
+	if !ok {
+		return nil, errHistoryNotSupported
+	}
+	return instrumented("GetHistory", func() ([]models.MetricSample, error) {
+		return historyReader.GetHistory(ctx, mType, key, from, to)
+	})
+}
+
+func (is *InstrumentedStorage) GetRollups(ctx context.Context, mType string, key string, resolution time.Duration, from, to time.Time) ([]models.MetricRollup, error) {
+	rollupReader, ok := is.store.(RollupReader)
+	if !ok {
+		return nil, errRollupsNotSupported
+	}
+	return instrumented("GetRollups", func() ([]models.MetricRollup, error) {
+		return rollupReader.GetRollups(ctx, mType, key, resolution, from, to)
+	})
+}
+
+func (is *InstrumentedStorage) GetSilences(ctx context.Context) ([]models.Silence, error) {
+	silenceStorer, ok := is.store.(SilenceStorer)
+	if !ok {
+		return nil, errSilencesNotSupported
+	}
+	return instrumented("GetSilences", func() ([]models.Silence, error) {
+		return silenceStorer.GetSilences(ctx)
+	})
+}
+
+func (is *InstrumentedStorage) SaveSilence(ctx context.Context, s models.Silence) error {
+	silenceStorer, ok := is.store.(SilenceStorer)
+	if !ok {
+		return errSilencesNotSupported
+	}
+	return instrumentedNoValue("SaveSilence", func() error {
+		return silenceStorer.SaveSilence(ctx, s)
+	})
+}
+
+func (is *InstrumentedStorage) DeleteSilence(ctx context.Context, id string) error {
+	silenceStorer, ok := is.store.(SilenceStorer)
+	if !ok {
+		return errSilencesNotSupported
+	}
+	return instrumentedNoValue("DeleteSilence", func() error {
+		return silenceStorer.DeleteSilence(ctx, id)
+	})
+}
+
+// SelfMetrics reports metrics of the wrapped storage.
+func (is *InstrumentedStorage) SelfMetrics() []models.MetricModel {
+	if reporter, ok := is.store.(SelfMetricsReporter); ok {
+		return reporter.SelfMetrics()
+	}
+	return nil
+}
+
+func (is *InstrumentedStorage) Ping(ctx context.Context) error {
+	return instrumentedNoValue("Ping", func() error {
+		return is.store.Ping(ctx)
+	})
+}
+
+func (is *InstrumentedStorage) ShutDown() {
+	is.store.ShutDown()
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"time"
 
 	"github.com/etoneja/go-metrics/internal/models"
 )
@@ -11,9 +12,55 @@ type Storager interface {
 	SetGauge(ctx context.Context, key string, value float64) (float64, error)
 	GetCounter(ctx context.Context, key string) (int64, error)
 	IncrementCounter(ctx context.Context, key string, value int64) (int64, error)
+	GetHistogram(ctx context.Context, key string) (*models.Histogram, error)
+	UpdateHistogram(ctx context.Context, key string, h *models.Histogram) (*models.Histogram, error)
+	GetSummary(ctx context.Context, key string) (*models.QuantileSketch, error)
+	UpdateSummary(ctx context.Context, key string, observations []float64) (*models.QuantileSketch, error)
 	GetAll(ctx context.Context) ([]models.MetricModel, error)
+	DeleteMetric(ctx context.Context, mType string, key string) error
+	ResetCounter(ctx context.Context, key string) error
 
+	// BatchUpdate applies all metrics or none of them. It returns the stored
+	// state of every metric in request order, for counters the accumulated
+	// value rather than the delta.
 	BatchUpdate(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error)
 	Ping(ctx context.Context) error
 	ShutDown()
 }
+
+// HistoryReader is implemented by storages that keep timestamped samples of metrics.
+type HistoryReader interface {
+	GetHistory(ctx context.Context, mType string, key string, from, to time.Time) ([]models.MetricSample, error)
+}
+
+// RollupReader is implemented by storages that keep downsampled history.
+type RollupReader interface {
+	GetRollups(ctx context.Context, mType string, key string, resolution time.Duration, from, to time.Time) ([]models.MetricRollup, error)
+}
+
+// HistoryCompactor is implemented by storages that can build rollups and
+// remove expired history according to a retention policy.
+type HistoryCompactor interface {
+	CompactHistory(ctx context.Context, policy *RetentionPolicy, now time.Time) error
+}
+
+// MetricExpirer is implemented by storages that can evict metrics which
+// were not updated since a given time.
+type MetricExpirer interface {
+	ExpireMetrics(ctx context.Context, before time.Time) (int, error)
+}
+
+// SelfMetricsReporter is implemented by storages that report metrics about
+// themselves, e.g. connection pool usage. They are exposed next to stored
+// metrics.
+type SelfMetricsReporter interface {
+	SelfMetrics() []models.MetricModel
+}
+
+// SilenceStorer is implemented by storages that persist alert silences.
+// DeleteSilence returns ErrNotFound for unknown IDs.
+type SilenceStorer interface {
+	GetSilences(ctx context.Context) ([]models.Silence, error)
+	SaveSilence(ctx context.Context, s models.Silence) error
+	DeleteSilence(ctx context.Context, id string) error
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+)
+
+// memShardCount is the number of shards of MemStorage. Metrics are spread
+// over shards by series key, so writes of different metrics rarely wait for
+// each other.
+const memShardCount = 64
+
+// memShard holds the metrics whose series keys hash to it. All metric types
+// of a series key live in the same shard.
+type memShard struct {
+	mu sync.RWMutex
+
+	gauge     map[string]float64
+	counter   map[string]int64
+	histogram map[string]*models.Histogram
+	summary   map[string]*models.QuantileSketch
+	updated   map[metricKey]time.Time
+	history   *memHistory
+}
+
+func newMemShard(historySize int) *memShard {
+	return &memShard{
+		gauge:     make(map[string]float64),
+		counter:   make(map[string]int64),
+		histogram: make(map[string]*models.Histogram),
+		summary:   make(map[string]*models.QuantileSketch),
+		updated:   make(map[metricKey]time.Time),
+		history:   newMemHistory(historySize),
+	}
+}
+
+func memShardIndex(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % memShardCount)
+}
+
+// len returns the number of metrics in the shard.
+func (s *memShard) len() int {
+	return len(s.gauge) + len(s.counter) + len(s.histogram) + len(s.summary)
+}
+
+// stateModel returns the stored state of a metric as a model that
+// restoreMetric accepts.
+func (s *memShard) stateModel(mType string, key string) models.MetricModel {
+	switch mType {
+	case common.MetricTypeGauge:
+		return *newSeriesMetricModel(key, mType, 0, s.gauge[key])
+	case common.MetricTypeCounter:
+		return *newSeriesMetricModel(key, mType, s.counter[key], 0)
+	case common.MetricTypeHistogram:
+		return *newSeriesHistogramModel(key, s.histogram[key])
+	default:
+		return *newSeriesSummaryModel(key, s.summary[key])
+	}
+}
+
+// restoreMetric sets the stored state of a metric from a dumped model.
+func (s *memShard) restoreMetric(m models.MetricModel, key string, updated time.Time) {
+	switch m.MType {
+	case common.MetricTypeGauge:
+		s.gauge[key] = *m.Value
+	case common.MetricTypeCounter:
+		s.counter[key] = *m.Delta
+	case common.MetricTypeHistogram:
+		s.histogram[key] = m.Histogram
+	case common.MetricTypeSummary:
+		s.summary[key] = mergeSummary(nil, m.Observations, m.Sketch)
+	}
+	s.updated[metricKey{mType: m.MType, id: key}] = updated
+}
+
+// deleteMetric removes a metric and its last update time.
+func (s *memShard) deleteMetric(mk metricKey) {
+	switch mk.mType {
+	case common.MetricTypeGauge:
+		delete(s.gauge, mk.id)
+	case common.MetricTypeCounter:
+		delete(s.counter, mk.id)
+	case common.MetricTypeHistogram:
+		delete(s.histogram, mk.id)
+	case common.MetricTypeSummary:
+		delete(s.summary, mk.id)
+	}
+	delete(s.updated, mk)
+}
+
+// memUndo is the state of a metric before a write, enough to restore it.
+type memUndo struct {
+	shard      *memShard
+	key        metricKey
+	exists     bool
+	gauge      float64
+	counter    int64
+	histogram  *models.Histogram
+	summary    *models.QuantileSketch
+	updated    time.Time
+	hasUpdated bool
+}
+
+// save returns the current state of a metric. Stored histograms and
+// sketches are never modified in place, so keeping the pointers is enough.
+func (s *memShard) save(mType string, key string) memUndo {
+	u := memUndo{shard: s, key: metricKey{mType: mType, id: key}}
+	switch mType {
+	case common.MetricTypeGauge:
+		u.gauge, u.exists = s.gauge[key]
+	case common.MetricTypeCounter:
+		u.counter, u.exists = s.counter[key]
+	case common.MetricTypeHistogram:
+		u.histogram, u.exists = s.histogram[key]
+	case common.MetricTypeSummary:
+		u.summary, u.exists = s.summary[key]
+	}
+	u.updated, u.hasUpdated = s.updated[u.key]
+	return u
+}
+
+func (u *memUndo) restore() {
+	s := u.shard
+	key := u.key.id
+	switch u.key.mType {
+	case common.MetricTypeGauge:
+		if u.exists {
+			s.gauge[key] = u.gauge
+		} else {
+			delete(s.gauge, key)
+		}
+	case common.MetricTypeCounter:
+		if u.exists {
+			s.counter[key] = u.counter
+		} else {
+			delete(s.counter, key)
+		}
+	case common.MetricTypeHistogram:
+		if u.exists {
+			s.histogram[key] = u.histogram
+		} else {
+			delete(s.histogram, key)
+		}
+	case common.MetricTypeSummary:
+		if u.exists {
+			s.summary[key] = u.summary
+		} else {
+			delete(s.summary, key)
+		}
+	}
+	if u.hasUpdated {
+		s.updated[u.key] = u.updated
+	} else {
+		delete(s.updated, u.key)
+	}
+}
+
+// memUndoLog records the previous state of the metrics touched by a write,
+// so a failed write is rolled back without copying whole maps.
+type memUndoLog []memUndo
+
+func (l *memUndoLog) save(s *memShard, mType string, key string) {
+	*l = append(*l, s.save(mType, key))
+}
+
+// rollback restores the saved states in reverse order, so a metric touched
+// several times ends up in its state before the first write.
+func (l memUndoLog) rollback() {
+	for i := len(l) - 1; i >= 0; i-- {
+		l[i].restore()
+	}
+}
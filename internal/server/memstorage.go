@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,35 +11,51 @@ import (
 	"sync/atomic"
 	"time"
 
-	"maps"
-
 	"github.com/etoneja/go-metrics/internal/common"
 	"github.com/etoneja/go-metrics/internal/logger"
 	"github.com/etoneja/go-metrics/internal/models"
 	"go.uber.org/zap"
 )
 
+// MemStorage keeps metrics in memory, spread over memShardCount shards.
+//
+// Writes of a metric hold mu for reading and the lock of its shard, so
+// writes to different shards run in parallel. Operations over all metrics
+// (dumps, expiry, restore) hold mu for writing. In sync mode every write
+// dumps the whole storage, so writes hold mu for writing too. Silences are
+// few and rarely change, they are guarded by mu alone.
 type MemStorage struct {
-	mu *sync.RWMutex
+	mu       *sync.RWMutex
+	shards   [memShardCount]*memShard
+	silences map[string]models.Silence
 
 	filePath           string
 	syncDump           bool
+	wal                *wal
 	dumpInProgress     atomic.Bool
 	shutdownInProgress atomic.Bool
 	stopChan           chan struct{}
 	doneChan           chan struct{}
 
-	gauge   map[string]float64
-	counter map[string]int64
+	ttl       time.Duration
+	compactor *historyCompactor
+	sweeper   *metricSweeper
 }
 
 func NewMemStorage() *MemStorage {
-	return &MemStorage{
+	ms := &MemStorage{
 		mu:       &sync.RWMutex{},
+		silences: make(map[string]models.Silence),
 		stopChan: make(chan struct{}),
 		doneChan: make(chan struct{}),
-		gauge:    make(map[string]float64),
-		counter:  make(map[string]int64),
+	}
+	ms.initShards(DefaultHistorySize)
+	return ms
+}
+
+func (ms *MemStorage) initShards(historySize int) {
+	for i := range ms.shards {
+		ms.shards[i] = newMemShard(historySize)
 	}
 }
 
@@ -46,13 +63,33 @@ type StorageConfig struct {
 	StoreInterval   uint
 	FileStoragePath string
 	Restore         bool
+	HistorySize     uint
+	Retention       *RetentionPolicy
+	CompactInterval uint
+	MetricTTL       time.Duration
+	WALPath         string
 }
 
-func NewMemStorageFromStorageConfig(sc *StorageConfig) *MemStorage {
+func NewMemStorageFromStorageConfig(sc *StorageConfig) (*MemStorage, error) {
 	ms := NewMemStorage()
 
 	ms.syncDump = sc.StoreInterval == 0
 	ms.filePath = sc.FileStoragePath
+	ms.initShards(int(sc.HistorySize))
+	ms.ttl = sc.MetricTTL
+
+	storeInterval := sc.StoreInterval
+	if sc.WALPath != "" {
+		w, err := openWAL(sc.WALPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open WAL: %w", err)
+		}
+		ms.wal = w
+		ms.syncDump = false
+		if storeInterval == 0 {
+			storeInterval = walDumpInterval
+		}
+	}
 
 	if sc.Restore {
 		err := ms.load()
@@ -61,22 +98,122 @@ func NewMemStorageFromStorageConfig(sc *StorageConfig) *MemStorage {
 		}
 	}
 
+	if ms.wal != nil {
+		ms.initWAL(sc.Restore)
+	}
+
 	if !ms.syncDump {
-		ms.startPeriodicDump(sc.StoreInterval)
+		ms.startPeriodicDump(storeInterval)
 	}
 
-	return ms
+	if sc.Retention != nil && sc.CompactInterval > 0 {
+		ms.compactor = startHistoryCompactor(ms, sc.Retention, sc.CompactInterval)
+	}
+
+	if ms.ttl > 0 {
+		ms.sweeper = startMetricSweeper(ms, ms.ttl)
+	}
+
+	return ms, nil
 }
 
-func (ms *MemStorage) GetGauge(ctx context.Context, key string) (float64, error) {
+func (ms *MemStorage) shard(key string) *memShard {
+	return ms.shards[memShardIndex(key)]
+}
+
+// rlockKey locks the shard of key for reading.
+func (ms *MemStorage) rlockKey(key string) (*memShard, func()) {
 	ms.mu.RLock()
-	defer ms.mu.RUnlock()
+	s := ms.shard(key)
+	s.mu.RLock()
+	return s, func() {
+		s.mu.RUnlock()
+		ms.mu.RUnlock()
+	}
+}
+
+// lockKey locks the shard of key for a write.
+func (ms *MemStorage) lockKey(key string) (*memShard, func()) {
+	if ms.syncDump {
+		ms.mu.Lock()
+		return ms.shard(key), ms.mu.Unlock
+	}
+
+	ms.mu.RLock()
+	s := ms.shard(key)
+	s.mu.Lock()
+	return s, func() {
+		s.mu.Unlock()
+		ms.mu.RUnlock()
+	}
+}
+
+// lockKeys locks the shards of all keys for a write. Shards are locked in
+// index order, so concurrent batches cannot deadlock.
+func (ms *MemStorage) lockKeys(keys []string) func() {
+	if ms.syncDump {
+		ms.mu.Lock()
+		return ms.mu.Unlock
+	}
+
+	var locked [memShardCount]bool
+	for _, key := range keys {
+		locked[memShardIndex(key)] = true
+	}
+
+	ms.mu.RLock()
+	for i, s := range ms.shards {
+		if locked[i] {
+			s.mu.Lock()
+		}
+	}
+	return func() {
+		for i, s := range ms.shards {
+			if locked[i] {
+				s.mu.Unlock()
+			}
+		}
+		ms.mu.RUnlock()
+	}
+}
+
+// rlockAll locks every shard for reading, which gives a consistent view of
+// the storage while writers wait.
+func (ms *MemStorage) rlockAll() func() {
+	ms.mu.RLock()
+	for _, s := range ms.shards {
+		s.mu.RLock()
+	}
+	return func() {
+		for _, s := range ms.shards {
+			s.mu.RUnlock()
+		}
+		ms.mu.RUnlock()
+	}
+}
+
+// persist makes a write durable before it is acknowledged. The write is
+// appended to the WAL if there is one, otherwise in sync mode the whole
+// storage is dumped.
+func (ms *MemStorage) persist(rec *walRecord) error {
+	if ms.wal != nil {
+		return ms.wal.append(rec)
+	}
+	if ms.syncDump {
+		return ms.dump()
+	}
+	return nil
+}
+
+func (ms *MemStorage) GetGauge(ctx context.Context, key string) (float64, error) {
+	s, unlock := ms.rlockKey(key)
+	defer unlock()
 
 	if err := ctx.Err(); err != nil {
 		return 0, err
 	}
 
-	val, ok := ms.gauge[key]
+	val, ok := s.gauge[key]
 	if !ok {
 		return 0, fmt.Errorf("%s %s: %w", common.MetricTypeGauge, key, ErrNotFound)
 	}
@@ -84,41 +221,38 @@ func (ms *MemStorage) GetGauge(ctx context.Context, key string) (float64, error)
 }
 
 func (ms *MemStorage) SetGauge(ctx context.Context, key string, value float64) (float64, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
+	s, unlock := ms.lockKey(key)
+	defer unlock()
 
 	if err := ctx.Err(); err != nil {
 		return 0, err
 	}
 
-	prevValue, ok := ms.gauge[key]
+	now := time.Now()
+	undo := s.save(common.MetricTypeGauge, key)
+	s.gauge[key] = value
+	s.updated[undo.key] = now
 
-	ms.gauge[key] = value
-
-	if ms.syncDump {
-		err := ms.dump()
-		if err != nil {
-			if ok {
-				ms.gauge[key] = prevValue
-			} else {
-				delete(ms.gauge, key)
-			}
-			return 0, err
-		}
+	err := ms.persist(newWALUpdate(now, *newSeriesMetricModel(key, common.MetricTypeGauge, 0, value)))
+	if err != nil {
+		undo.restore()
+		return 0, err
 	}
 
+	s.history.record(common.MetricTypeGauge, key, models.NewMetricSample(now, common.MetricTypeGauge, 0, value))
+
 	return value, nil
 }
 
 func (ms *MemStorage) GetCounter(ctx context.Context, key string) (int64, error) {
-	ms.mu.RLock()
-	defer ms.mu.RUnlock()
+	s, unlock := ms.rlockKey(key)
+	defer unlock()
 
 	if err := ctx.Err(); err != nil {
 		return 0, err
 	}
 
-	val, ok := ms.counter[key]
+	val, ok := s.counter[key]
 	if !ok {
 		return 0, fmt.Errorf("%s %s: %w", common.MetricTypeCounter, key, ErrNotFound)
 	}
@@ -126,38 +260,178 @@ func (ms *MemStorage) GetCounter(ctx context.Context, key string) (int64, error)
 }
 
 func (ms *MemStorage) IncrementCounter(ctx context.Context, key string, value int64) (int64, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
+	s, unlock := ms.lockKey(key)
+	defer unlock()
 
 	if err := ctx.Err(); err != nil {
 		return 0, err
 	}
 
-	val, ok := ms.counter[key]
+	now := time.Now()
+	undo := s.save(common.MetricTypeCounter, key)
+	increment := value
+	value += undo.counter
+	s.counter[key] = value
+	s.updated[undo.key] = now
 
-	if ok {
-		value += val
+	err := ms.persist(newWALUpdate(now, *newSeriesMetricModel(key, common.MetricTypeCounter, value, 0)))
+	if err != nil {
+		undo.restore()
+		return 0, err
 	}
-	ms.counter[key] = value
 
-	if ms.syncDump {
-		err := ms.dump()
-		if err != nil {
-			if ok {
-				ms.counter[key] = val
-			} else {
-				delete(ms.counter, key)
-			}
-			return 0, err
-		}
-	}
+	s.history.record(common.MetricTypeCounter, key, models.NewCounterSample(now, value, increment))
 
 	return value, nil
 }
 
+func (ms *MemStorage) GetHistogram(ctx context.Context, key string) (*models.Histogram, error) {
+	s, unlock := ms.rlockKey(key)
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	val, ok := s.histogram[key]
+	if !ok {
+		return nil, fmt.Errorf("%s %s: %w", common.MetricTypeHistogram, key, ErrNotFound)
+	}
+	return val.Clone(), nil
+}
+
+// UpdateHistogram merges h into the stored histogram and returns the result.
+// Stored histograms are never modified in place, so readers can share them.
+func (ms *MemStorage) UpdateHistogram(ctx context.Context, key string, h *models.Histogram) (*models.Histogram, error) {
+	s, unlock := ms.lockKey(key)
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	undo := s.save(common.MetricTypeHistogram, key)
+
+	merged, err := mergeHistogram(undo.histogram, h)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	s.histogram[key] = merged
+	s.updated[undo.key] = now
+
+	err = ms.persist(newWALUpdate(now, *newSeriesHistogramModel(key, merged)))
+	if err != nil {
+		undo.restore()
+		return nil, err
+	}
+
+	return merged.Clone(), nil
+}
+
+func (ms *MemStorage) GetSummary(ctx context.Context, key string) (*models.QuantileSketch, error) {
+	s, unlock := ms.rlockKey(key)
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	val, ok := s.summary[key]
+	if !ok {
+		return nil, fmt.Errorf("%s %s: %w", common.MetricTypeSummary, key, ErrNotFound)
+	}
+	return val, nil
+}
+
+// UpdateSummary adds observations to the sketch of the summary and returns it.
+// Stored sketches are never modified in place, so readers can share them.
+func (ms *MemStorage) UpdateSummary(ctx context.Context, key string, observations []float64) (*models.QuantileSketch, error) {
+	s, unlock := ms.lockKey(key)
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	undo := s.save(common.MetricTypeSummary, key)
+	merged := mergeSummary(undo.summary, observations, nil)
+	s.summary[key] = merged
+	s.updated[undo.key] = now
+
+	err := ms.persist(newWALUpdate(now, *newSeriesSummaryModel(key, merged)))
+	if err != nil {
+		undo.restore()
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+// DeleteMetric removes a metric together with its history.
+func (ms *MemStorage) DeleteMetric(ctx context.Context, mType string, key string) error {
+	s, unlock := ms.lockKey(key)
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	switch mType {
+	case common.MetricTypeGauge, common.MetricTypeCounter, common.MetricTypeHistogram, common.MetricTypeSummary:
+	default:
+		return fmt.Errorf("unknown metric type %s", mType)
+	}
+
+	undo := s.save(mType, key)
+	if !undo.exists {
+		return fmt.Errorf("%s %s: %w", mType, key, ErrNotFound)
+	}
+	s.deleteMetric(undo.key)
+
+	err := ms.persist(&walRecord{Op: walOpDelete, Time: time.Now(), MType: mType, Key: key})
+	if err != nil {
+		undo.restore()
+		return err
+	}
+
+	s.history.delete(mType, key)
+
+	return nil
+}
+
+// ResetCounter sets an existing counter to zero.
+func (ms *MemStorage) ResetCounter(ctx context.Context, key string) error {
+	s, unlock := ms.lockKey(key)
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	undo := s.save(common.MetricTypeCounter, key)
+	if !undo.exists {
+		return fmt.Errorf("%s %s: %w", common.MetricTypeCounter, key, ErrNotFound)
+	}
+	now := time.Now()
+	s.counter[key] = 0
+	s.updated[undo.key] = now
+
+	err := ms.persist(newWALUpdate(now, *newSeriesMetricModel(key, common.MetricTypeCounter, 0, 0)))
+	if err != nil {
+		undo.restore()
+		return err
+	}
+
+	s.history.record(common.MetricTypeCounter, key, models.NewCounterSample(now, 0, 0))
+
+	return nil
+}
+
 func (ms *MemStorage) GetAll(ctx context.Context) ([]models.MetricModel, error) {
-	ms.mu.RLock()
-	defer ms.mu.RUnlock()
+	unlock := ms.rlockAll()
+	defer unlock()
 
 	if err := ctx.Err(); err != nil {
 		return nil, err
@@ -166,28 +440,50 @@ func (ms *MemStorage) GetAll(ctx context.Context) ([]models.MetricModel, error)
 	return ms.getAll(), nil
 }
 
+// getAll lists all metrics, the caller must hold every shard.
 func (ms *MemStorage) getAll() []models.MetricModel {
-	metrics := make([]models.MetricModel, 0, len(ms.gauge)+len(ms.counter))
-	for k, v := range ms.gauge {
-		metrics = append(metrics, *models.NewMetricModel(k, common.MetricTypeGauge, 0, v))
+	count := 0
+	for _, s := range ms.shards {
+		count += s.len()
+	}
+
+	metrics := make([]models.MetricModel, 0, count)
+	for _, s := range ms.shards {
+		for k, v := range s.gauge {
+			metrics = append(metrics, *newSeriesMetricModel(k, common.MetricTypeGauge, 0, v))
+		}
+		for k, v := range s.counter {
+			metrics = append(metrics, *newSeriesMetricModel(k, common.MetricTypeCounter, v, 0))
+		}
+		for k, v := range s.histogram {
+			metrics = append(metrics, *newSeriesHistogramModel(k, v.Clone()))
+		}
+		for k, v := range s.summary {
+			metrics = append(metrics, *newSeriesSummaryModel(k, v))
+		}
 	}
-	for k, v := range ms.counter {
-		metrics = append(metrics, *models.NewMetricModel(k, common.MetricTypeCounter, v, 0))
+	for i := range metrics {
+		key := metrics[i].SeriesKey()
+		updated, ok := ms.shard(key).updated[metricKey{mType: metrics[i].MType, id: key}]
+		if !ok {
+			continue
+		}
+		metrics[i].UpdatedAt = &updated
+		metrics[i].ExpiresAt = expiresAt(updated, ms.ttl)
 	}
-	sort.Slice(metrics, func(i, j int) bool {
-		return metrics[i].ID < metrics[j].ID
-	})
+	sortMetricModels(metrics)
 
 	return metrics
 }
 
 func (ms *MemStorage) Dump() error {
-	ms.mu.RLock()
-	defer ms.mu.RUnlock()
+	unlock := ms.rlockAll()
+	defer unlock()
 
 	return ms.dump()
 }
 
+// dump writes all metrics to the file, the caller must hold every shard.
 func (ms *MemStorage) dump() error {
 	if !ms.dumpInProgress.CompareAndSwap(false, true) {
 		return fmt.Errorf("dump already in progress")
@@ -197,7 +493,32 @@ func (ms *MemStorage) dump() error {
 		ms.dumpInProgress.Store(false)
 	}()
 
-	metrics := ms.getAll()
+	start := time.Now()
+	if err := ms.writeDump(); err != nil {
+		selfMetrics.inc(selfDumpFailures, nil)
+		return err
+	}
+	selfMetrics.observeSince(selfDumpDuration, nil, start)
+
+	logger.Get().Info("Data dumped successfully")
+
+	return nil
+}
+
+// memDump is the format of the dump file. Dumps written before silences
+// were added hold the bare metrics array.
+type memDump struct {
+	Metrics  []models.MetricModel `json:"metrics"`
+	Silences []models.Silence     `json:"silences,omitempty"`
+}
+
+// writeDump writes all metrics and silences to a temp file and renames it
+// over the dump file, then truncates the WAL.
+func (ms *MemStorage) writeDump() error {
+	data := memDump{
+		Metrics:  ms.getAll(),
+		Silences: ms.getSilences(),
+	}
 
 	tmpPath := ms.filePath + ".tmp"
 	file, err := os.Create(tmpPath)
@@ -224,7 +545,7 @@ func (ms *MemStorage) dump() error {
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
 
-	err = encoder.Encode(metrics)
+	err = encoder.Encode(data)
 	if err != nil {
 		return fmt.Errorf("failed to encode metrics: %w", err)
 	}
@@ -239,7 +560,12 @@ func (ms *MemStorage) dump() error {
 		return fmt.Errorf("failed to rename temp file: %w", err)
 	}
 
-	logger.Get().Info("Data dumped successfully")
+	if ms.wal != nil {
+		// The dump holds every logged write, writers wait for the shards.
+		if truncErr := ms.wal.truncate(); truncErr != nil {
+			logger.Get().Error("Failed to truncate WAL", zap.Error(truncErr))
+		}
+	}
 
 	return nil
 }
@@ -258,32 +584,153 @@ func (ms *MemStorage) load() error {
 		}
 	}()
 
-	decoder := json.NewDecoder(file)
+	reader := bufio.NewReader(file)
+	decoder := json.NewDecoder(reader)
 
-	var metrics []models.MetricModel
-	err = decoder.Decode(&metrics)
+	var data memDump
+	first, err := peekNonSpace(reader)
 	if err != nil {
 		return err
 	}
+	if first == '[' {
+		err = decoder.Decode(&data.Metrics)
+	} else {
+		err = decoder.Decode(&data)
+	}
+	if err != nil {
+		return err
+	}
+	metrics := data.Metrics
 
 	logger.Get().Info("Loaded entries", zap.Int("count", len(metrics)))
 
+	now := time.Now()
+	counts := make(map[string]int, 4)
 	for _, m := range metrics {
-		switch m.MType {
-		case common.MetricTypeGauge:
-			ms.gauge[m.ID] = *m.Value
-		case common.MetricTypeCounter:
-			ms.counter[m.ID] = *m.Delta
-		default:
-			return fmt.Errorf("unknown metric type %s", m.MType)
+		updated := now
+		if m.UpdatedAt != nil {
+			updated = *m.UpdatedAt
+		}
+		err = ms.restoreMetric(m, updated)
+		if err != nil {
+			return err
 		}
+		counts[m.MType]++
 	}
 
 	logger.Get().Info("Loaded metrics",
-		zap.Int("gauges", len(ms.gauge)),
-		zap.Int("counters", len(ms.counter)),
+		zap.Int("gauges", counts[common.MetricTypeGauge]),
+		zap.Int("counters", counts[common.MetricTypeCounter]),
+		zap.Int("histograms", counts[common.MetricTypeHistogram]),
+		zap.Int("summaries", counts[common.MetricTypeSummary]),
 	)
 
+	for _, silence := range data.Silences {
+		ms.silences[silence.ID] = silence
+	}
+	if len(data.Silences) > 0 {
+		logger.Get().Info("Loaded silences", zap.Int("count", len(data.Silences)))
+	}
+
+	return nil
+}
+
+// peekNonSpace returns the first byte of r that is not whitespace without
+// consuming it.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = r.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+// restoreMetric sets the stored state of a metric from a dumped model,
+// the caller must hold every shard.
+func (ms *MemStorage) restoreMetric(m models.MetricModel, updated time.Time) error {
+	switch m.MType {
+	case common.MetricTypeGauge, common.MetricTypeCounter, common.MetricTypeHistogram, common.MetricTypeSummary:
+	default:
+		return fmt.Errorf("unknown metric type %s", m.MType)
+	}
+	key := m.SeriesKey()
+	ms.shard(key).restoreMetric(m, key, updated)
+	return nil
+}
+
+// initWAL replays the WAL on top of the restored snapshot and folds it into
+// a new dump. Without restore the WAL describes discarded state and is
+// truncated. A WAL that fails to replay is moved aside rather than
+// truncated, so the records after the failing one can be inspected.
+func (ms *MemStorage) initWAL(restore bool) {
+	if !restore {
+		err := ms.wal.truncate()
+		if err != nil {
+			logger.Get().Error("Failed to truncate WAL", zap.Error(err))
+		}
+		return
+	}
+
+	ms.mu.Lock()
+	count, err := ms.wal.replay(ms.applyWALRecord)
+	ms.mu.Unlock()
+	logger.Get().Info("Replayed WAL records", zap.Int("count", count))
+	if err != nil {
+		logger.Get().Error("Error occurred during WAL replay", zap.Error(err))
+		aside, moveErr := ms.wal.moveAside(time.Now())
+		if moveErr != nil {
+			// A dump would truncate the records left to inspect.
+			logger.Get().Error("Failed to move WAL aside", zap.Error(moveErr))
+			return
+		}
+		logger.Get().Warn("Moved WAL aside", zap.String("path", aside))
+	}
+
+	if count > 0 {
+		err = ms.Dump()
+		if err != nil {
+			logger.Get().Error("Dump after WAL replay error", zap.Error(err))
+		}
+	}
+}
+
+// applyWALRecord applies a logged write, the caller must hold every shard.
+func (ms *MemStorage) applyWALRecord(rec *walRecord) error {
+	switch rec.Op {
+	case walOpUpdate:
+		for _, m := range rec.Metrics {
+			err := ms.restoreMetric(m, rec.Time)
+			if err != nil {
+				return err
+			}
+		}
+	case walOpDelete:
+		ms.shard(rec.Key).deleteMetric(metricKey{mType: rec.MType, id: rec.Key})
+	case walOpExpire:
+		for _, s := range ms.shards {
+			for mk, updated := range s.updated {
+				if updated.Before(rec.Time) {
+					s.deleteMetric(mk)
+				}
+			}
+		}
+	case walOpSilence:
+		if rec.Silence == nil {
+			return fmt.Errorf("wal %s record without silence", rec.Op)
+		}
+		ms.silences[rec.Silence.ID] = *rec.Silence
+	case walOpUnsilence:
+		delete(ms.silences, rec.Key)
+	default:
+		return fmt.Errorf("unknown wal operation %s", rec.Op)
+	}
 	return nil
 }
 
@@ -319,6 +766,12 @@ func (ms *MemStorage) ShutDown() {
 	}
 
 	logger.Get().Info("MemStorage shutting down...")
+	if ms.compactor != nil {
+		ms.compactor.stop()
+	}
+	if ms.sweeper != nil {
+		ms.sweeper.stop()
+	}
 	if ms.syncDump {
 		for ms.dumpInProgress.Load() {
 			logger.Get().Info("Dump in progress. Waiting...")
@@ -328,6 +781,11 @@ func (ms *MemStorage) ShutDown() {
 		close(ms.stopChan)
 		<-ms.doneChan
 	}
+	if ms.wal != nil {
+		if err := ms.wal.close(); err != nil {
+			logger.Get().Error("Failed to close WAL", zap.Error(err))
+		}
+	}
 	logger.Get().Info("MemStorage shutdown completed")
 }
 
@@ -338,39 +796,70 @@ func (ms *MemStorage) Ping(ctx context.Context) error {
 	return nil
 }
 
+// BatchUpdate applies all metrics or none of them. Only the shards of the
+// metrics in the batch are locked, and the rollback log keeps the previous
+// state of the touched metrics only.
 func (ms *MemStorage) BatchUpdate(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
+	keys := make([]string, len(metrics))
+	for i := range metrics {
+		keys[i] = metrics[i].SeriesKey()
+	}
+
+	unlock := ms.lockKeys(keys)
+	defer unlock()
 
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	backupCounters := make(map[string]int64, len(ms.counter))
-	maps.Copy(backupCounters, ms.counter)
-
-	backupGauges := make(map[string]float64, len(ms.gauge))
-	maps.Copy(backupGauges, ms.gauge)
-
 	newMetrics := make([]models.MetricModel, 0, len(metrics))
+	samples := make([]models.MetricSample, 0, len(metrics))
+	states := make([]models.MetricModel, 0, len(metrics))
+	undo := make(memUndoLog, 0, len(metrics))
+	now := time.Now()
 
 	var err error
-	for _, m := range metrics {
+	for i, m := range metrics {
+		key := keys[i]
+		s := ms.shard(key)
+		undo.save(s, m.MType, key)
+
 		switch m.MType {
 		case common.MetricTypeCounter:
-			val, ok := ms.counter[m.ID]
-			if ok {
-				val += *m.Delta
-			} else {
-				val = *m.Delta
-			}
-			ms.counter[m.ID] = val
-			newMetrics = append(newMetrics, *models.NewMetricModel(m.ID, m.MType, *m.Delta, 0))
+			val := s.counter[key] + *m.Delta
+			s.counter[key] = val
+			newMetric := models.NewMetricModel(m.ID, m.MType, val, 0)
+			newMetric.Labels = m.Labels
+			newMetrics = append(newMetrics, *newMetric)
+			samples = append(samples, models.NewCounterSample(now, val, *m.Delta))
 
 		case common.MetricTypeGauge:
 			val := *m.Value
-			ms.gauge[m.ID] = val
-			newMetrics = append(newMetrics, *models.NewMetricModel(m.ID, m.MType, 0, *m.Value))
+			s.gauge[key] = val
+			newMetric := models.NewMetricModel(m.ID, m.MType, 0, *m.Value)
+			newMetric.Labels = m.Labels
+			newMetrics = append(newMetrics, *newMetric)
+			samples = append(samples, models.NewMetricSample(now, m.MType, 0, val))
+
+		case common.MetricTypeHistogram:
+			var merged *models.Histogram
+			merged, err = mergeHistogram(s.histogram[key], m.Histogram)
+			if err != nil {
+				break
+			}
+			s.histogram[key] = merged
+			newMetric := models.NewHistogramMetricModel(m.ID, merged.Clone())
+			newMetric.Labels = m.Labels
+			newMetrics = append(newMetrics, *newMetric)
+			samples = append(samples, models.MetricSample{})
+
+		case common.MetricTypeSummary:
+			merged := mergeSummary(s.summary[key], m.Observations, m.Sketch)
+			s.summary[key] = merged
+			newMetric := models.NewSummaryMetricModel(m.ID, merged)
+			newMetric.Labels = m.Labels
+			newMetrics = append(newMetrics, *newMetric)
+			samples = append(samples, models.MetricSample{})
 
 		default:
 			err = fmt.Errorf("bad metric type %s", m.MType)
@@ -379,25 +868,170 @@ func (ms *MemStorage) BatchUpdate(ctx context.Context, metrics []models.MetricMo
 		if err != nil {
 			break
 		}
+		s.updated[metricKey{mType: m.MType, id: key}] = now
+		states = append(states, s.stateModel(m.MType, key))
 	}
 
-	restoreBackup := func() {
-		ms.counter = backupCounters
-		ms.gauge = backupGauges
+	if err != nil {
+		undo.rollback()
+		return nil, err
 	}
 
+	err = ms.persist(newWALUpdate(now, states...))
 	if err != nil {
-		restoreBackup()
+		undo.rollback()
 		return nil, err
 	}
 
-	if ms.syncDump {
-		err := ms.dump()
-		if err != nil {
-			restoreBackup()
-			return nil, err
+	for i, m := range newMetrics {
+		if m.MType == common.MetricTypeHistogram || m.MType == common.MetricTypeSummary {
+			continue
 		}
+		ms.shard(keys[i]).history.record(m.MType, keys[i], samples[i])
 	}
 
 	return newMetrics, nil
 }
+
+func (ms *MemStorage) GetHistory(ctx context.Context, mType string, key string, from, to time.Time) ([]models.MetricSample, error) {
+	s, unlock := ms.rlockKey(key)
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.history.get(mType, key, from, to), nil
+}
+
+func (ms *MemStorage) GetRollups(ctx context.Context, mType string, key string, resolution time.Duration, from, to time.Time) ([]models.MetricRollup, error) {
+	s, unlock := ms.rlockKey(key)
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.history.getRollups(mType, key, resolution, from, to), nil
+}
+
+// CompactHistory compacts the history of one shard at a time, so writes to
+// the other shards are not blocked.
+func (ms *MemStorage) CompactHistory(ctx context.Context, policy *RetentionPolicy, now time.Time) error {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, s := range ms.shards {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.history.compact(policy, now)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// ExpireMetrics removes metrics that were last updated before the given time.
+// History of expired metrics is left to the retention policy.
+func (ms *MemStorage) ExpireMetrics(ctx context.Context, before time.Time) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var undo memUndoLog
+	for _, s := range ms.shards {
+		for mk, updated := range s.updated {
+			if updated.Before(before) {
+				undo.save(s, mk.mType, mk.id)
+				s.deleteMetric(mk)
+			}
+		}
+	}
+	if len(undo) == 0 {
+		return 0, nil
+	}
+
+	err := ms.persist(&walRecord{Op: walOpExpire, Time: before})
+	if err != nil {
+		undo.rollback()
+		return 0, err
+	}
+
+	return len(undo), nil
+}
+
+// GetSilences returns all stored silences ordered by ID.
+func (ms *MemStorage) GetSilences(ctx context.Context) ([]models.Silence, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return ms.getSilences(), nil
+}
+
+// getSilences returns silences ordered by ID, the caller must hold mu.
+func (ms *MemStorage) getSilences() []models.Silence {
+	silences := make([]models.Silence, 0, len(ms.silences))
+	for _, silence := range ms.silences {
+		silences = append(silences, silence)
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].ID < silences[j].ID
+	})
+	return silences
+}
+
+// SaveSilence creates or replaces the silence with the ID of s.
+func (ms *MemStorage) SaveSilence(ctx context.Context, s models.Silence) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	prev, existed := ms.silences[s.ID]
+	ms.silences[s.ID] = s
+
+	err := ms.persist(&walRecord{Op: walOpSilence, Time: time.Now(), Silence: &s})
+	if err != nil {
+		if existed {
+			ms.silences[s.ID] = prev
+		} else {
+			delete(ms.silences, s.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteSilence removes a silence.
+func (ms *MemStorage) DeleteSilence(ctx context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	prev, ok := ms.silences[id]
+	if !ok {
+		return fmt.Errorf("silence %s: %w", id, ErrNotFound)
+	}
+	delete(ms.silences, id)
+
+	err := ms.persist(&walRecord{Op: walOpUnsilence, Time: time.Now(), Key: id})
+	if err != nil {
+		ms.silences[id] = prev
+		return err
+	}
+	return nil
+}
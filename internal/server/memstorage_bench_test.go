@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"go.uber.org/zap"
+)
+
+// benchBatchSize is the number of metrics in a benchmark batch, about what
+// an agent reports in one /updates/ request.
+const benchBatchSize = 40
+
+func newBenchMemStorage(b *testing.B) *MemStorage {
+	b.Helper()
+	ms, err := NewMemStorageFromStorageConfig(&StorageConfig{
+		StoreInterval:   3600,
+		FileStoragePath: filepath.Join(b.TempDir(), "dump.json"),
+		HistorySize:     DefaultHistorySize,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(ms.ShutDown)
+	return ms
+}
+
+// newBenchBatch returns a batch of gauges and counters reported by one agent.
+func newBenchBatch(agent int) []models.MetricModel {
+	metrics := make([]models.MetricModel, 0, benchBatchSize)
+	for i := range benchBatchSize / 2 {
+		gauge := models.NewMetricModel(fmt.Sprintf("gauge%d", i), common.MetricTypeGauge, 0, float64(i))
+		gauge.Labels = map[string]string{"agent": fmt.Sprint(agent)}
+		counter := models.NewMetricModel(fmt.Sprintf("counter%d", i), common.MetricTypeCounter, 1, 0)
+		counter.Labels = map[string]string{"agent": fmt.Sprint(agent)}
+		metrics = append(metrics, *gauge, *counter)
+	}
+	return metrics
+}
+
+func BenchmarkMemStorage_BatchUpdateParallel(b *testing.B) {
+	ms := newBenchMemStorage(b)
+	ctx := context.Background()
+	var agents atomic.Int64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		batch := newBenchBatch(int(agents.Add(1)))
+		for pb.Next() {
+			if _, err := ms.BatchUpdate(ctx, batch); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkMemStorage_SetGaugeParallel(b *testing.B) {
+	ms := newBenchMemStorage(b)
+	ctx := context.Background()
+	var agents atomic.Int64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		key := fmt.Sprintf("gauge%d", agents.Add(1))
+		for pb.Next() {
+			if _, err := ms.SetGauge(ctx, key, 1); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkBatchUpdateHandlerParallel(b *testing.B) {
+	bh := BaseHandler{store: newBenchMemStorage(b), logger: zap.NewNop()}
+	handler := bh.MetricBatchUpdateJSONHandler()
+	var agents atomic.Int64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		body, err := json.Marshal(newBenchBatch(int(agents.Add(1))))
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		for pb.Next() {
+			req := httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != http.StatusOK {
+				b.Errorf("unexpected status %d", rec.Code)
+				return
+			}
+		}
+	})
+}
@@ -2,8 +2,12 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/etoneja/go-metrics/internal/common"
 	"github.com/etoneja/go-metrics/internal/models"
@@ -191,6 +195,66 @@ func TestMemStorage_BatchUpdate_InvalidType(t *testing.T) {
 	}
 }
 
+func TestMemStorage_BatchUpdate_RollbackAcrossShards(t *testing.T) {
+	storage := NewMemStorage()
+	ctx := context.Background()
+
+	_, err := storage.SetGauge(ctx, "g0", 1)
+	require.NoError(t, err)
+
+	metrics := []models.MetricModel{
+		*models.NewMetricModel("g0", common.MetricTypeGauge, 0, 2),
+		*models.NewMetricModel("c0", common.MetricTypeCounter, 1, 0),
+		*models.NewMetricModel("c0", common.MetricTypeCounter, 1, 0),
+	}
+	for i := range 16 {
+		metrics = append(metrics, *models.NewMetricModel(fmt.Sprintf("g%d", i+1), common.MetricTypeGauge, 0, 1))
+	}
+	metrics = append(metrics, models.MetricModel{ID: "bad", MType: "invalid"})
+
+	_, err = storage.BatchUpdate(ctx, metrics)
+	require.Error(t, err)
+
+	val, err := storage.GetGauge(ctx, "g0")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, val)
+
+	all, err := storage.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+}
+
+func TestMemStorage_ConcurrentBatchUpdate(t *testing.T) {
+	storage := NewMemStorage()
+	ctx := context.Background()
+
+	const workers, rounds = 8, 50
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every worker touches the shared counters in a different order.
+			metrics := make([]models.MetricModel, 0, 10)
+			for i := range 10 {
+				id := fmt.Sprintf("c%d", (i+w)%10)
+				metrics = append(metrics, *models.NewMetricModel(id, common.MetricTypeCounter, 1, 0))
+			}
+			for range rounds {
+				_, err := storage.BatchUpdate(ctx, metrics)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 10 {
+		val, err := storage.GetCounter(ctx, fmt.Sprintf("c%d", i))
+		require.NoError(t, err)
+		assert.Equal(t, int64(workers*rounds), val)
+	}
+}
+
 func TestNewMemStorageFromStorageConfig(t *testing.T) {
 	config := &StorageConfig{
 		StoreInterval:   5,
@@ -198,12 +262,34 @@ func TestNewMemStorageFromStorageConfig(t *testing.T) {
 		Restore:         false,
 	}
 
-	storage := NewMemStorageFromStorageConfig(config)
+	storage, err := NewMemStorageFromStorageConfig(config)
+	if err != nil {
+		t.Fatalf("NewMemStorageFromStorageConfig failed: %v", err)
+	}
 	if storage == nil {
 		t.Error("NewMemStorageFromStorageConfig returned nil")
 	}
 }
 
+func TestNewMemStorageFromStorageConfig_BadWALPath(t *testing.T) {
+	_, err := NewMemStorageFromStorageConfig(&StorageConfig{
+		WALPath: filepath.Join(t.TempDir(), "missing", "metrics.wal"),
+	})
+	if err == nil {
+		t.Error("expected error for a WAL in a missing directory")
+	}
+}
+
+// newTestMemStorage creates a MemStorage failing the test on errors.
+func newTestMemStorage(t *testing.T, sc *StorageConfig) *MemStorage {
+	t.Helper()
+	ms, err := NewMemStorageFromStorageConfig(sc)
+	if err != nil {
+		t.Fatalf("NewMemStorageFromStorageConfig failed: %v", err)
+	}
+	return ms
+}
+
 func TestMemStorage_GetSetIntegration(t *testing.T) {
 	storage := NewMemStorage()
 
@@ -256,8 +342,9 @@ func TestMemStorage_DumpAndLoad(t *testing.T) {
 	ms.filePath = tmpFile.Name()
 
 	ms.mu.Lock()
-	ms.gauge["test_gauge"] = 123.45
-	ms.counter["test_counter"] = 42
+	ms.shard("test_gauge").gauge["test_gauge"] = 123.45
+	ms.shard("test_counter").counter["test_counter"] = 42
+	ms.shard("test_histogram").histogram["test_histogram"] = &models.Histogram{Bounds: []float64{1}, Counts: []uint64{2, 3}, Sum: 7}
 	ms.mu.Unlock()
 
 	err = ms.Dump()
@@ -270,11 +357,12 @@ func TestMemStorage_DumpAndLoad(t *testing.T) {
 		FileStoragePath: tmpFile.Name(),
 		Restore:         true,
 	}
-	ms2 := NewMemStorageFromStorageConfig(sc)
+	ms2 := newTestMemStorage(t, sc)
 
 	ms2.mu.RLock()
-	assert.Equal(t, 123.45, ms2.gauge["test_gauge"])
-	assert.Equal(t, int64(42), ms2.counter["test_counter"])
+	assert.Equal(t, 123.45, ms2.shard("test_gauge").gauge["test_gauge"])
+	assert.Equal(t, int64(42), ms2.shard("test_counter").counter["test_counter"])
+	assert.Equal(t, []uint64{2, 3}, ms2.shard("test_histogram").histogram["test_histogram"].Counts)
 	ms2.mu.RUnlock()
 }
 
@@ -286,3 +374,506 @@ func TestMemStorage_DumpError(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create temp file")
 }
+
+func TestMemStorage_GetHistory(t *testing.T) {
+	storage := NewMemStorage()
+	ctx := context.Background()
+
+	_, err := storage.IncrementCounter(ctx, "counter1", 2)
+	require.NoError(t, err)
+	_, err = storage.SetGauge(ctx, "gauge1", 1.5)
+	require.NoError(t, err)
+	_, err = storage.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("counter1", common.MetricTypeCounter, 3, 0),
+		*models.NewMetricModel("counter1", common.MetricTypeCounter, 4, 0),
+		*models.NewMetricModel("gauge1", common.MetricTypeGauge, 0, 2.5),
+	})
+	require.NoError(t, err)
+
+	counters, err := storage.GetHistory(ctx, common.MetricTypeCounter, "counter1", time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, counters, 3)
+	assert.Equal(t, int64(2), *counters[0].Delta)
+	assert.Equal(t, int64(5), *counters[1].Delta)
+	assert.Equal(t, int64(9), *counters[2].Delta)
+
+	gauges, err := storage.GetHistory(ctx, common.MetricTypeGauge, "gauge1", time.Time{}, time.Now())
+	require.NoError(t, err)
+	require.Len(t, gauges, 2)
+	assert.Equal(t, 1.5, *gauges[0].Value)
+	assert.Equal(t, 2.5, *gauges[1].Value)
+
+	_, err = storage.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("gauge1", common.MetricTypeGauge, 0, 3.5),
+		{ID: "bad", MType: "invalid"},
+	})
+	require.Error(t, err)
+
+	gauges, err = storage.GetHistory(ctx, common.MetricTypeGauge, "gauge1", time.Time{}, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, gauges, 2)
+}
+
+func TestMemStorage_Labels(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test_labels_*.json")
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+	defer func() {
+		if removeErr := os.Remove(tmpFile.Name()); removeErr != nil && !os.IsNotExist(removeErr) {
+			t.Logf("Remove temp file failed: %v", removeErr)
+		}
+	}()
+
+	storage := NewMemStorage()
+	storage.filePath = tmpFile.Name()
+	ctx := context.Background()
+
+	hostA := models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 1)
+	hostA.Labels = map[string]string{"host": "a"}
+	hostB := models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 2)
+	hostB.Labels = map[string]string{"host": "b"}
+	plain := models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 3)
+
+	result, err := storage.BatchUpdate(ctx, []models.MetricModel{*hostB, *hostA, *plain})
+	require.NoError(t, err)
+	assert.Equal(t, hostB.Labels, result[0].Labels)
+
+	value, err := storage.GetGauge(ctx, `Alloc{host="a"}`)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, value)
+
+	value, err = storage.GetGauge(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, value)
+
+	metrics, err := storage.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+	assert.Nil(t, metrics[0].Labels)
+	assert.Equal(t, hostA.Labels, metrics[1].Labels)
+	assert.Equal(t, hostB.Labels, metrics[2].Labels)
+
+	require.NoError(t, storage.Dump())
+
+	restored := newTestMemStorage(t, &StorageConfig{
+		FileStoragePath: tmpFile.Name(),
+		Restore:         true,
+	})
+	value, err = restored.GetGauge(ctx, `Alloc{host="b"}`)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+}
+
+func TestMemStorage_Histogram(t *testing.T) {
+	storage := NewMemStorage()
+	ctx := context.Background()
+
+	_, err := storage.GetHistogram(ctx, "latency")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	h := models.NewHistogram([]float64{1})
+	h.Observe(0.5)
+
+	result, err := storage.UpdateHistogram(ctx, "latency", h)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 0}, result.Counts)
+
+	h.Observe(2)
+	_, err = storage.BatchUpdate(ctx, []models.MetricModel{*models.NewHistogramMetricModel("latency", h)})
+	require.NoError(t, err)
+
+	result, err = storage.GetHistogram(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 1}, result.Counts)
+	assert.Equal(t, 3.0, result.Sum)
+
+	_, err = storage.UpdateHistogram(ctx, "latency", models.NewHistogram([]float64{2}))
+	assert.ErrorIs(t, err, models.ErrHistogramBoundsMismatch)
+
+	_, err = storage.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 1),
+		*models.NewHistogramMetricModel("latency", models.NewHistogram([]float64{2})),
+	})
+	assert.ErrorIs(t, err, models.ErrHistogramBoundsMismatch)
+
+	_, err = storage.GetGauge(ctx, "Alloc")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	metrics, err := storage.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, common.MetricTypeHistogram, metrics[0].MType)
+	assert.Equal(t, uint64(3), metrics[0].Histogram.Count())
+}
+
+func TestMemStorage_Summary(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test_summary_*.json")
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+	defer func() {
+		if removeErr := os.Remove(tmpFile.Name()); removeErr != nil && !os.IsNotExist(removeErr) {
+			t.Logf("Remove temp file failed: %v", removeErr)
+		}
+	}()
+
+	storage := NewMemStorage()
+	storage.filePath = tmpFile.Name()
+	ctx := context.Background()
+
+	_, err = storage.GetSummary(ctx, "latency")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = storage.UpdateSummary(ctx, "latency", []float64{1, 2})
+	require.NoError(t, err)
+
+	result, err := storage.BatchUpdate(ctx, []models.MetricModel{
+		{ID: "latency", MType: common.MetricTypeSummary, Observations: []float64{3, 4, 100}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), result[0].Summary.Count)
+	assert.Nil(t, result[0].Sketch)
+
+	sketch, err := storage.GetSummary(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, 110.0, sketch.Sum)
+	assert.InDelta(t, 3, sketch.Quantile(0.5), 3*models.SketchRelativeAccuracy)
+
+	require.NoError(t, storage.Dump())
+
+	restored := newTestMemStorage(t, &StorageConfig{
+		FileStoragePath: tmpFile.Name(),
+		Restore:         true,
+	})
+	restoredSketch, err := restored.GetSummary(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, sketch.Count, restoredSketch.Count)
+	assert.Equal(t, sketch.Quantile(0.99), restoredSketch.Quantile(0.99))
+
+	restoredSketch, err = restored.UpdateSummary(ctx, "latency", []float64{5})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), restoredSketch.Count)
+}
+
+func TestMemStorage_DeleteMetric(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test_delete_*.json")
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+	defer func() {
+		if removeErr := os.Remove(tmpFile.Name()); removeErr != nil && !os.IsNotExist(removeErr) {
+			t.Logf("Remove temp file failed: %v", removeErr)
+		}
+	}()
+
+	storage := newTestMemStorage(t, &StorageConfig{
+		FileStoragePath: tmpFile.Name(),
+		HistorySize:     10,
+	})
+	ctx := context.Background()
+
+	_, err = storage.SetGauge(ctx, "Alloc", 1)
+	require.NoError(t, err)
+	_, err = storage.IncrementCounter(ctx, "Alloc", 5)
+	require.NoError(t, err)
+
+	err = storage.DeleteMetric(ctx, common.MetricTypeGauge, "Alloc")
+	require.NoError(t, err)
+
+	_, err = storage.GetGauge(ctx, "Alloc")
+	assert.ErrorIs(t, err, ErrNotFound)
+	samples, err := storage.GetHistory(ctx, common.MetricTypeGauge, "Alloc", time.Time{}, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+
+	value, err := storage.GetCounter(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), value)
+
+	err = storage.DeleteMetric(ctx, common.MetricTypeGauge, "Alloc")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	restored := newTestMemStorage(t, &StorageConfig{
+		FileStoragePath: tmpFile.Name(),
+		Restore:         true,
+	})
+	_, err = restored.GetGauge(ctx, "Alloc")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemStorage_ResetCounter(t *testing.T) {
+	storage := NewMemStorage()
+	ctx := context.Background()
+
+	err := storage.ResetCounter(ctx, "PollCount")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = storage.IncrementCounter(ctx, "PollCount", 5)
+	require.NoError(t, err)
+
+	require.NoError(t, storage.ResetCounter(ctx, "PollCount"))
+
+	value, err := storage.IncrementCounter(ctx, "PollCount", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+}
+
+func TestMemStorage_ExpireMetrics(t *testing.T) {
+	storage := newTestMemStorage(t, &StorageConfig{
+		FileStoragePath: t.TempDir() + "/dump.json",
+		MetricTTL:       time.Hour,
+	})
+	defer storage.ShutDown()
+	ctx := context.Background()
+
+	_, err := storage.SetGauge(ctx, "stale", 1)
+	require.NoError(t, err)
+	_, err = storage.UpdateHistogram(ctx, "stale", &models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5})
+	require.NoError(t, err)
+
+	cutoff := time.Now()
+
+	_, err = storage.IncrementCounter(ctx, "fresh", 1)
+	require.NoError(t, err)
+
+	metrics, err := storage.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+	for _, m := range metrics {
+		require.NotNil(t, m.UpdatedAt)
+		require.NotNil(t, m.ExpiresAt)
+		assert.Equal(t, m.UpdatedAt.Add(time.Hour), *m.ExpiresAt)
+	}
+
+	expired, err := storage.ExpireMetrics(ctx, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, 2, expired)
+
+	_, err = storage.GetGauge(ctx, "stale")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = storage.GetHistogram(ctx, "stale")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	value, err := storage.GetCounter(ctx, "fresh")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+
+	expired, err = storage.ExpireMetrics(ctx, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+}
+
+func TestMemStorage_DumpAndLoad_UpdatedAt(t *testing.T) {
+	filePath := t.TempDir() + "/dump.json"
+
+	ms := NewMemStorage()
+	ms.filePath = filePath
+
+	_, err := ms.SetGauge(context.Background(), "test_gauge", 1)
+	require.NoError(t, err)
+	updated := ms.shard("test_gauge").updated[metricKey{mType: common.MetricTypeGauge, id: "test_gauge"}]
+
+	require.NoError(t, ms.Dump())
+
+	ms2 := newTestMemStorage(t, &StorageConfig{FileStoragePath: filePath, Restore: true})
+	defer ms2.ShutDown()
+
+	ms2.mu.RLock()
+	restored := ms2.shard("test_gauge").updated[metricKey{mType: common.MetricTypeGauge, id: "test_gauge"}]
+	ms2.mu.RUnlock()
+	assert.True(t, updated.Equal(restored))
+}
+
+func TestMemStorage_WALRecovery(t *testing.T) {
+	dir := t.TempDir()
+	sc := &StorageConfig{
+		StoreInterval:   3600,
+		FileStoragePath: filepath.Join(dir, "dump.json"),
+		Restore:         true,
+		WALPath:         filepath.Join(dir, "metrics.wal"),
+	}
+	ctx := context.Background()
+
+	ms := newTestMemStorage(t, sc)
+	_, err := ms.IncrementCounter(ctx, "PollCount", 3)
+	require.NoError(t, err)
+	_, err = ms.SetGauge(ctx, "Alloc", 1.5)
+	require.NoError(t, err)
+	_, err = ms.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("PollCount", common.MetricTypeCounter, 2, 0),
+		*models.NewMetricModel("Frees", common.MetricTypeGauge, 0, 7),
+	})
+	require.NoError(t, err)
+	require.NoError(t, ms.DeleteMetric(ctx, common.MetricTypeGauge, "Frees"))
+
+	_, err = os.Stat(sc.FileStoragePath)
+	require.True(t, os.IsNotExist(err), "writes must not dump with a WAL")
+
+	// Recover without a snapshot, as after a crash before the first dump.
+	ms2 := newTestMemStorage(t, sc)
+	counter, err := ms2.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), counter)
+	gauge, err := ms2.GetGauge(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, gauge)
+	_, err = ms2.GetGauge(ctx, "Frees")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Replay folded the WAL into a snapshot.
+	info, err := os.Stat(sc.WALPath)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+	ms2.ShutDown()
+	ms.ShutDown()
+}
+
+func TestMemStorage_WALReplayIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	sc := &StorageConfig{
+		StoreInterval:   3600,
+		FileStoragePath: filepath.Join(dir, "dump.json"),
+		Restore:         true,
+		WALPath:         filepath.Join(dir, "metrics.wal"),
+	}
+	ctx := context.Background()
+
+	ms := newTestMemStorage(t, sc)
+	defer ms.ShutDown()
+	_, err := ms.IncrementCounter(ctx, "PollCount", 3)
+	require.NoError(t, err)
+	logged, err := os.ReadFile(sc.WALPath)
+	require.NoError(t, err)
+
+	// Simulate a crash after the dump but before the WAL was truncated.
+	require.NoError(t, ms.Dump())
+	require.NoError(t, os.WriteFile(sc.WALPath, logged, 0o644))
+
+	ms2 := newTestMemStorage(t, sc)
+	defer ms2.ShutDown()
+	counter, err := ms2.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), counter)
+}
+
+func TestMemStorage_WALCorruptRecordMovedAside(t *testing.T) {
+	dir := t.TempDir()
+	sc := &StorageConfig{
+		StoreInterval:   3600,
+		FileStoragePath: filepath.Join(dir, "dump.json"),
+		Restore:         true,
+		WALPath:         filepath.Join(dir, "metrics.wal"),
+	}
+	ctx := context.Background()
+
+	ms := newTestMemStorage(t, sc)
+	_, err := ms.SetGauge(ctx, "Alloc", 1)
+	require.NoError(t, err)
+	f, err := os.OpenFile(sc.WALPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString("not json\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	_, err = ms.SetGauge(ctx, "Frees", 2)
+	require.NoError(t, err)
+
+	// Recover as after a crash, a shutdown would dump and empty the WAL.
+	ms2 := newTestMemStorage(t, sc)
+	defer ms.ShutDown()
+	defer ms2.ShutDown()
+	gauge, err := ms2.GetGauge(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, gauge)
+
+	// The records after the corrupt one are kept for inspection.
+	aside, err := filepath.Glob(sc.WALPath + ".corrupt-*")
+	require.NoError(t, err)
+	require.Len(t, aside, 1)
+	data, err := os.ReadFile(aside[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Frees")
+}
+
+func TestMemStorage_DumpAndLoad_Silences(t *testing.T) {
+	sc := &StorageConfig{
+		FileStoragePath: filepath.Join(t.TempDir(), "dump.json"),
+		Restore:         true,
+	}
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	silence := models.Silence{
+		ID:       "abc",
+		MetricID: "HeapAlloc",
+		StartsAt: start,
+		EndsAt:   start.Add(time.Hour),
+		Author:   "alice",
+	}
+
+	ms := newTestMemStorage(t, sc)
+	_, err := ms.SetGauge(ctx, "HeapAlloc", 1)
+	require.NoError(t, err)
+	require.NoError(t, ms.SaveSilence(ctx, silence))
+
+	ms2 := newTestMemStorage(t, sc)
+	silences, err := ms2.GetSilences(ctx)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	assert.Equal(t, silence.ID, silences[0].ID)
+	assert.True(t, silence.EndsAt.Equal(silences[0].EndsAt))
+	_, err = ms2.GetGauge(ctx, "HeapAlloc")
+	assert.NoError(t, err)
+}
+
+func TestMemStorage_LoadLegacyDump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+	legacy := `  [{"id": "Alloc", "type": "gauge", "value": 1.5}, {"id": "PollCount", "type": "counter", "delta": 3}]`
+	require.NoError(t, os.WriteFile(path, []byte(legacy), 0o644))
+
+	ms := newTestMemStorage(t, &StorageConfig{
+		StoreInterval:   3600,
+		FileStoragePath: path,
+		Restore:         true,
+	})
+	defer ms.ShutDown()
+	ctx := context.Background()
+
+	gauge, err := ms.GetGauge(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, gauge)
+	counter, err := ms.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), counter)
+	silences, err := ms.GetSilences(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, silences)
+}
+
+func TestMemStorage_WALRecovery_Silences(t *testing.T) {
+	dir := t.TempDir()
+	sc := &StorageConfig{
+		StoreInterval:   3600,
+		FileStoragePath: filepath.Join(dir, "dump.json"),
+		Restore:         true,
+		WALPath:         filepath.Join(dir, "metrics.wal"),
+	}
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	ms := newTestMemStorage(t, sc)
+	defer ms.ShutDown()
+	for _, id := range []string{"kept", "deleted"} {
+		require.NoError(t, ms.SaveSilence(ctx, models.Silence{
+			ID:       id,
+			MetricID: "HeapAlloc",
+			StartsAt: start,
+			EndsAt:   start.Add(time.Hour),
+			Author:   "alice",
+		}))
+	}
+	require.NoError(t, ms.DeleteSilence(ctx, "deleted"))
+
+	ms2 := newTestMemStorage(t, sc)
+	defer ms2.ShutDown()
+	silences, err := ms2.GetSilences(ctx)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	assert.Equal(t, "kept", silences[0].ID)
+}
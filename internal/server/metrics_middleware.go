@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// MetricsMiddleware counts requests and records their durations per route
+// pattern, so paths with metric names do not create a series each.
+func (bmw *BaseMiddleware) MetricsMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			responseData := &responseData{}
+			lw := &loggingResponseWriter{
+				ResponseWriter: w,
+				responseData:   responseData,
+			}
+
+			next.ServeHTTP(lw, r)
+
+			// The pattern is known only after the router matched the request.
+			route := selfUnmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			status := responseData.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			selfMetrics.inc(selfHTTPRequests, map[string]string{
+				selfLabelRoute:  route,
+				selfLabelMethod: r.Method,
+				selfLabelCode:   strconv.Itoa(status),
+			})
+			selfMetrics.observeSince(selfHTTPRequestDuration, map[string]string{
+				selfLabelRoute:  route,
+				selfLabelMethod: r.Method,
+			}, start)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
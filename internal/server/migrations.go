@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// migrationsFS holds the schema migrations. Each file is named
+// <version>_<name>.sql and is applied once, in version order.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationsLockID is the advisory lock key that serializes migrations
+// between server replicas sharing a database.
+const migrationsLockID int64 = 0x676f6d6574726963
+
+// baselineVersion is the version of installs that only have the metrics
+// table and predate schema_migrations.
+const baselineVersion = 1
+
+const (
+	queryLockMigrations        = "SELECT pg_advisory_lock($1);"
+	queryUnlockMigrations      = "SELECT pg_advisory_unlock($1);"
+	queryDisableStmtTimeout    = "SET statement_timeout = 0;"
+	queryResetStmtTimeout      = "RESET statement_timeout;"
+	queryCreateMigrationsTable = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version bigint primary key,
+			name text not null,
+			applied_at timestamptz not null default now()
+	);`
+	querySelectSchemaVersion = "select coalesce(max(version), 0) from schema_migrations;"
+	queryMetricsTableExists  = "select to_regclass('metrics') is not null;"
+	queryInsertMigration     = "INSERT INTO schema_migrations (version, name) VALUES ($1, $2);"
+)
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// loadMigrations reads migrations from fsys sorted by version.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(files))
+	seen := make(map[int]string, len(files))
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid migration file name %s, expected <version>_<name>.sql", file)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", file)
+		}
+		if prev, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d in %s and %s", version, prev, file)
+		}
+		seen[version] = file
+
+		query, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, query: string(query)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+func embeddedMigrations() ([]migration, error) {
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return loadMigrations(sub)
+}
+
+// runMigrations brings the schema up to the latest embedded migration.
+// It holds an advisory lock for the whole run, so concurrently starting
+// replicas apply every migration once.
+func (dbs *DBStorage) runMigrations(ctx context.Context) error {
+	logger.Get().Info("Running migrations")
+
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	conn, err := dbs.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// Waiting for the lock and migrations may take longer than the
+	// statement timeout of the pool.
+	_, err = conn.Exec(ctx, queryDisableStmtTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to disable statement timeout: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), queryResetStmtTimeout); err != nil {
+			logger.Get().Error("failed to reset statement timeout", zap.Error(err))
+		}
+	}()
+
+	_, err = conn.Exec(ctx, queryLockMigrations, migrationsLockID)
+	if err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), queryUnlockMigrations, migrationsLockID); err != nil {
+			logger.Get().Error("failed to unlock migrations", zap.Error(err))
+		}
+	}()
+
+	_, err = conn.Exec(ctx, queryCreateMigrationsTable)
+	if err != nil {
+		return fmt.Errorf("failed to create schema migrations table: %w", err)
+	}
+
+	version, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		err = applyMigration(ctx, conn, m)
+		if err != nil {
+			return err
+		}
+		applied++
+		version = m.version
+	}
+
+	logger.Get().Info("Migrations completed successfully",
+		zap.Int("version", version),
+		zap.Int("applied", applied),
+	)
+	return nil
+}
+
+// schemaVersion returns the latest applied migration. A database that has
+// the metrics table but no recorded migrations is marked as baselineVersion.
+func schemaVersion(ctx context.Context, conn *pgxpool.Conn) (int, error) {
+	var version int
+	err := conn.QueryRow(ctx, querySelectSchemaVersion).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > 0 {
+		return version, nil
+	}
+
+	var exists bool
+	err = conn.QueryRow(ctx, queryMetricsTableExists).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to detect existing schema: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	logger.Get().Info("Detected existing schema without migrations", zap.Int("version", baselineVersion))
+	_, err = conn.Exec(ctx, queryInsertMigration, baselineVersion, "baseline")
+	if err != nil {
+		return 0, fmt.Errorf("failed to record baseline version: %w", err)
+	}
+	return baselineVersion, nil
+}
+
+func applyMigration(ctx context.Context, conn *pgxpool.Conn, m migration) error {
+	logger.Get().Info("Applying migration", zap.Int("version", m.version), zap.String("name", m.name))
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			logger.Get().Error("rollback error", zap.Error(err))
+		}
+	}()
+
+	_, err = tx.Exec(ctx, m.query)
+	if err != nil {
+		return fmt.Errorf("failed to apply migration %d %s: %w", m.version, m.name, err)
+	}
+
+	_, err = tx.Exec(ctx, queryInsertMigration, m.version, m.name)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d %s: %w", m.version, m.name, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migration %d %s: %w", m.version, m.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS metrics (
+	id varchar(150) primary key,
+	delta bigint null,
+	value double precision null
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS metric_samples (
+	id varchar(150) not null,
+	mtype varchar(16) not null,
+	ts timestamptz not null default now(),
+	delta bigint null,
+	value double precision null
+);
+ALTER TABLE metric_samples ADD COLUMN IF NOT EXISTS increment bigint null;
+CREATE INDEX IF NOT EXISTS metric_samples_mtype_id_ts_idx ON metric_samples (mtype, id, ts);
+CREATE INDEX IF NOT EXISTS metric_samples_ts_idx ON metric_samples (ts);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS metric_rollups (
+	id varchar(150) not null,
+	mtype varchar(16) not null,
+	resolution bigint not null,
+	ts timestamptz not null,
+	count bigint not null,
+	min double precision null,
+	max double precision null,
+	avg double precision null,
+	last double precision null,
+	sum bigint null,
+	primary key (mtype, id, resolution, ts)
+);
@@ -0,0 +1,4 @@
+-- Series keys with labels do not fit varchar(150).
+ALTER TABLE metrics ALTER COLUMN id TYPE text;
+ALTER TABLE metric_samples ALTER COLUMN id TYPE text;
+ALTER TABLE metric_rollups ALTER COLUMN id TYPE text;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS histograms (
+	id text primary key,
+	bounds double precision[] not null,
+	counts bigint[] not null,
+	sum double precision not null
+);
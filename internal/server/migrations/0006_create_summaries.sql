@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS summaries (
+	id text primary key,
+	sketch jsonb not null
+);
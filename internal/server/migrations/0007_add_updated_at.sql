@@ -0,0 +1,7 @@
+-- Last update time of metrics, used for expiry.
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS updated_at timestamptz not null default now();
+ALTER TABLE histograms ADD COLUMN IF NOT EXISTS updated_at timestamptz not null default now();
+ALTER TABLE summaries ADD COLUMN IF NOT EXISTS updated_at timestamptz not null default now();
+CREATE INDEX IF NOT EXISTS metrics_updated_at_idx ON metrics (updated_at);
+CREATE INDEX IF NOT EXISTS histograms_updated_at_idx ON histograms (updated_at);
+CREATE INDEX IF NOT EXISTS summaries_updated_at_idx ON summaries (updated_at);
@@ -0,0 +1,10 @@
+-- Alert silences. An empty metric_type matches metrics of any type.
+CREATE TABLE IF NOT EXISTS silences (
+	id text primary key,
+	metric_id text not null,
+	metric_type text not null default '',
+	starts_at timestamptz not null,
+	ends_at timestamptz not null,
+	author text not null,
+	comment text not null default ''
+);
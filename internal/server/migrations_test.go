@@ -0,0 +1,70 @@
+package server
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.sql": {Data: []byte("SELECT 2;")},
+		"0010_tenth.sql":  {Data: []byte("SELECT 10;")},
+		"0001_first.sql":  {Data: []byte("SELECT 1;")},
+		"README.md":       {Data: []byte("not a migration")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, migration{version: 1, name: "first", query: "SELECT 1;"}, migrations[0])
+	assert.Equal(t, 2, migrations[1].version)
+	assert.Equal(t, 10, migrations[2].version)
+	assert.Equal(t, "tenth", migrations[2].name)
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "no name",
+			fsys: fstest.MapFS{"0001.sql": {}},
+		},
+		{
+			name: "bad version",
+			fsys: fstest.MapFS{"first_create.sql": {}},
+		},
+		{
+			name: "zero version",
+			fsys: fstest.MapFS{"0000_create.sql": {}},
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{"0001_create.sql": {}, "1_alter.sql": {}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := embeddedMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.version, "migrations must be numbered without gaps")
+		assert.NotEmpty(t, m.query)
+	}
+	assert.Equal(t, baselineVersion, migrations[0].version)
+	assert.Contains(t, migrations[0].query, "CREATE TABLE IF NOT EXISTS metrics")
+}
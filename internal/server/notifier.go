@@ -0,0 +1,311 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/etoneja/go-metrics/internal/models"
+	"go.uber.org/zap"
+)
+
+// webhookRetryInterval is how often notifications that failed every attempt
+// of the backoff schedule are retried.
+const webhookRetryInterval = 30 * time.Second
+
+// webhookPayload is the JSON body POSTed to webhooks.
+type webhookPayload struct {
+	Alerts []models.Alert `json:"alerts"`
+}
+
+// outboxState is what AlertNotifier persists: alerts not delivered yet and
+// the last delivered state of every rule, per webhook URL.
+type outboxState struct {
+	Pending map[string][]models.Alert               `json:"pending"`
+	Sent    map[string]map[string]models.AlertState `json:"sent"`
+}
+
+// errWebhookRejected is returned for responses that are not worth retrying.
+var errWebhookRejected = errors.New("webhook rejected notification")
+
+// AlertNotifier delivers alert state changes to webhooks.
+//
+// Changes are queued per webhook in an outbox, at most one per rule: a newer
+// state replaces an undelivered one, and a state equal to the last delivered
+// one is dropped, so restarts and flapping do not repeat notifications. All
+// queued alerts of a webhook are sent in one request, signed like agent
+// requests with the HashSHA256 header when a key is set, and retried with
+// the backoff schedule and then every webhookRetryInterval. With a path the
+// outbox is kept in a file and survives restarts.
+type AlertNotifier struct {
+	urls    []string
+	hashKey string
+	path    string
+	client  *http.Client
+	backoff []time.Duration
+
+	mu    sync.Mutex
+	state outboxState
+
+	wake     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopChan chan struct{}
+	doneChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAlertNotifier returns a notifier for webhook urls with the outbox
+// loaded from path, an empty path keeps the outbox in memory.
+func NewAlertNotifier(urls []string, hashKey string, path string) (*AlertNotifier, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	an := &AlertNotifier{
+		urls:    urls,
+		hashKey: hashKey,
+		path:    path,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		backoff: common.DefaultBackoffSchedule,
+		state: outboxState{
+			Pending: make(map[string][]models.Alert),
+			Sent:    make(map[string]map[string]models.AlertState),
+		},
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+
+	if err := an.load(); err != nil {
+		cancel()
+		return nil, err
+	}
+	return an, nil
+}
+
+// load reads the outbox file, webhooks that are no longer configured are
+// dropped.
+func (an *AlertNotifier) load() error {
+	if an.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(an.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read alert outbox: %w", err)
+	}
+	var state outboxState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse alert outbox %s: %w", an.path, err)
+	}
+
+	for _, url := range an.urls {
+		if len(state.Pending[url]) > 0 {
+			an.state.Pending[url] = state.Pending[url]
+		}
+		if len(state.Sent[url]) > 0 {
+			an.state.Sent[url] = state.Sent[url]
+		}
+	}
+	return nil
+}
+
+// persist writes the outbox to a temp file and renames it over the outbox
+// file, the caller must hold mu.
+func (an *AlertNotifier) persist() {
+	if an.path == "" {
+		return
+	}
+
+	data, err := json.Marshal(an.state)
+	if err != nil {
+		logger.Get().Error("Failed to encode alert outbox", zap.Error(err))
+		return
+	}
+	tmpPath := an.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		logger.Get().Error("Failed to write alert outbox", zap.Error(err))
+		return
+	}
+	if err := os.Rename(tmpPath, an.path); err != nil {
+		logger.Get().Error("Failed to rename alert outbox", zap.Error(err))
+	}
+}
+
+// Notify queues alert state changes for every webhook.
+func (an *AlertNotifier) Notify(alerts []models.Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	an.mu.Lock()
+	for _, url := range an.urls {
+		pending := an.state.Pending[url]
+		for _, alert := range alerts {
+			pending = slices.DeleteFunc(pending, func(queued models.Alert) bool {
+				return queued.Rule == alert.Rule
+			})
+			if an.state.Sent[url][alert.Rule] == alert.State {
+				continue
+			}
+			pending = append(pending, alert)
+		}
+		an.state.Pending[url] = pending
+	}
+	an.persist()
+	an.mu.Unlock()
+
+	select {
+	case an.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start delivers queued notifications until Stop.
+func (an *AlertNotifier) Start() {
+	ticker := time.NewTicker(webhookRetryInterval)
+
+	go func() {
+		defer close(an.doneChan)
+		defer ticker.Stop()
+		// The outbox may hold notifications from before a restart.
+		an.flush()
+		for {
+			select {
+			case <-an.wake:
+				an.flush()
+			case <-ticker.C:
+				an.flush()
+			case <-an.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop aborts deliveries in progress, undelivered notifications stay in
+// the outbox.
+func (an *AlertNotifier) Stop() {
+	an.stopOnce.Do(func() {
+		an.cancel()
+		close(an.stopChan)
+	})
+	<-an.doneChan
+}
+
+// flush delivers queued alerts of every webhook.
+func (an *AlertNotifier) flush() {
+	for _, url := range an.urls {
+		an.mu.Lock()
+		alerts := slices.Clone(an.state.Pending[url])
+		an.mu.Unlock()
+		if len(alerts) == 0 {
+			continue
+		}
+
+		err := an.deliver(url, alerts)
+		if err != nil && !errors.Is(err, errWebhookRejected) {
+			logger.Get().Warn("Failed to deliver alerts, will retry",
+				zap.String("url", url),
+				zap.Int("alerts", len(alerts)),
+				zap.Error(err),
+			)
+			continue
+		}
+		if err != nil {
+			logger.Get().Error("Alerts dropped",
+				zap.String("url", url),
+				zap.Int("alerts", len(alerts)),
+				zap.Error(err),
+			)
+		}
+		an.delivered(url, alerts, err == nil)
+	}
+}
+
+// delivered removes alerts from the outbox, alerts queued during delivery
+// stay. Sent states are recorded only if the webhook accepted them.
+func (an *AlertNotifier) delivered(url string, alerts []models.Alert, accepted bool) {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+
+	an.state.Pending[url] = slices.DeleteFunc(an.state.Pending[url], func(queued models.Alert) bool {
+		return slices.ContainsFunc(alerts, func(alert models.Alert) bool {
+			return alert.Rule == queued.Rule && alert.State == queued.State && alert.ActiveAt.Equal(queued.ActiveAt)
+		})
+	})
+	if accepted {
+		if an.state.Sent[url] == nil {
+			an.state.Sent[url] = make(map[string]models.AlertState)
+		}
+		for _, alert := range alerts {
+			an.state.Sent[url][alert.Rule] = alert.State
+		}
+	}
+	an.persist()
+}
+
+// deliver POSTs alerts to url with retries.
+func (an *AlertNotifier) deliver(url string, alerts []models.Alert) error {
+	body, err := json.Marshal(webhookPayload{Alerts: alerts})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errWebhookRejected, err)
+	}
+
+	attemptNum := 0
+	for range common.GetBackoffTicker(an.ctx, an.backoff) {
+		attemptNum++
+		err = an.post(url, body)
+		if err == nil || errors.Is(err, errWebhookRejected) {
+			return err
+		}
+		logger.Get().Debug("Webhook attempt failed",
+			zap.String("attempt", fmt.Sprintf("[%d/%d]", attemptNum, len(an.backoff)+1)),
+			zap.Error(err),
+		)
+	}
+	if err == nil {
+		err = an.ctx.Err()
+	}
+	return err
+}
+
+func (an *AlertNotifier) post(url string, body []byte) error {
+	req, err := http.NewRequestWithContext(an.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errWebhookRejected, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if an.hashKey != "" {
+		req.Header.Set(common.HashHeaderKey, common.ComputeHash(an.hashKey, body))
+	}
+
+	resp, err := an.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests:
+		return fmt.Errorf("%w: http %d", errWebhookRejected, resp.StatusCode)
+	default:
+		return fmt.Errorf("http %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver records notifications, answering with the queued status
+// codes first and 200 OK after them.
+type webhookReceiver struct {
+	t        *testing.T
+	hashKey  string
+	mu       sync.Mutex
+	statuses []int
+	attempts int
+	received []webhookPayload
+}
+
+func newWebhookReceiver(t *testing.T, hashKey string, statuses ...int) (*webhookReceiver, *httptest.Server) {
+	wr := &webhookReceiver{t: t, hashKey: hashKey, statuses: statuses}
+	server := httptest.NewServer(wr)
+	t.Cleanup(server.Close)
+	return wr, server
+}
+
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(wr.t, err)
+	if wr.hashKey != "" {
+		assert.Equal(wr.t, common.ComputeHash(wr.hashKey, body), r.Header.Get(common.HashHeaderKey))
+	}
+
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	wr.attempts++
+	if len(wr.statuses) > 0 {
+		status := wr.statuses[0]
+		wr.statuses = wr.statuses[1:]
+		w.WriteHeader(status)
+		return
+	}
+
+	var payload webhookPayload
+	require.NoError(wr.t, json.Unmarshal(body, &payload))
+	wr.received = append(wr.received, payload)
+}
+
+func (wr *webhookReceiver) payloads() []webhookPayload {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return append([]webhookPayload(nil), wr.received...)
+}
+
+func (wr *webhookReceiver) attemptCount() int {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return wr.attempts
+}
+
+func newTestAlertNotifier(t *testing.T, urls []string, hashKey, path string) *AlertNotifier {
+	t.Helper()
+	an, err := NewAlertNotifier(urls, hashKey, path)
+	require.NoError(t, err)
+	an.backoff = []time.Duration{10 * time.Millisecond, 10 * time.Millisecond}
+	return an
+}
+
+func testAlert(rule string, state models.AlertState) models.Alert {
+	return models.Alert{
+		Rule:     rule,
+		Expr:     "gauge g > 1",
+		State:    state,
+		Value:    2,
+		ActiveAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func (an *AlertNotifier) pendingCount(url string) int {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+	return len(an.state.Pending[url])
+}
+
+func TestAlertNotifier_GroupsAndSigns(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, "secret")
+	an := newTestAlertNotifier(t, []string{server.URL}, "secret", "")
+
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring), testAlert("b", models.AlertStateFiring)})
+	an.flush()
+
+	payloads := receiver.payloads()
+	require.Len(t, payloads, 1)
+	require.Len(t, payloads[0].Alerts, 2)
+	assert.Equal(t, "a", payloads[0].Alerts[0].Rule)
+	assert.Equal(t, "b", payloads[0].Alerts[1].Rule)
+	assert.Equal(t, 0, an.pendingCount(server.URL))
+}
+
+func TestAlertNotifier_Dedup(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, "")
+	an := newTestAlertNotifier(t, []string{server.URL}, "", "")
+
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+	an.flush()
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+	an.flush()
+	assert.Len(t, receiver.payloads(), 1, "the same state is sent once")
+
+	// Undelivered states are replaced by newer ones.
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateResolved)})
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+	assert.Equal(t, 0, an.pendingCount(server.URL), "back to the delivered state")
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateResolved)})
+	an.flush()
+
+	payloads := receiver.payloads()
+	require.Len(t, payloads, 2)
+	require.Len(t, payloads[1].Alerts, 1)
+	assert.Equal(t, models.AlertStateResolved, payloads[1].Alerts[0].State)
+}
+
+func TestAlertNotifier_Retry(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, "", http.StatusInternalServerError, http.StatusServiceUnavailable)
+	an := newTestAlertNotifier(t, []string{server.URL}, "", "")
+
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+	an.flush()
+
+	assert.Equal(t, 3, receiver.attemptCount())
+	assert.Len(t, receiver.payloads(), 1)
+	assert.Equal(t, 0, an.pendingCount(server.URL))
+}
+
+func TestAlertNotifier_RetryExhaustedKeepsOutbox(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, "",
+		http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	an := newTestAlertNotifier(t, []string{server.URL}, "", "")
+
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+	an.flush()
+	assert.Equal(t, 3, receiver.attemptCount())
+	assert.Equal(t, 1, an.pendingCount(server.URL))
+
+	an.flush()
+	assert.Len(t, receiver.payloads(), 1)
+	assert.Equal(t, 0, an.pendingCount(server.URL))
+}
+
+func TestAlertNotifier_RejectedIsDropped(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, "", http.StatusBadRequest)
+	an := newTestAlertNotifier(t, []string{server.URL}, "", "")
+
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+	an.flush()
+	assert.Equal(t, 1, receiver.attemptCount())
+	assert.Equal(t, 0, an.pendingCount(server.URL))
+
+	// A rejected state was not delivered, so it is not deduplicated.
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+	an.flush()
+	assert.Len(t, receiver.payloads(), 1)
+}
+
+func TestAlertNotifier_OutboxSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	receiver, server := newWebhookReceiver(t, "",
+		http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+
+	an := newTestAlertNotifier(t, []string{server.URL}, "", path)
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+	an.flush()
+	require.Empty(t, receiver.payloads())
+
+	// The notifier delivers the outbox as soon as it starts.
+	restarted := newTestAlertNotifier(t, []string{server.URL, "http://localhost:1/other"}, "", path)
+	require.Equal(t, 1, restarted.pendingCount(server.URL))
+	restarted.Start()
+	require.Eventually(t, func() bool {
+		return len(receiver.payloads()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	restarted.Stop()
+
+	// Delivered states are remembered across restarts too.
+	restarted = newTestAlertNotifier(t, []string{server.URL}, "", path)
+	restarted.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+	assert.Equal(t, 0, restarted.pendingCount(server.URL))
+}
+
+func TestAlertNotifier_StopAbortsRetries(t *testing.T) {
+	statuses := make([]int, 100)
+	for i := range statuses {
+		statuses[i] = http.StatusInternalServerError
+	}
+	_, server := newWebhookReceiver(t, "", statuses...)
+	an := newTestAlertNotifier(t, []string{server.URL}, "", "")
+	an.backoff = []time.Duration{time.Hour}
+
+	an.Start()
+	an.Notify([]models.Alert{testAlert("a", models.AlertStateFiring)})
+
+	stopped := make(chan struct{})
+	go func() {
+		an.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop waits for retries")
+	}
+	assert.Equal(t, 1, an.pendingCount(server.URL))
+}
+
+func TestAlertEngine_Notifies(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, "")
+	an := newTestAlertNotifier(t, []string{server.URL}, "", "")
+
+	store := NewMemStorage()
+	ae := NewAlertEngine(store, []*AlertRule{
+		mustParseAlertRule(t, "High", "gauge g > 1"),
+		mustParseAlertRule(t, "Slow", "gauge g > 1 for 1m"),
+	})
+	ae.SetNotifier(an)
+	now := time.Now()
+
+	_, err := store.SetGauge(t.Context(), "g", 2)
+	require.NoError(t, err)
+	ae.evaluate(t.Context(), now)
+	ae.evaluate(t.Context(), now.Add(10*time.Second))
+	an.flush()
+	payloads := receiver.payloads()
+	require.Len(t, payloads, 1, "pending alerts are not sent, firing ones once")
+	require.Len(t, payloads[0].Alerts, 1)
+	assert.Equal(t, "High", payloads[0].Alerts[0].Rule)
+
+	_, err = store.SetGauge(t.Context(), "g", 0)
+	require.NoError(t, err)
+	ae.evaluate(t.Context(), now.Add(20*time.Second))
+	an.flush()
+	payloads = receiver.payloads()
+	require.Len(t, payloads, 2)
+	assert.Equal(t, models.AlertStateResolved, payloads[1].Alerts[0].State)
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+)
+
+const (
+	prometheusTextVersion     = "0.0.4"
+	prometheusTextContentType = "text/plain; version=" + prometheusTextVersion + "; charset=utf-8"
+)
+
+// sanitizePrometheusName converts a metric ID into a valid Prometheus metric name.
+//
+// Every character outside [a-zA-Z0-9_:] is replaced with an underscore and
+// a leading digit is prefixed with an underscore.
+func sanitizePrometheusName(id string) string {
+	var b strings.Builder
+	b.Grow(len(id) + 1)
+
+	for i, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+func formatPrometheusFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// escapePrometheusLabelValue escapes backslash, double quote and line feed
+// as required by the text exposition format.
+func escapePrometheusLabelValue(v string) string {
+	return prometheusLabelValueReplacer.Replace(v)
+}
+
+var prometheusLabelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatPrometheusLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := slices.Sorted(maps.Keys(labels))
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(sanitizePrometheusLabelName(name))
+		b.WriteString(`="`)
+		b.WriteString(escapePrometheusLabelValue(labels[name]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// sanitizePrometheusLabelName converts a label name into a valid Prometheus
+// label name, which unlike metric names may not contain colons.
+func sanitizePrometheusLabelName(name string) string {
+	return strings.ReplaceAll(sanitizePrometheusName(name), ":", "_")
+}
+
+// writePrometheusHistogram writes cumulative _bucket series with the le label
+// followed by _sum and _count series of a histogram.
+func writePrometheusHistogram(w io.Writer, name string, m models.MetricModel) error {
+	labels := make(map[string]string, len(m.Labels)+1)
+	maps.Copy(labels, m.Labels)
+
+	var cumulative uint64
+	for i, c := range m.Histogram.Counts {
+		cumulative += c
+		le := math.Inf(1)
+		if i < len(m.Histogram.Bounds) {
+			le = m.Histogram.Bounds[i]
+		}
+		labels["le"] = formatPrometheusFloat(le)
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", name, formatPrometheusLabels(labels), cumulative); err != nil {
+			return err
+		}
+	}
+
+	seriesLabels := formatPrometheusLabels(m.Labels)
+	if _, err := fmt.Fprintf(w, "%s_sum%s %s\n", name, seriesLabels, formatPrometheusFloat(m.Histogram.Sum)); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s_count%s %d\n", name, seriesLabels, cumulative)
+	return err
+}
+
+// writePrometheusSummary writes series with the quantile label followed by
+// _sum and _count series of a summary.
+func writePrometheusSummary(w io.Writer, name string, m models.MetricModel) error {
+	labels := make(map[string]string, len(m.Labels)+1)
+	maps.Copy(labels, m.Labels)
+
+	quantiles := slices.Collect(maps.Keys(m.Summary.Quantiles))
+	slices.SortFunc(quantiles, func(a, b string) int {
+		qa, _ := strconv.ParseFloat(a, 64)
+		qb, _ := strconv.ParseFloat(b, 64)
+		return cmp.Compare(qa, qb)
+	})
+	for _, q := range quantiles {
+		labels["quantile"] = q
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", name, formatPrometheusLabels(labels), formatPrometheusFloat(m.Summary.Quantiles[q])); err != nil {
+			return err
+		}
+	}
+
+	seriesLabels := formatPrometheusLabels(m.Labels)
+	if _, err := fmt.Fprintf(w, "%s_sum%s %s\n", name, seriesLabels, formatPrometheusFloat(m.Summary.Sum)); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s_count%s %d\n", name, seriesLabels, m.Summary.Count)
+	return err
+}
+
+func isPrometheusType(mType string) bool {
+	switch mType {
+	case common.MetricTypeCounter, common.MetricTypeGauge, common.MetricTypeHistogram, common.MetricTypeSummary:
+		return true
+	}
+	return false
+}
+
+// writePrometheusText renders metrics in the Prometheus text exposition format.
+//
+// Series are grouped by sanitized metric name with a single TYPE line per
+// name. Series whose name is already used by a metric of another type, or
+// whose name and labels duplicate an already written series, are skipped and
+// returned so the caller can report them.
+func writePrometheusText(w io.Writer, metrics []models.MetricModel) ([]models.MetricModel, error) {
+	type family struct {
+		mType  string
+		series []models.MetricModel
+	}
+
+	families := make(map[string]*family)
+	var names []string
+	seen := make(map[string]struct{}, len(metrics))
+	var skipped []models.MetricModel
+
+	for _, m := range metrics {
+		if !isPrometheusType(m.MType) {
+			skipped = append(skipped, m)
+			continue
+		}
+
+		name := sanitizePrometheusName(m.ID)
+		f, ok := families[name]
+		if !ok {
+			f = &family{mType: m.MType}
+			families[name] = f
+			names = append(names, name)
+		}
+
+		seriesKey := name + formatPrometheusLabels(m.Labels)
+		if _, dup := seen[seriesKey]; dup || f.mType != m.MType {
+			skipped = append(skipped, m)
+			continue
+		}
+		seen[seriesKey] = struct{}{}
+		f.series = append(f.series, m)
+	}
+
+	for _, name := range names {
+		f := families[name]
+		if _, err := fmt.Fprintf(w, "# TYPE %s %s\n", name, f.mType); err != nil {
+			return skipped, err
+		}
+
+		for _, m := range f.series {
+			switch m.MType {
+			case common.MetricTypeHistogram:
+				if err := writePrometheusHistogram(w, name, m); err != nil {
+					return skipped, err
+				}
+				continue
+			case common.MetricTypeSummary:
+				if err := writePrometheusSummary(w, name, m); err != nil {
+					return skipped, err
+				}
+				continue
+			}
+
+			var value string
+			if m.MType == common.MetricTypeCounter {
+				value = strconv.FormatInt(*m.Delta, 10)
+			} else {
+				value = formatPrometheusFloat(*m.Value)
+			}
+
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", name, formatPrometheusLabels(m.Labels), value); err != nil {
+				return skipped, err
+			}
+		}
+	}
+
+	return skipped, nil
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizePrometheusName(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected string
+	}{
+		{"HeapAlloc", "HeapAlloc"},
+		{"http_requests:rate5m", "http_requests:rate5m"},
+		{"cpu.usage-1", "cpu_usage_1"},
+		{"1st", "_1st"},
+		{"temp °C", "temp__C"},
+		{"", "_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizePrometheusName(tt.id))
+		})
+	}
+}
+
+func TestFormatPrometheusFloat(t *testing.T) {
+	assert.Equal(t, "1.5", formatPrometheusFloat(1.5))
+	assert.Equal(t, "1e+21", formatPrometheusFloat(1e21))
+	assert.Equal(t, "NaN", formatPrometheusFloat(math.NaN()))
+	assert.Equal(t, "+Inf", formatPrometheusFloat(math.Inf(1)))
+	assert.Equal(t, "-Inf", formatPrometheusFloat(math.Inf(-1)))
+}
+
+func TestWritePrometheusText(t *testing.T) {
+	metrics := []models.MetricModel{
+		*models.NewMetricModel("PollCount", common.MetricTypeCounter, 42, 0),
+		*models.NewMetricModel("cpu.usage", common.MetricTypeGauge, 0, 95.5),
+		*models.NewMetricModel("cpu-usage", common.MetricTypeGauge, 0, 1),
+	}
+
+	var buf bytes.Buffer
+	skipped, err := writePrometheusText(&buf, metrics)
+	require.NoError(t, err)
+
+	expected := "# TYPE PollCount counter\n" +
+		"PollCount 42\n" +
+		"# TYPE cpu_usage gauge\n" +
+		"cpu_usage 95.5\n"
+	assert.Equal(t, expected, buf.String())
+
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "cpu-usage", skipped[0].ID)
+}
+
+func TestWritePrometheusText_Labels(t *testing.T) {
+	withLabels := func(m *models.MetricModel, labels map[string]string) models.MetricModel {
+		m.Labels = labels
+		return *m
+	}
+
+	metrics := []models.MetricModel{
+		withLabels(models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 1), map[string]string{"host": "a"}),
+		withLabels(models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 2), map[string]string{"host": "b", "path": "C:\\x \"y\"\n"}),
+		withLabels(models.NewMetricModel("Alloc", common.MetricTypeCounter, 3, 0), map[string]string{"host": "c"}),
+		withLabels(models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 4), map[string]string{"host": "a"}),
+		withLabels(models.NewMetricModel("Count", common.MetricTypeCounter, 5, 0), map[string]string{"a:b": "x"}),
+	}
+
+	var buf bytes.Buffer
+	skipped, err := writePrometheusText(&buf, metrics)
+	require.NoError(t, err)
+
+	expected := "# TYPE Alloc gauge\n" +
+		"Alloc{host=\"a\"} 1\n" +
+		"Alloc{host=\"b\",path=\"C:\\\\x \\\"y\\\"\\n\"} 2\n" +
+		"# TYPE Count counter\n" +
+		"Count{a_b=\"x\"} 5\n"
+	assert.Equal(t, expected, buf.String())
+
+	require.Len(t, skipped, 2)
+	assert.Equal(t, common.MetricTypeCounter, skipped[0].MType)
+	assert.Equal(t, 4.0, *skipped[1].Value)
+}
+
+func TestWritePrometheusText_Histogram(t *testing.T) {
+	h := models.NewHistogram([]float64{0.5, 1})
+	for _, v := range []float64{0.1, 0.7, 0.9, 3} {
+		h.Observe(v)
+	}
+	m := models.NewHistogramMetricModel("latency", h)
+	m.Labels = map[string]string{"host": "a"}
+
+	var buf bytes.Buffer
+	skipped, err := writePrometheusText(&buf, []models.MetricModel{*m})
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+
+	expected := "# TYPE latency histogram\n" +
+		"latency_bucket{host=\"a\",le=\"0.5\"} 1\n" +
+		"latency_bucket{host=\"a\",le=\"1\"} 3\n" +
+		"latency_bucket{host=\"a\",le=\"+Inf\"} 4\n" +
+		"latency_sum{host=\"a\"} 4.7\n" +
+		"latency_count{host=\"a\"} 4\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWritePrometheusText_Summary(t *testing.T) {
+	sketch := models.NewQuantileSketch()
+	sketch.Add(2)
+	m := models.NewSummaryMetricModel("latency", sketch)
+
+	var buf bytes.Buffer
+	skipped, err := writePrometheusText(&buf, []models.MetricModel{*m})
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+
+	expected := "# TYPE latency summary\n" +
+		"latency{quantile=\"0.5\"} 2\n" +
+		"latency{quantile=\"0.9\"} 2\n" +
+		"latency{quantile=\"0.99\"} 2\n" +
+		"latency_sum 2\n" +
+		"latency_count 1\n"
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/etoneja/go-metrics/internal/proto"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+const (
+	// remoteWriteMaxSize limits both the compressed and the decoded size
+	// of remote_write requests.
+	remoteWriteMaxSize = 32 << 20
+	// remoteWriteBatchSize is the number of metrics written per
+	// BatchUpdate.
+	remoteWriteBatchSize = 5000
+	// remoteWriteNameLabel is the label holding the metric name.
+	remoteWriteNameLabel = "__name__"
+	// remoteWriteV2ContentType is the content type of remote_write 2.0,
+	// which is answered with 415 so senders fall back to 1.0.
+	remoteWriteV2ContentType = "io.prometheus.write.v2.Request"
+)
+
+// remoteWriteStats counts what a remote_write request contained.
+type remoteWriteStats struct {
+	series  int
+	samples int
+	// skipped counts non-finite samples, e.g. staleness markers.
+	skipped int
+}
+
+// decodeRemoteWrite decodes a snappy compressed WriteRequest.
+func decodeRemoteWrite(body []byte) (*proto.RemoteWriteRequest, error) {
+	data, err := snappyDecode(body, remoteWriteMaxSize)
+	if err != nil {
+		return nil, err
+	}
+	var req proto.RemoteWriteRequest
+	if err := protobuf.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("invalid write request: %w", err)
+	}
+	return &req, nil
+}
+
+// remoteWriteMetrics maps time series to gauges named by __name__ with the
+// other labels, internal ones starting with __ are dropped. Prometheus
+// counters are cumulative, so every series is stored as a gauge holding the
+// value of its latest sample. Series repeated in the request are merged.
+// Series that cannot be stored are reported as errors and left out.
+func remoteWriteMetrics(req *proto.RemoteWriteRequest) ([]models.MetricModel, remoteWriteStats, []error) {
+	var stats remoteWriteStats
+	var errs []error
+
+	type latest struct {
+		metric    models.MetricModel
+		timestamp int64
+	}
+	seen := make(map[string]int)
+	var series []latest
+
+	for _, ts := range req.GetTimeseries() {
+		stats.series++
+		stats.samples += len(ts.GetSamples())
+
+		var id string
+		labels := make(map[string]string, len(ts.GetLabels()))
+		for _, l := range ts.GetLabels() {
+			switch {
+			case l.GetName() == remoteWriteNameLabel:
+				id = l.GetValue()
+			case strings.HasPrefix(l.GetName(), "__"):
+			default:
+				labels[l.GetName()] = l.GetValue()
+			}
+		}
+		if id == "" {
+			errs = append(errs, fmt.Errorf("invalid metric name %q", id))
+			continue
+		}
+		if err := models.ValidateID(id); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := models.ValidateLabels(labels); err != nil {
+			errs = append(errs, fmt.Errorf("series %q: %w", id, err))
+			continue
+		}
+
+		found := false
+		var value float64
+		var timestamp int64
+		for _, s := range ts.GetSamples() {
+			if math.IsNaN(s.GetValue()) || math.IsInf(s.GetValue(), 0) {
+				stats.skipped++
+				continue
+			}
+			if !found || s.GetTimestamp() >= timestamp {
+				value, timestamp, found = s.GetValue(), s.GetTimestamp(), true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		key := models.SeriesKey(id, labels)
+		if i, ok := seen[key]; ok {
+			if timestamp >= series[i].timestamp {
+				series[i].metric.Value = &value
+				series[i].timestamp = timestamp
+			}
+			continue
+		}
+		m := models.NewMetricModel(id, common.MetricTypeGauge, 0, value)
+		if len(labels) > 0 {
+			m.Labels = labels
+		}
+		seen[key] = len(series)
+		series = append(series, latest{metric: *m, timestamp: timestamp})
+	}
+
+	metrics := make([]models.MetricModel, 0, len(series))
+	for _, s := range series {
+		metrics = append(metrics, s.metric)
+	}
+	return metrics, stats, errs
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/etoneja/go-metrics/internal/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// snappyEncode encodes src in the snappy block format for tests. Runs of
+// four bytes seen before become copies, everything else literals.
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	literal := func(b []byte) {
+		for len(b) > 0 {
+			n := min(len(b), 1<<16)
+			if n <= 60 {
+				dst = append(dst, byte(n-1)<<2|snappyTagLiteral)
+			} else {
+				dst = append(dst, 61<<2|snappyTagLiteral, byte(n-1), byte((n-1)>>8))
+			}
+			dst = append(dst, b[:n]...)
+			b = b[n:]
+		}
+	}
+
+	seen := make(map[[4]byte]int)
+	start := 0
+	for i := 0; i+4 <= len(src); {
+		key := [4]byte(src[i : i+4])
+		prev, ok := seen[key]
+		seen[key] = i
+		if !ok || i-prev > math.MaxUint16 {
+			i++
+			continue
+		}
+		length := 4
+		for i+length < len(src) && length < 64 && src[prev+length] == src[i+length] {
+			length++
+		}
+		literal(src[start:i])
+		dst = append(dst, byte(length-1)<<2|snappyTagCopy2, byte(i-prev), byte((i-prev)>>8))
+		i += length
+		start = i
+	}
+	literal(src[start:])
+	return dst
+}
+
+func TestSnappyDecode(t *testing.T) {
+	inputs := [][]byte{
+		{},
+		[]byte("a"),
+		[]byte(strings.Repeat("abcd", 100)),
+		[]byte(strings.Repeat("x", 70000)),
+		bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6, 7}, 10000),
+	}
+	for _, input := range inputs {
+		decoded, err := snappyDecode(snappyEncode(input), remoteWriteMaxSize)
+		require.NoError(t, err)
+		assert.Equal(t, input, decoded)
+	}
+
+	// A one byte literal and an overlapping 1-byte-offset copy of 8 bytes.
+	decoded, err := snappyDecode([]byte{9, 0 << 2, 'z', 4<<2 | snappyTagCopy1, 1}, 16)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("zzzzzzzzz"), decoded)
+
+	// The same with a 4 byte offset.
+	decoded, err = snappyDecode([]byte{4, 0 << 2, 'z', 2<<2 | snappyTagCopy4, 1, 0, 0, 0}, 16)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("zzzz"), decoded)
+}
+
+func TestSnappyDecode_Corrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+	}{
+		{"empty", nil},
+		{"short literal", []byte{5, 4 << 2, 'a', 'b'}},
+		{"short literal length", []byte{100, 60 << 2}},
+		{"copy before output", []byte{4, 3<<2 | snappyTagCopy2, 1, 0}},
+		{"zero offset", []byte{5, 0 << 2, 'a', 0<<2 | snappyTagCopy1, 0}},
+		{"longer than header", []byte{1, 1 << 2, 'a', 'b'}},
+		{"shorter than header", []byte{3, 1 << 2, 'a', 'b'}},
+		{"truncated copy", []byte{5, 0 << 2, 'a', snappyTagCopy4, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := snappyDecode(tt.src, 1024)
+			assert.ErrorIs(t, err, errSnappyCorrupt)
+		})
+	}
+
+	_, err := snappyDecode(snappyEncode(make([]byte, 100)), 99)
+	assert.ErrorContains(t, err, "exceeds 99 bytes")
+}
+
+func remoteSeries(labels []string, samples ...*proto.RemoteSample) *proto.RemoteTimeSeries {
+	ts := &proto.RemoteTimeSeries{Samples: samples}
+	for i := 0; i+1 < len(labels); i += 2 {
+		ts.Labels = append(ts.Labels, &proto.RemoteLabel{Name: labels[i], Value: labels[i+1]})
+	}
+	return ts
+}
+
+func remoteSample(value float64, timestamp int64) *proto.RemoteSample {
+	return &proto.RemoteSample{Value: value, Timestamp: timestamp}
+}
+
+func TestRemoteWriteMetrics(t *testing.T) {
+	req := &proto.RemoteWriteRequest{Timeseries: []*proto.RemoteTimeSeries{
+		remoteSeries([]string{"__name__", "up", "job", "node"}, remoteSample(1, 1000)),
+		remoteSeries([]string{"__name__", "load", "__replica__", "a"},
+			remoteSample(3, 3000), remoteSample(1, 1000), remoteSample(math.NaN(), 4000)),
+		// The same series again, older and newer.
+		remoteSeries([]string{"job", "node", "__name__", "up"}, remoteSample(0, 500)),
+		remoteSeries([]string{"__name__", "load"}, remoteSample(5, 5000)),
+		// Stale series only.
+		remoteSeries([]string{"__name__", "gone"}, remoteSample(math.Inf(1), 1000)),
+		remoteSeries([]string{"job", "node"}, remoteSample(1, 1000)),
+		remoteSeries([]string{"__name__", "up", "bad-label", "x"}, remoteSample(1, 1000)),
+	}}
+
+	metrics, stats, errs := remoteWriteMetrics(req)
+	assert.Equal(t, remoteWriteStats{series: 7, samples: 9, skipped: 2}, stats)
+	require.Len(t, errs, 2)
+	assert.ErrorContains(t, errs[0], `invalid metric name ""`)
+	assert.ErrorContains(t, errs[1], `invalid label name "bad-label"`)
+
+	require.Len(t, metrics, 2)
+	assert.Equal(t, `up{job="node"}`, metrics[0].SeriesKey())
+	assert.Equal(t, 1.0, *metrics[0].Value)
+	assert.Equal(t, "load", metrics[1].SeriesKey(), "internal labels are dropped")
+	assert.Equal(t, 5.0, *metrics[1].Value)
+}
+
+func TestDecodeRemoteWrite(t *testing.T) {
+	req := &proto.RemoteWriteRequest{Timeseries: []*proto.RemoteTimeSeries{
+		remoteSeries([]string{"__name__", "up"}, remoteSample(1, 1000)),
+	}}
+	data, err := protobuf.Marshal(req)
+	require.NoError(t, err)
+
+	decoded, err := decodeRemoteWrite(snappyEncode(data))
+	require.NoError(t, err)
+	assert.True(t, protobuf.Equal(req, decoded))
+
+	_, err = decodeRemoteWrite(data)
+	assert.Error(t, err, "not compressed")
+	_, err = decodeRemoteWrite(snappyEncode([]byte{0xff, 0xff}))
+	assert.ErrorContains(t, err, "invalid write request")
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/logger"
+	"go.uber.org/zap"
+)
+
+const DefaultHistoryRetention = "raw:24h,1m:30d,1h:365d"
+
+const retentionRawKey = "raw"
+
+// RetentionTier keeps rollups of the given resolution for the Retention period.
+type RetentionTier struct {
+	Resolution time.Duration
+	Retention  time.Duration
+}
+
+// RetentionPolicy describes how long raw samples are kept and which rollups
+// are built from them. Tiers are ordered by resolution, every tier is
+// aggregated from the previous one (the first one from raw samples).
+type RetentionPolicy struct {
+	Raw   time.Duration
+	Tiers []RetentionTier
+}
+
+// parseRetentionDuration parses a Go duration with additional support of days ("30d").
+func parseRetentionDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// ParseRetentionPolicy parses a retention spec like "raw:24h,1m:30d,1h:365d".
+//
+// The "raw" entry sets retention of raw samples, every other entry is
+// "<resolution>:<retention>" of a rollup tier. An empty spec disables retention.
+func ParseRetentionPolicy(spec string) (*RetentionPolicy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	policy := &RetentionPolicy{}
+
+	for _, part := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid retention entry %q", part)
+		}
+
+		retention, err := parseRetentionDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retention entry %q: %w", part, err)
+		}
+		if retention <= 0 {
+			return nil, fmt.Errorf("invalid retention entry %q: retention must be positive", part)
+		}
+
+		if key == retentionRawKey {
+			if policy.Raw != 0 {
+				return nil, fmt.Errorf("duplicate raw retention entry %q", part)
+			}
+			policy.Raw = retention
+			continue
+		}
+
+		resolution, err := parseRetentionDuration(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retention entry %q: %w", part, err)
+		}
+		if resolution < time.Second || resolution%time.Second != 0 {
+			return nil, fmt.Errorf("invalid retention entry %q: resolution must be a whole number of seconds", part)
+		}
+		policy.Tiers = append(policy.Tiers, RetentionTier{Resolution: resolution, Retention: retention})
+	}
+
+	if policy.Raw == 0 {
+		return nil, fmt.Errorf("retention spec %q has no raw entry", spec)
+	}
+
+	sourceResolution := time.Duration(0)
+	sourceRetention := policy.Raw
+	for _, tier := range policy.Tiers {
+		if tier.Resolution <= sourceResolution {
+			return nil, fmt.Errorf("rollup resolutions must be increasing: %s after %s", tier.Resolution, sourceResolution)
+		}
+		if sourceResolution != 0 && tier.Resolution%sourceResolution != 0 {
+			return nil, fmt.Errorf("rollup resolution %s is not a multiple of %s", tier.Resolution, sourceResolution)
+		}
+		if sourceRetention < tier.Resolution {
+			return nil, fmt.Errorf("retention %s is shorter than next rollup resolution %s", sourceRetention, tier.Resolution)
+		}
+		sourceResolution = tier.Resolution
+		sourceRetention = tier.Retention
+	}
+
+	return policy, nil
+}
+
+// checkHistorySize rejects a memory history of size samples per metric that
+// cannot hold the raw samples of policy reported every historyReportInterval.
+func checkHistorySize(size uint, policy *RetentionPolicy) error {
+	if size == 0 || policy == nil {
+		return nil
+	}
+	need := uint(policy.Raw / historyReportInterval)
+	if size < need {
+		return fmt.Errorf("history size %d cannot hold raw retention %s, it needs %d samples at a %s report interval", size, policy.Raw, need, historyReportInterval)
+	}
+	return nil
+}
+
+// alignToResolution returns the start of the bucket of the given resolution
+// that t falls into. Buckets are aligned to the Unix epoch.
+func alignToResolution(t time.Time, resolution time.Duration) time.Time {
+	return t.Add(-time.Duration(t.UnixNano() % int64(resolution)))
+}
+
+// historyCompactor periodically builds rollups and removes expired history.
+type historyCompactor struct {
+	target   HistoryCompactor
+	policy   *RetentionPolicy
+	stopChan chan struct{}
+	doneChan chan struct{}
+	stopOnce sync.Once
+}
+
+func startHistoryCompactor(target HistoryCompactor, policy *RetentionPolicy, period uint) *historyCompactor {
+	hc := &historyCompactor{
+		target:   target,
+		policy:   policy,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+
+	ticker := time.NewTicker(time.Second * time.Duration(period))
+
+	go func() {
+		defer close(hc.doneChan)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				hc.compact()
+			case <-hc.stopChan:
+				return
+			}
+		}
+	}()
+
+	return hc
+}
+
+func (hc *historyCompactor) compact() {
+	start := time.Now()
+	err := hc.target.CompactHistory(context.Background(), hc.policy, start)
+	if err != nil {
+		logger.Get().Error("History compaction error", zap.Error(err))
+		return
+	}
+	logger.Get().Debug("History compacted", zap.Duration("duration", time.Since(start)))
+}
+
+func (hc *historyCompactor) stop() {
+	hc.stopOnce.Do(func() {
+		close(hc.stopChan)
+	})
+	<-hc.doneChan
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected *RetentionPolicy
+		wantErr  bool
+	}{
+		{
+			name:     "empty",
+			spec:     "",
+			expected: nil,
+		},
+		{
+			name: "default",
+			spec: DefaultHistoryRetention,
+			expected: &RetentionPolicy{
+				Raw: 24 * time.Hour,
+				Tiers: []RetentionTier{
+					{Resolution: time.Minute, Retention: 30 * 24 * time.Hour},
+					{Resolution: time.Hour, Retention: 365 * 24 * time.Hour},
+				},
+			},
+		},
+		{
+			name:     "raw only",
+			spec:     " raw:2h ",
+			expected: &RetentionPolicy{Raw: 2 * time.Hour},
+		},
+		{name: "missing raw", spec: "1m:1h", wantErr: true},
+		{name: "duplicate raw", spec: "raw:1h,raw:2h", wantErr: true},
+		{name: "bad entry", spec: "raw", wantErr: true},
+		{name: "bad duration", spec: "raw:forever", wantErr: true},
+		{name: "bad days", spec: "raw:xd", wantErr: true},
+		{name: "zero retention", spec: "raw:0s", wantErr: true},
+		{name: "sub second resolution", spec: "raw:1h,500ms:1h", wantErr: true},
+		{name: "decreasing resolutions", spec: "raw:1d,1h:2d,1m:1d", wantErr: true},
+		{name: "not a multiple", spec: "raw:1d,1m:1d,90s:2d", wantErr: true},
+		{name: "source retention too short", spec: "raw:30s,1m:1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseRetentionPolicy(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
+
+func TestCheckHistorySize(t *testing.T) {
+	policy, err := ParseRetentionPolicy(DefaultHistoryRetention)
+	require.NoError(t, err)
+	assert.NoError(t, checkHistorySize(DefaultHistorySize, policy), "the defaults fit")
+	assert.Error(t, checkHistorySize(1000, policy))
+	assert.NoError(t, checkHistorySize(0, policy), "history disabled")
+	assert.NoError(t, checkHistorySize(1000, nil), "retention disabled")
+}
+
+func TestAlignToResolution(t *testing.T) {
+	ts := time.Date(2025, 3, 4, 5, 6, 7, 8, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 3, 4, 5, 6, 0, 0, time.UTC), alignToResolution(ts, time.Minute).UTC())
+	assert.Equal(t, time.Date(2025, 3, 4, 5, 0, 0, 0, time.UTC), alignToResolution(ts, time.Hour).UTC())
+	assert.Equal(t, int64(0), alignToResolution(ts, 7*time.Second).Unix()%7)
+}
+
+func TestMemStorage_CompactHistory(t *testing.T) {
+	policy, err := ParseRetentionPolicy("raw:2m,1m:10m,5m:1h")
+	require.NoError(t, err)
+
+	storage := NewMemStorage()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	gauges := []float64{4, 1, 3, 10, 2}
+	for i, v := range gauges {
+		ts := base.Add(time.Duration(i) * 20 * time.Second)
+		storage.shard("g").history.record("gauge", "g", sampleAt(ts, "gauge", 0, v))
+		storage.shard("c").history.record("counter", "c", counterSampleAt(ts, int64(i+1)))
+	}
+
+	ctx := context.Background()
+	now := base.Add(90 * time.Second)
+	require.NoError(t, storage.CompactHistory(ctx, policy, now))
+
+	rollups, err := storage.GetRollups(ctx, "gauge", "g", time.Minute, time.Time{}, now)
+	require.NoError(t, err)
+	require.Len(t, rollups, 1)
+	assert.Equal(t, base, rollups[0].Timestamp.UTC())
+	assert.Equal(t, int64(3), rollups[0].Count)
+	assert.Equal(t, 1.0, *rollups[0].Min)
+	assert.Equal(t, 4.0, *rollups[0].Max)
+	assert.Equal(t, 8.0/3, *rollups[0].Avg)
+	assert.Equal(t, 3.0, *rollups[0].Last)
+
+	counterRollups, err := storage.GetRollups(ctx, "counter", "c", time.Minute, time.Time{}, now)
+	require.NoError(t, err)
+	require.Len(t, counterRollups, 1)
+	assert.Equal(t, int64(1+2+3), *counterRollups[0].Sum)
+
+	// Compacting again must not duplicate complete buckets.
+	require.NoError(t, storage.CompactHistory(ctx, policy, now))
+	rollups, err = storage.GetRollups(ctx, "gauge", "g", time.Minute, time.Time{}, now)
+	require.NoError(t, err)
+	assert.Len(t, rollups, 1)
+
+	now = base.Add(5*time.Minute + time.Second)
+	require.NoError(t, storage.CompactHistory(ctx, policy, now))
+
+	rollups, err = storage.GetRollups(ctx, "gauge", "g", time.Minute, time.Time{}, now)
+	require.NoError(t, err)
+	require.Len(t, rollups, 2)
+	assert.Equal(t, 2.0, *rollups[1].Last)
+	assert.Equal(t, int64(2), rollups[1].Count)
+
+	rollups, err = storage.GetRollups(ctx, "gauge", "g", 5*time.Minute, time.Time{}, now)
+	require.NoError(t, err)
+	require.Len(t, rollups, 1)
+	assert.Equal(t, int64(5), rollups[0].Count)
+	assert.Equal(t, 1.0, *rollups[0].Min)
+	assert.Equal(t, 10.0, *rollups[0].Max)
+	assert.Equal(t, 4.0, *rollups[0].Avg)
+	assert.Equal(t, 2.0, *rollups[0].Last)
+
+	// Raw samples are out of retention by now.
+	samples, err := storage.GetHistory(ctx, "gauge", "g", time.Time{}, now)
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+
+	// Everything expires eventually and the metric is forgotten.
+	require.NoError(t, storage.CompactHistory(ctx, policy, now.Add(2*time.Hour)))
+	for _, key := range []string{"g", "c"} {
+		assert.Empty(t, storage.shard(key).history.rings)
+		assert.Empty(t, storage.shard(key).history.rollups)
+	}
+}
+
+type countingCompactor struct {
+	calls atomic.Int32
+}
+
+func (c *countingCompactor) CompactHistory(ctx context.Context, policy *RetentionPolicy, now time.Time) error {
+	c.calls.Add(1)
+	return nil
+}
+
+func TestHistoryCompactor_StartStop(t *testing.T) {
+	target := &countingCompactor{}
+	hc := startHistoryCompactor(target, &RetentionPolicy{Raw: time.Hour}, 1)
+
+	assert.Eventually(t, func() bool { return target.calls.Load() > 0 }, 3*time.Second, 50*time.Millisecond)
+
+	hc.stop()
+	hc.stop()
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-func NewRouter(store Storager, cfg *config) http.Handler {
+func NewRouter(store Storager, cfg *config, health *Health, alerts *AlertEngine) http.Handler {
 	r := chi.NewRouter()
 
 	lg := logger.Get()
@@ -15,20 +15,39 @@ func NewRouter(store Storager, cfg *config) http.Handler {
 	bmw := BaseMiddleware{logger: lg}
 
 	r.Use(bmw.LoggerMiddleware())
-	r.Use(bmw.TrustedIPMiddleware(cfg.TrustedSubnet))
-	r.Use(bmw.DecryptMiddleware(cfg.GetPrivateKey()))
-	r.Use(bmw.HashMiddleware(cfg.HashKey))
-	r.Use(bmw.GzipMiddleware())
+	r.Use(bmw.MetricsMiddleware())
 
 	bh := BaseHandler{store: store, logger: lg}
 
-	r.Get("/", bh.MetricListHandler())
-	r.Post("/update/{metricType}/{metricName}/{metricValue}", bh.MetricUpdateHandler())
-	r.Post("/update/", bh.MetricUpdateJSONHandler())
-	r.Post("/updates/", bh.MetricBatchUpdateJSONHandler())
-	r.Get("/value/{metricType}/{metricName}", bh.MetricGetHandler())
-	r.Post("/value/", bh.MetricGetJSONHandler())
-	r.Get("/ping", bh.PingHandler())
+	// Probes come from load balancers and orchestrators, which neither sign
+	// nor encrypt requests and may be outside the trusted subnet.
+	r.Get("/healthz", bh.LivenessHandler())
+	r.Get("/readyz", bh.ReadinessHandler(health))
+
+	r.Group(func(r chi.Router) {
+		r.Use(bmw.TrustedIPMiddleware(cfg.TrustedSubnet))
+		r.Use(bmw.DecryptMiddleware(cfg.GetPrivateKey()))
+		r.Use(bmw.HashMiddleware(cfg.HashKey))
+		r.Use(bmw.GzipMiddleware())
+
+		r.Get("/", bh.MetricListHandler())
+		r.Post("/update/{metricType}/{metricName}/{metricValue}", bh.MetricUpdateHandler())
+		r.Post("/update/", bh.MetricUpdateJSONHandler())
+		r.Post("/updates/", bh.MetricBatchUpdateJSONHandler())
+		r.Post("/write", bh.InfluxWriteHandler())
+		r.Post("/api/v1/write", bh.RemoteWriteHandler())
+		r.Get("/value/{metricType}/{metricName}", bh.MetricGetHandler())
+		r.Delete("/value/{metricType}/{metricName}", bh.MetricDeleteHandler())
+		r.Post("/value/", bh.MetricGetJSONHandler())
+		r.Get("/ping", bh.PingHandler())
+		r.Get("/metrics", bh.MetricPrometheusHandler())
+		r.Get("/history/{metricType}/{metricName}", bh.MetricHistoryHandler())
+		r.Get("/alerts", bh.AlertsHandler(alerts))
+		r.Post("/alerts/{rule}/ack", bh.AlertAckHandler(alerts))
+		r.Post("/silences", bh.SilenceCreateHandler(alerts))
+		r.Get("/silences", bh.SilenceListHandler(alerts))
+		r.Delete("/silences/{id}", bh.SilenceDeleteHandler(alerts))
+	})
 
 	return r
 }
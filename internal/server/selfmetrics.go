@@ -0,0 +1,99 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+)
+
+// Names of metrics the server records about itself.
+const (
+	selfHTTPRequests          = "http_requests_total"
+	selfHTTPRequestDuration   = "http_request_duration_seconds"
+	selfGRPCRequests          = "grpc_requests_total"
+	selfGRPCRequestDuration   = "grpc_request_duration_seconds"
+	selfStorageDuration       = "storage_operation_duration_seconds"
+	selfStorageErrors         = "storage_operation_errors_total"
+	selfBatchSize             = "batch_update_size"
+	selfDecryptFailures       = "decrypt_failures_total"
+	selfHashFailures          = "hash_failures_total"
+	selfDumpDuration          = "dump_duration_seconds"
+	selfDumpFailures          = "dump_failures_total"
+	selfUnmatchedRoute        = "unmatched"
+	selfLabelRoute            = "route"
+	selfLabelMethod           = "method"
+	selfLabelCode             = "code"
+	selfLabelStorageOperation = "operation"
+)
+
+var (
+	// latencyBounds are histogram bounds of durations in seconds.
+	latencyBounds = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	// batchSizeBounds are histogram bounds of metrics per batch.
+	batchSizeBounds = []float64{1, 10, 50, 100, 500, 1000, 5000, 10000}
+)
+
+// selfRegistry holds counters and histograms the server records about
+// itself. Series are identified like stored metrics, by name and labels.
+type selfRegistry struct {
+	mu         sync.Mutex
+	counters   map[string]int64
+	histograms map[string]*models.Histogram
+}
+
+// selfMetrics is the registry of the server, like the logger it is shared
+// by all components.
+var selfMetrics = newSelfRegistry()
+
+func newSelfRegistry() *selfRegistry {
+	return &selfRegistry{
+		counters:   make(map[string]int64),
+		histograms: make(map[string]*models.Histogram),
+	}
+}
+
+// inc increments a counter.
+func (r *selfRegistry) inc(name string, labels map[string]string) {
+	key := models.SeriesKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters[key]++
+}
+
+// observe adds v to a histogram, bounds are used when the series is new.
+func (r *selfRegistry) observe(name string, labels map[string]string, bounds []float64, v float64) {
+	key := models.SeriesKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.histograms[key]
+	if !ok {
+		h = models.NewHistogram(bounds)
+		r.histograms[key] = h
+	}
+	h.Observe(v)
+}
+
+// observeSince adds the time passed since start to a latency histogram.
+func (r *selfRegistry) observeSince(name string, labels map[string]string, start time.Time) {
+	r.observe(name, labels, latencyBounds, time.Since(start).Seconds())
+}
+
+// snapshot returns all series as metric models.
+func (r *selfRegistry) snapshot() []models.MetricModel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics := make([]models.MetricModel, 0, len(r.counters)+len(r.histograms))
+	for key, value := range r.counters {
+		metrics = append(metrics, *newSeriesMetricModel(key, common.MetricTypeCounter, value, 0))
+	}
+	for key, h := range r.histograms {
+		metrics = append(metrics, *newSeriesHistogramModel(key, h.Clone()))
+	}
+	sortMetricModels(metrics)
+	return metrics
+}
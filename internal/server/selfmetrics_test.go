@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestSelfMetrics replaces the registry of the server for one test.
+func newTestSelfMetrics(t *testing.T) *selfRegistry {
+	t.Helper()
+	prev := selfMetrics
+	selfMetrics = newSelfRegistry()
+	t.Cleanup(func() {
+		selfMetrics = prev
+	})
+	return selfMetrics
+}
+
+func (r *selfRegistry) counter(name string, labels map[string]string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counters[models.SeriesKey(name, labels)]
+}
+
+func (r *selfRegistry) observations(name string, labels map[string]string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.histograms[models.SeriesKey(name, labels)]
+	if !ok {
+		return 0
+	}
+	var count uint64
+	for _, c := range h.Counts {
+		count += c
+	}
+	return count
+}
+
+func TestSelfRegistry_Snapshot(t *testing.T) {
+	r := newSelfRegistry()
+	r.inc("requests_total", map[string]string{"code": "200"})
+	r.inc("requests_total", map[string]string{"code": "200"})
+	r.inc("failures_total", nil)
+	r.observe("size", nil, []float64{1, 10}, 5)
+
+	metrics := r.snapshot()
+	require.Len(t, metrics, 3)
+
+	assert.Equal(t, "failures_total", metrics[0].ID)
+	assert.Equal(t, int64(1), *metrics[0].Delta)
+
+	assert.Equal(t, "requests_total", metrics[1].ID)
+	assert.Equal(t, common.MetricTypeCounter, metrics[1].MType)
+	assert.Equal(t, map[string]string{"code": "200"}, metrics[1].Labels)
+	assert.Equal(t, int64(2), *metrics[1].Delta)
+
+	assert.Equal(t, "size", metrics[2].ID)
+	assert.Equal(t, common.MetricTypeHistogram, metrics[2].MType)
+	assert.Equal(t, []uint64{0, 1, 0}, metrics[2].Histogram.Counts)
+
+	// The snapshot is a copy.
+	metrics[2].Histogram.Counts[0] = 100
+	assert.Equal(t, uint64(1), r.observations("size", nil))
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	r := newTestSelfMetrics(t)
+
+	store := NewMemStorage()
+	server := httptest.NewServer(newTestRouter(store, &config{}))
+	defer server.Close()
+
+	for _, path := range []string{"/update/gauge/a/1", "/update/gauge/b/2", "/update/gauge/c/bad"} {
+		resp, err := http.Post(server.URL+path, "text/plain", nil)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+	resp, err := http.Get(server.URL + "/no/such/route")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	route := "/update/{metricType}/{metricName}/{metricValue}"
+	assert.Equal(t, int64(2), r.counter(selfHTTPRequests, map[string]string{
+		selfLabelRoute: route, selfLabelMethod: http.MethodPost, selfLabelCode: "200",
+	}))
+	assert.Equal(t, int64(1), r.counter(selfHTTPRequests, map[string]string{
+		selfLabelRoute: route, selfLabelMethod: http.MethodPost, selfLabelCode: "400",
+	}))
+	assert.Equal(t, uint64(3), r.observations(selfHTTPRequestDuration, map[string]string{
+		selfLabelRoute: route, selfLabelMethod: http.MethodPost,
+	}))
+	assert.Equal(t, int64(1), r.counter(selfHTTPRequests, map[string]string{
+		selfLabelRoute: selfUnmatchedRoute, selfLabelMethod: http.MethodGet, selfLabelCode: "404",
+	}))
+}
+
+func TestMetricsInterceptor(t *testing.T) {
+	r := newTestSelfMetrics(t)
+	interceptor := metricsInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/service.Method"}
+
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	_, err = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.InvalidArgument, "bad request")
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, int64(1), r.counter(selfGRPCRequests, map[string]string{
+		selfLabelMethod: info.FullMethod, selfLabelCode: codes.OK.String(),
+	}))
+	assert.Equal(t, int64(1), r.counter(selfGRPCRequests, map[string]string{
+		selfLabelMethod: info.FullMethod, selfLabelCode: codes.InvalidArgument.String(),
+	}))
+	assert.Equal(t, uint64(2), r.observations(selfGRPCRequestDuration, map[string]string{
+		selfLabelMethod: info.FullMethod,
+	}))
+}
+
+func TestInstrumentedStorage(t *testing.T) {
+	r := newTestSelfMetrics(t)
+	store := NewInstrumentedStorage(NewMemStorage())
+	ctx := context.Background()
+
+	_, err := store.SetGauge(ctx, "g", 1)
+	require.NoError(t, err)
+	_, err = store.GetGauge(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = store.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("g", common.MetricTypeGauge, 0, 2),
+		{ID: "bad", MType: "invalid"},
+	})
+	require.Error(t, err)
+
+	setGauge := map[string]string{selfLabelStorageOperation: "SetGauge"}
+	getGauge := map[string]string{selfLabelStorageOperation: "GetGauge"}
+	batchUpdate := map[string]string{selfLabelStorageOperation: "BatchUpdate"}
+	assert.Equal(t, uint64(1), r.observations(selfStorageDuration, setGauge))
+	assert.Equal(t, uint64(1), r.observations(selfStorageDuration, getGauge))
+	assert.Equal(t, int64(0), r.counter(selfStorageErrors, getGauge), "missing metrics are not errors")
+	assert.Equal(t, int64(1), r.counter(selfStorageErrors, batchUpdate))
+	assert.Equal(t, uint64(1), r.observations(selfBatchSize, nil))
+}
+
+func TestMemStorage_DumpMetrics(t *testing.T) {
+	r := newTestSelfMetrics(t)
+
+	ms := newTestMemStorage(t, &StorageConfig{FileStoragePath: t.TempDir() + "/dump.json"})
+	require.NoError(t, ms.Dump())
+	assert.Equal(t, uint64(1), r.observations(selfDumpDuration, nil))
+
+	ms = newTestMemStorage(t, &StorageConfig{FileStoragePath: t.TempDir() + "/missing/dump.json"})
+	require.Error(t, ms.Dump())
+	assert.Equal(t, int64(1), r.counter(selfDumpFailures, nil))
+}
+
+func TestAdminRouter_Metrics(t *testing.T) {
+	r := newTestSelfMetrics(t)
+	r.inc(selfHashFailures, nil)
+
+	server := httptest.NewServer(NewAdminRouter(NewCachedStorage(NewMemStorage(), 10)))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Logf("Failed to close response body: %v", err)
+		}
+	}()
+
+	assert.Equal(t, prometheusTextContentType, resp.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(resp.Body)
+	assert.True(t, strings.HasPrefix(string(body), "# TYPE hash_failures_total counter\nhash_failures_total 1\n"), string(body))
+	assert.Contains(t, string(body), "storage_cache_entries 0\n")
+
+	resp, err = http.Get(server.URL + "/value/gauge/Alloc")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "admin listener does not serve the metrics API")
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// errSnappyCorrupt is returned for malformed snappy input.
+var errSnappyCorrupt = errors.New("snappy: corrupt input")
+
+// Tags of the snappy block format elements, in the two low bits of the tag
+// byte.
+const (
+	snappyTagLiteral = 0x00
+	snappyTagCopy1   = 0x01
+	snappyTagCopy2   = 0x02
+	snappyTagCopy4   = 0x03
+)
+
+// snappyDecode decodes src in the snappy block format, the format used by
+// Prometheus remote_write (not the framed stream format). The decoded
+// length is read from the header first, inputs that decode to more than
+// maxLen bytes are rejected before allocating.
+//
+// See https://github.com/google/snappy/blob/main/format_description.txt.
+func snappyDecode(src []byte, maxLen int) ([]byte, error) {
+	n, header := binary.Uvarint(src)
+	if header <= 0 || n > 0xffffffff {
+		return nil, errSnappyCorrupt
+	}
+	if n > uint64(maxLen) {
+		return nil, fmt.Errorf("snappy: decoded length %d exceeds %d bytes", n, maxLen)
+	}
+
+	dst := make([]byte, 0, n)
+	s := src[header:]
+	for len(s) > 0 {
+		tag := s[0]
+		var length, offset int
+		switch tag & 0x03 {
+		case snappyTagLiteral:
+			length = int(tag >> 2)
+			s = s[1:]
+			// Lengths of 60 and more are stored in the next 1-4 bytes.
+			if length >= 60 {
+				size := length - 59
+				if len(s) < size {
+					return nil, errSnappyCorrupt
+				}
+				var buf [4]byte
+				copy(buf[:], s[:size])
+				length = int(binary.LittleEndian.Uint32(buf[:]))
+				s = s[size:]
+			}
+			length++
+			if length <= 0 || length > len(s) || length > int(n)-len(dst) {
+				return nil, errSnappyCorrupt
+			}
+			dst = append(dst, s[:length]...)
+			s = s[length:]
+			continue
+		case snappyTagCopy1:
+			if len(s) < 2 {
+				return nil, errSnappyCorrupt
+			}
+			length = 4 + int(tag>>2&0x07)
+			offset = int(tag&0xe0)<<3 | int(s[1])
+			s = s[2:]
+		case snappyTagCopy2:
+			if len(s) < 3 {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(s[1:3]))
+			s = s[3:]
+		case snappyTagCopy4:
+			if len(s) < 5 {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(s[1:5]))
+			s = s[5:]
+		}
+
+		if offset <= 0 || offset > len(dst) || length > int(n)-len(dst) {
+			return nil, errSnappyCorrupt
+		}
+		// Copies may overlap their own output, e.g. offset 1 repeats a byte.
+		start := len(dst) - offset
+		for i := range length {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if len(dst) != int(n) {
+		return nil, errSnappyCorrupt
+	}
+	return dst, nil
+}
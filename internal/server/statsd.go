@@ -0,0 +1,436 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/etoneja/go-metrics/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// statsdMaxPacketSize is the largest UDP datagram.
+	statsdMaxPacketSize = 65535
+	// statsdMaxLineSize limits lines read from TCP connections.
+	statsdMaxLineSize = 64 << 10
+	// statsdCountSuffix is appended to timer names for their counters.
+	statsdCountSuffix = "_count"
+)
+
+// statsdSample is a parsed StatsD line.
+type statsdSample struct {
+	key      string
+	kind     string
+	value    float64
+	relative bool
+	rate     float64
+}
+
+// statsdGauge is the gauge value received in a flush interval. Relative
+// updates are applied to the stored value on flush.
+type statsdGauge struct {
+	value    float64
+	relative bool
+}
+
+// statsdTimer accumulates timings of a flush interval, sampled timings
+// are weighted by the inverse of their sample rate.
+type statsdTimer struct {
+	sum   float64
+	count float64
+}
+
+// StatsDServer receives StatsD lines over UDP and TCP on the same address
+// and writes them to the storage every flush interval:
+//
+//	name:1|c          counter increment, "|@0.1" sample rates are scaled up
+//	name:42|g         gauge, "+3" and "-3" change the stored value
+//	name:12|ms        timer, also "h" and "d": the mean of the interval is
+//	                  stored as gauge name, the number of timings as
+//	                  counter name_count
+//
+// DogStatsD tags like "|#host:web1" become labels. Sets are not supported.
+// Packets and connections from outside the trusted subnet are dropped.
+type StatsDServer struct {
+	store         Storager
+	subnet        *net.IPNet
+	flushInterval time.Duration
+
+	mu       sync.Mutex
+	counters map[string]int64
+	gauges   map[string]statsdGauge
+	timers   map[string]statsdTimer
+
+	udp     net.PacketConn
+	tcp     net.Listener
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	wg      sync.WaitGroup
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+	stopOnce sync.Once
+}
+
+func newStatsDServer(store Storager, subnet *net.IPNet, flushInterval time.Duration) *StatsDServer {
+	return &StatsDServer{
+		store:         store,
+		subnet:        subnet,
+		flushInterval: flushInterval,
+		counters:      make(map[string]int64),
+		gauges:        make(map[string]statsdGauge),
+		timers:        make(map[string]statsdTimer),
+		conns:         make(map[net.Conn]struct{}),
+		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+	}
+}
+
+// StartStatsDServer listens for StatsD on cfg.StatsDAddress.
+func StartStatsDServer(store Storager, cfg *config, serverErrChan chan<- error) (*StatsDServer, error) {
+	subnet, err := parseTrustedSubnet(cfg.TrustedSubnet)
+	if err != nil {
+		return nil, err
+	}
+	s := newStatsDServer(store, subnet, time.Duration(cfg.StatsDFlushInterval)*time.Second)
+
+	udp, err := net.ListenPacket("udp", cfg.StatsDAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen StatsD UDP on %s: %w", cfg.StatsDAddress, err)
+	}
+	// The UDP address has the port picked for port 0.
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		_ = udp.Close()
+		return nil, fmt.Errorf("failed to listen StatsD TCP on %s: %w", cfg.StatsDAddress, err)
+	}
+	s.udp, s.tcp = udp, tcp
+
+	logger.Get().Info("StatsD server starting", zap.String("addr", cfg.StatsDAddress))
+	s.wg.Add(2)
+	go s.serveUDP(serverErrChan)
+	go s.serveTCP(serverErrChan)
+	go s.flushLoop()
+
+	return s, nil
+}
+
+// StopStatsDServer stops receiving, closes TCP connections and flushes what
+// was received.
+func StopStatsDServer(s *StatsDServer, shutdownCtx context.Context) {
+	if s == nil {
+		return
+	}
+	logger.Get().Info("Stopping StatsD server...")
+
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+		_ = s.udp.Close()
+		_ = s.tcp.Close()
+		s.connsMu.Lock()
+		for conn := range s.conns {
+			_ = conn.Close()
+		}
+		s.connsMu.Unlock()
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		<-s.doneChan
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		logger.Get().Info("StatsD server stopped")
+	case <-shutdownCtx.Done():
+		logger.Get().Warn("StatsD server forced to stop")
+	}
+}
+
+func (s *StatsDServer) stopping() bool {
+	select {
+	case <-s.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// trusted reports whether packets from addr are accepted.
+func (s *StatsDServer) trusted(addr net.Addr) bool {
+	return trustedSource(s.subnet, addr, logger.Get())
+}
+
+func (s *StatsDServer) serveUDP(serverErrChan chan<- error) {
+	defer s.wg.Done()
+
+	buf := make([]byte, statsdMaxPacketSize)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			if s.stopping() {
+				return
+			}
+			logger.Get().Error("StatsD UDP server failed", zap.Error(err))
+			serverErrChan <- err
+			return
+		}
+		if !s.trusted(addr) {
+			continue
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			s.handleLine(line)
+		}
+	}
+}
+
+func (s *StatsDServer) serveTCP(serverErrChan chan<- error) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			if s.stopping() {
+				return
+			}
+			logger.Get().Error("StatsD TCP server failed", zap.Error(err))
+			serverErrChan <- err
+			return
+		}
+		if !s.trusted(conn.RemoteAddr()) {
+			_ = conn.Close()
+			continue
+		}
+
+		s.connsMu.Lock()
+		if s.stopping() {
+			s.connsMu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.connsMu.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+func (s *StatsDServer) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.connsMu.Lock()
+		delete(s.conns, conn)
+		s.connsMu.Unlock()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), statsdMaxLineSize)
+	for scanner.Scan() {
+		s.handleLine(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil && !s.stopping() {
+		logger.Get().Debug("StatsD connection closed",
+			zap.String("addr", conn.RemoteAddr().String()),
+			zap.Error(err))
+	}
+}
+
+func (s *StatsDServer) handleLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	sample, err := parseStatsDLine(line)
+	if err != nil {
+		logger.Get().Debug("Bad StatsD line", zap.String("line", line), zap.Error(err))
+		return
+	}
+	s.add(sample)
+}
+
+// parseStatsDLine parses "name:value|type[|@rate][|#tag:value,...]".
+func parseStatsDLine(line string) (statsdSample, error) {
+	name, rest, ok := strings.Cut(line, ":")
+	if !ok || name == "" || strings.Contains(name, " ") {
+		return statsdSample{}, fmt.Errorf("invalid metric name")
+	}
+	if err := models.ValidateID(name); err != nil {
+		return statsdSample{}, err
+	}
+	parts := strings.Split(rest, "|")
+	if len(parts) < 2 {
+		return statsdSample{}, fmt.Errorf("missing metric type")
+	}
+
+	sample := statsdSample{kind: parts[1], rate: 1}
+	var labels map[string]string
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			rate, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return statsdSample{}, fmt.Errorf("invalid sample rate %q", part)
+			}
+			sample.rate = rate
+		case strings.HasPrefix(part, "#"):
+			labels = make(map[string]string)
+			for _, tag := range strings.Split(part[1:], ",") {
+				tagName, tagValue, _ := strings.Cut(tag, ":")
+				labels[tagName] = tagValue
+			}
+			if err := models.ValidateLabels(labels); err != nil {
+				return statsdSample{}, err
+			}
+		default:
+			return statsdSample{}, fmt.Errorf("unknown field %q", part)
+		}
+	}
+	sample.key = models.SeriesKey(name, labels)
+
+	raw := parts[0]
+	switch sample.kind {
+	case "c", "ms", "h", "d":
+	case "g":
+		sample.relative = strings.HasPrefix(raw, "+") || strings.HasPrefix(raw, "-")
+	case "s":
+		return statsdSample{}, fmt.Errorf("sets are not supported")
+	default:
+		return statsdSample{}, fmt.Errorf("unknown metric type %q", sample.kind)
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return statsdSample{}, fmt.Errorf("invalid value %q", raw)
+	}
+	sample.value = value
+
+	return sample, nil
+}
+
+// add aggregates a sample into the current flush interval.
+func (s *StatsDServer) add(sample statsdSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch sample.kind {
+	case "c":
+		s.counters[sample.key] += int64(math.Round(sample.value / sample.rate))
+	case "g":
+		gauge, ok := s.gauges[sample.key]
+		if sample.relative && ok {
+			gauge.value += sample.value
+		} else {
+			gauge = statsdGauge{value: sample.value, relative: sample.relative}
+		}
+		s.gauges[sample.key] = gauge
+	default:
+		timer := s.timers[sample.key]
+		timer.sum += sample.value / sample.rate
+		timer.count += 1 / sample.rate
+		s.timers[sample.key] = timer
+	}
+}
+
+func (s *StatsDServer) flushLoop() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(context.Background())
+		case <-s.stopChan:
+			// Wait for readers, then write what they received.
+			s.wg.Wait()
+			s.flush(context.Background())
+			return
+		}
+	}
+}
+
+// flush writes the metrics aggregated since the previous flush. On a
+// storage error they are merged back and written with the next flush.
+func (s *StatsDServer) flush(ctx context.Context) {
+	s.mu.Lock()
+	counters, gauges, timers := s.counters, s.gauges, s.timers
+	s.counters = make(map[string]int64)
+	s.gauges = make(map[string]statsdGauge)
+	s.timers = make(map[string]statsdTimer)
+	s.mu.Unlock()
+
+	metrics := make([]models.MetricModel, 0, len(counters)+len(gauges)+2*len(timers))
+	for key, delta := range counters {
+		metrics = append(metrics, *newSeriesMetricModel(key, common.MetricTypeCounter, delta, 0))
+	}
+	for key, gauge := range gauges {
+		value := gauge.value
+		if gauge.relative {
+			current, err := s.store.GetGauge(ctx, key)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				logger.Get().Error("Failed to get StatsD gauge", zap.String("key", key), zap.Error(err))
+				s.requeue(counters, gauges, timers)
+				return
+			}
+			value += current
+		}
+		metrics = append(metrics, *newSeriesMetricModel(key, common.MetricTypeGauge, 0, value))
+	}
+	for key, timer := range timers {
+		id, labels := models.ParseSeriesKey(key)
+		metrics = append(metrics,
+			*newSeriesMetricModel(key, common.MetricTypeGauge, 0, timer.sum/timer.count),
+			*newSeriesMetricModel(models.SeriesKey(id+statsdCountSuffix, labels), common.MetricTypeCounter, int64(math.Round(timer.count)), 0),
+		)
+	}
+	if len(metrics) == 0 {
+		return
+	}
+
+	if _, err := s.store.BatchUpdate(ctx, metrics); err != nil {
+		logger.Get().Error("Failed to write StatsD metrics", zap.Int("metrics", len(metrics)), zap.Error(err))
+		s.requeue(counters, gauges, timers)
+		return
+	}
+	logger.Get().Debug("StatsD metrics written", zap.Int("metrics", len(metrics)))
+}
+
+// requeue merges metrics that failed to be written into the current flush
+// interval, values received since take precedence over gauges.
+func (s *StatsDServer) requeue(counters map[string]int64, gauges map[string]statsdGauge, timers map[string]statsdTimer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, delta := range counters {
+		s.counters[key] += delta
+	}
+	for key, gauge := range gauges {
+		newer, ok := s.gauges[key]
+		switch {
+		case !ok:
+			s.gauges[key] = gauge
+		case newer.relative:
+			newer.value += gauge.value
+			newer.relative = gauge.relative
+			s.gauges[key] = newer
+		}
+	}
+	for key, timer := range timers {
+		newer := s.timers[key]
+		newer.sum += timer.sum
+		newer.count += timer.count
+		s.timers[key] = newer
+	}
+}
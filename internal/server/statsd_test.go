@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatsDLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    statsdSample
+		wantErr bool
+	}{
+		{"counter", "requests:1|c", statsdSample{key: "requests", kind: "c", value: 1, rate: 1}, false},
+		{"sampled counter", "requests:1|c|@0.1", statsdSample{key: "requests", kind: "c", value: 1, rate: 0.1}, false},
+		{"gauge", "queue:42|g", statsdSample{key: "queue", kind: "g", value: 42, rate: 1}, false},
+		{"relative gauge", "queue:-3|g", statsdSample{key: "queue", kind: "g", value: -3, relative: true, rate: 1}, false},
+		{"timer", "latency:12.5|ms", statsdSample{key: "latency", kind: "ms", value: 12.5, rate: 1}, false},
+		{"tags", "requests:1|c|#host:web1,env:prod", statsdSample{key: `requests{env="prod",host="web1"}`, kind: "c", value: 1, rate: 1}, false},
+		{"no value", "requests", statsdSample{}, true},
+		{"no type", "requests:1", statsdSample{}, true},
+		{"bad value", "requests:x|c", statsdSample{}, true},
+		{"bad type", "requests:1|x", statsdSample{}, true},
+		{"set", "users:alice|s", statsdSample{}, true},
+		{"bad rate", "requests:1|c|@2", statsdSample{}, true},
+		{"bad tag", "requests:1|c|#1host:web1", statsdSample{}, true},
+		{"bad name", "req{uests:1|c", statsdSample{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample, err := parseStatsDLine(tt.line)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sample)
+		})
+	}
+}
+
+func TestStatsDServer_Flush(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	_, err := store.SetGauge(ctx, "queue", 10)
+	require.NoError(t, err)
+	s := newStatsDServer(store, nil, time.Second)
+
+	for _, line := range []string{
+		"requests:1|c",
+		"requests:2|c|@0.5",
+		"queue:+5|g",
+		"queue:-2|g",
+		"temp:20|g",
+		"temp:21|g",
+		"latency:10|ms",
+		"latency:20|ms",
+		"bad line",
+	} {
+		s.handleLine(line)
+	}
+	s.flush(ctx)
+
+	requests, err := store.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), requests)
+	queue, err := store.GetGauge(ctx, "queue")
+	require.NoError(t, err)
+	assert.Equal(t, 13.0, queue, "relative gauges change the stored value")
+	temp, err := store.GetGauge(ctx, "temp")
+	require.NoError(t, err)
+	assert.Equal(t, 21.0, temp)
+	latency, err := store.GetGauge(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, 15.0, latency)
+	latencyCount, err := store.GetCounter(ctx, "latency_count")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), latencyCount)
+
+	// Counters are increments per interval.
+	s.handleLine("requests:1|c")
+	s.flush(ctx)
+	requests, err = store.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), requests)
+}
+
+// failingBatchStorage fails batch updates while fail is set and counts
+// them in calls.
+type failingBatchStorage struct {
+	Storager
+	fail  bool
+	calls int
+}
+
+func (s *failingBatchStorage) BatchUpdate(ctx context.Context, metrics []models.MetricModel) ([]models.MetricModel, error) {
+	s.calls++
+	if s.fail {
+		return nil, errors.New("connection refused")
+	}
+	return s.Storager.BatchUpdate(ctx, metrics)
+}
+
+func TestStatsDServer_FlushErrorRequeues(t *testing.T) {
+	store := &failingBatchStorage{Storager: NewMemStorage(), fail: true}
+	ctx := context.Background()
+	s := newStatsDServer(store, nil, time.Second)
+
+	s.handleLine("requests:1|c")
+	s.handleLine("temp:20|g")
+	s.flush(ctx)
+	s.handleLine("requests:2|c")
+	s.handleLine("temp:+1|g")
+
+	store.fail = false
+	s.flush(ctx)
+	requests, err := store.GetCounter(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), requests)
+	temp, err := store.GetGauge(ctx, "temp")
+	require.NoError(t, err)
+	assert.Equal(t, 21.0, temp)
+}
+
+func TestStatsDServer_Listeners(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	s, err := StartStatsDServer(store, &config{
+		StatsDAddress:       "127.0.0.1:0",
+		StatsDFlushInterval: 3600,
+		TrustedSubnet:       "127.0.0.0/8",
+	}, make(chan error, 1))
+	require.NoError(t, err)
+	addr := s.udp.LocalAddr().String()
+
+	udp, err := net.Dial("udp", addr)
+	require.NoError(t, err)
+	defer func() { _ = udp.Close() }()
+	_, err = udp.Write([]byte("udp_requests:1|c\nudp_requests:2|c\n"))
+	require.NoError(t, err)
+
+	tcp, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	_, err = tcp.Write([]byte("tcp_queue:7|g\n"))
+	require.NoError(t, err)
+	require.NoError(t, tcp.Close())
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.counters["udp_requests"] == 3 && s.gauges["tcp_queue"].value == 7
+	}, time.Second, 10*time.Millisecond)
+
+	// Stopping flushes what was received.
+	StopStatsDServer(s, ctx)
+	requests, err := store.GetCounter(ctx, "udp_requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), requests)
+	queue, err := store.GetGauge(ctx, "tcp_queue")
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, queue)
+}
+
+func TestStatsDServer_UntrustedSource(t *testing.T) {
+	store := NewMemStorage()
+	ctx := context.Background()
+	s, err := StartStatsDServer(store, &config{
+		StatsDAddress:       "127.0.0.1:0",
+		StatsDFlushInterval: 3600,
+		TrustedSubnet:       "10.0.0.0/8",
+	}, make(chan error, 1))
+	require.NoError(t, err)
+
+	assert.False(t, s.trusted(&net.UDPAddr{IP: net.ParseIP("127.0.0.1")}))
+	assert.True(t, s.trusted(&net.UDPAddr{IP: net.ParseIP("10.1.2.3")}))
+
+	udp, err := net.Dial("udp", s.udp.LocalAddr().String())
+	require.NoError(t, err)
+	defer func() { _ = udp.Close() }()
+	_, err = udp.Write([]byte("requests:1|c"))
+	require.NoError(t, err)
+
+	StopStatsDServer(s, ctx)
+	_, err = store.GetCounter(ctx, "requests")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
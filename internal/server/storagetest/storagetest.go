@@ -0,0 +1,395 @@
+// Package storagetest provides a conformance suite for server.Storager
+// implementations.
+//
+// Every storage backend is expected to pass Run, so handlers behave the
+// same whichever backend the server is configured with.
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/etoneja/go-metrics/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty storage for a test. The suite shuts the storage
+// down when the test finishes.
+type Factory func(t *testing.T) server.Storager
+
+// Run runs the conformance suite against storages created by newStorage.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s server.Storager)
+	}{
+		{"GaugeNotFound", testGaugeNotFound},
+		{"SetGauge", testSetGauge},
+		{"CounterNotFound", testCounterNotFound},
+		{"IncrementCounter", testIncrementCounter},
+		{"Histogram", testHistogram},
+		{"HistogramBoundsMismatch", testHistogramBoundsMismatch},
+		{"Summary", testSummary},
+		{"GetAll", testGetAll},
+		{"Labels", testLabels},
+		{"BatchUpdate", testBatchUpdate},
+		{"BatchUpdateInvalidType", testBatchUpdateInvalidType},
+		{"DeleteMetric", testDeleteMetric},
+		{"ResetCounter", testResetCounter},
+		{"ExpireMetrics", testExpireMetrics},
+		{"Silences", testSilences},
+		{"Ping", testPing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorage(t)
+			defer s.ShutDown()
+			tt.test(t, s)
+		})
+	}
+}
+
+func testGaugeNotFound(t *testing.T, s server.Storager) {
+	_, err := s.GetGauge(context.Background(), "Alloc")
+	assert.ErrorIs(t, err, server.ErrNotFound)
+}
+
+func testSetGauge(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	value, err := s.SetGauge(ctx, "Alloc", 1.5)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, value)
+
+	value, err = s.SetGauge(ctx, "Alloc", -2.25)
+	require.NoError(t, err)
+	assert.Equal(t, -2.25, value)
+
+	value, err = s.GetGauge(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, -2.25, value)
+}
+
+func testCounterNotFound(t *testing.T, s server.Storager) {
+	_, err := s.GetCounter(context.Background(), "PollCount")
+	assert.ErrorIs(t, err, server.ErrNotFound)
+}
+
+func testIncrementCounter(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	value, err := s.IncrementCounter(ctx, "PollCount", 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), value)
+
+	value, err = s.IncrementCounter(ctx, "PollCount", 4)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), value, "IncrementCounter returns the accumulated value")
+
+	value, err = s.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), value)
+}
+
+func testHistogram(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	_, err := s.GetHistogram(ctx, "latency")
+	assert.ErrorIs(t, err, server.ErrNotFound)
+
+	_, err = s.UpdateHistogram(ctx, "latency", &models.Histogram{Bounds: []float64{1, 5}, Counts: []uint64{1, 0, 0}, Sum: 0.5})
+	require.NoError(t, err)
+
+	h, err := s.UpdateHistogram(ctx, "latency", &models.Histogram{Bounds: []float64{1, 5}, Counts: []uint64{1, 1, 1}, Sum: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 5}, h.Bounds)
+	assert.Equal(t, []uint64{2, 1, 1}, h.Counts)
+	assert.Equal(t, 10.5, h.Sum)
+
+	h, err = s.GetHistogram(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 1, 1}, h.Counts)
+}
+
+func testHistogramBoundsMismatch(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	_, err := s.UpdateHistogram(ctx, "latency", &models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5})
+	require.NoError(t, err)
+
+	_, err = s.UpdateHistogram(ctx, "latency", &models.Histogram{Bounds: []float64{2}, Counts: []uint64{1, 0}, Sum: 0.5})
+	assert.ErrorIs(t, err, models.ErrHistogramBoundsMismatch)
+
+	h, err := s.GetHistogram(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 0}, h.Counts, "a rejected update must not change the histogram")
+}
+
+func testSummary(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	_, err := s.GetSummary(ctx, "size")
+	assert.ErrorIs(t, err, server.ErrNotFound)
+
+	_, err = s.UpdateSummary(ctx, "size", []float64{1, 2})
+	require.NoError(t, err)
+
+	sketch, err := s.UpdateSummary(ctx, "size", []float64{3})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), sketch.Count)
+	assert.Equal(t, 6.0, sketch.Sum)
+
+	sketch, err = s.GetSummary(ctx, "size")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), sketch.Count)
+	assert.InDelta(t, 2, sketch.Quantile(0.5), 2*models.SketchRelativeAccuracy)
+}
+
+func testGetAll(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	metrics, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, metrics)
+
+	_, err = s.SetGauge(ctx, "b", 1)
+	require.NoError(t, err)
+	_, err = s.IncrementCounter(ctx, "a", 2)
+	require.NoError(t, err)
+	_, err = s.UpdateHistogram(ctx, "c", &models.Histogram{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5})
+	require.NoError(t, err)
+	_, err = s.UpdateSummary(ctx, "d", []float64{1})
+	require.NoError(t, err)
+
+	metrics, err = s.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, metrics, 4)
+
+	assert.Equal(t, "a", metrics[0].ID)
+	assert.Equal(t, common.MetricTypeCounter, metrics[0].MType)
+	assert.Equal(t, int64(2), *metrics[0].Delta)
+
+	assert.Equal(t, "b", metrics[1].ID)
+	assert.Equal(t, common.MetricTypeGauge, metrics[1].MType)
+	assert.Equal(t, 1.0, *metrics[1].Value)
+
+	assert.Equal(t, "c", metrics[2].ID)
+	assert.Equal(t, common.MetricTypeHistogram, metrics[2].MType)
+	assert.Equal(t, []uint64{1, 0}, metrics[2].Histogram.Counts)
+
+	assert.Equal(t, "d", metrics[3].ID)
+	assert.Equal(t, common.MetricTypeSummary, metrics[3].MType)
+	require.NotNil(t, metrics[3].Summary)
+	assert.Equal(t, uint64(1), metrics[3].Summary.Count)
+
+	for _, m := range metrics {
+		assert.NotNil(t, m.UpdatedAt, "%s %s has no update time", m.MType, m.ID)
+	}
+}
+
+func testLabels(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	hostA := models.SeriesKey("requests", map[string]string{"host": "a"})
+	hostB := models.SeriesKey("requests", map[string]string{"host": "b"})
+
+	_, err := s.IncrementCounter(ctx, hostB, 2)
+	require.NoError(t, err)
+	_, err = s.IncrementCounter(ctx, hostA, 1)
+	require.NoError(t, err)
+	_, err = s.IncrementCounter(ctx, "requests", 5)
+	require.NoError(t, err)
+
+	value, err := s.GetCounter(ctx, hostA)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+
+	metrics, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+	assert.Empty(t, metrics[0].Labels)
+	assert.Equal(t, map[string]string{"host": "a"}, metrics[1].Labels)
+	assert.Equal(t, map[string]string{"host": "b"}, metrics[2].Labels)
+	for _, m := range metrics {
+		assert.Equal(t, "requests", m.ID)
+	}
+}
+
+func testBatchUpdate(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	_, err := s.IncrementCounter(ctx, "PollCount", 10)
+	require.NoError(t, err)
+
+	labeled := models.NewMetricModel("requests", common.MetricTypeCounter, 1, 0)
+	labeled.Labels = map[string]string{"host": "a"}
+
+	result, err := s.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("PollCount", common.MetricTypeCounter, 1, 0),
+		*models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 2.5),
+		*models.NewMetricModel("PollCount", common.MetricTypeCounter, 2, 0),
+		*labeled,
+		*models.NewHistogramMetricModel("latency", &models.Histogram{Bounds: []float64{1}, Counts: []uint64{0, 1}, Sum: 2}),
+	})
+	require.NoError(t, err)
+	require.Len(t, result, 5)
+
+	// Results follow the request order and hold the stored state, so
+	// counters carry the accumulated value rather than the delta.
+	assert.Equal(t, "PollCount", result[0].ID)
+	assert.Equal(t, int64(11), *result[0].Delta)
+	assert.Equal(t, "Alloc", result[1].ID)
+	assert.Equal(t, 2.5, *result[1].Value)
+	assert.Equal(t, "PollCount", result[2].ID)
+	assert.Equal(t, int64(13), *result[2].Delta)
+	assert.Equal(t, "requests", result[3].ID)
+	assert.Equal(t, map[string]string{"host": "a"}, result[3].Labels)
+	assert.Equal(t, int64(1), *result[3].Delta)
+	assert.Equal(t, "latency", result[4].ID)
+	assert.Equal(t, []uint64{0, 1}, result[4].Histogram.Counts)
+
+	value, err := s.GetCounter(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), value)
+}
+
+func testBatchUpdateInvalidType(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	_, err := s.BatchUpdate(ctx, []models.MetricModel{
+		*models.NewMetricModel("PollCount", common.MetricTypeCounter, 1, 0),
+		{ID: "bad", MType: "invalid"},
+	})
+	require.Error(t, err)
+
+	_, err = s.GetCounter(ctx, "PollCount")
+	assert.ErrorIs(t, err, server.ErrNotFound, "a failed batch must not store any metric")
+}
+
+func testDeleteMetric(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	err := s.DeleteMetric(ctx, common.MetricTypeGauge, "Alloc")
+	assert.ErrorIs(t, err, server.ErrNotFound)
+
+	_, err = s.SetGauge(ctx, "Alloc", 1)
+	require.NoError(t, err)
+	_, err = s.IncrementCounter(ctx, "Alloc", 1)
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteMetric(ctx, common.MetricTypeGauge, "Alloc"))
+
+	_, err = s.GetGauge(ctx, "Alloc")
+	assert.ErrorIs(t, err, server.ErrNotFound)
+
+	value, err := s.GetCounter(ctx, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value, "deleting a gauge keeps the counter with the same name")
+}
+
+func testResetCounter(t *testing.T, s server.Storager) {
+	ctx := context.Background()
+
+	err := s.ResetCounter(ctx, "PollCount")
+	assert.ErrorIs(t, err, server.ErrNotFound)
+
+	_, err = s.IncrementCounter(ctx, "PollCount", 5)
+	require.NoError(t, err)
+	require.NoError(t, s.ResetCounter(ctx, "PollCount"))
+
+	value, err := s.IncrementCounter(ctx, "PollCount", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+}
+
+func testExpireMetrics(t *testing.T, s server.Storager) {
+	expirer, ok := s.(server.MetricExpirer)
+	if !ok {
+		t.Skip("storage does not expire metrics")
+	}
+	ctx := context.Background()
+
+	_, err := s.SetGauge(ctx, "stale", 1)
+	require.NoError(t, err)
+
+	// Storages may keep timestamps with a coarser resolution than time.Now.
+	time.Sleep(10 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = s.SetGauge(ctx, "fresh", 1)
+	require.NoError(t, err)
+
+	expired, err := expirer.ExpireMetrics(ctx, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	_, err = s.GetGauge(ctx, "stale")
+	assert.ErrorIs(t, err, server.ErrNotFound)
+	_, err = s.GetGauge(ctx, "fresh")
+	assert.NoError(t, err)
+}
+
+func testSilences(t *testing.T, s server.Storager) {
+	silenceStorer, ok := s.(server.SilenceStorer)
+	if !ok {
+		t.Skip("storage does not keep silences")
+	}
+	ctx := context.Background()
+
+	silences, err := silenceStorer.GetSilences(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, silences)
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	silence := models.Silence{
+		ID:       "b",
+		MetricID: "HeapAlloc",
+		MType:    common.MetricTypeGauge,
+		StartsAt: start,
+		EndsAt:   start.Add(time.Hour),
+		Author:   "alice",
+		Comment:  "load test",
+	}
+	require.NoError(t, silenceStorer.SaveSilence(ctx, silence))
+	require.NoError(t, silenceStorer.SaveSilence(ctx, models.Silence{
+		ID:       "a",
+		MetricID: "PollCount",
+		StartsAt: start,
+		EndsAt:   start.Add(time.Minute),
+		Author:   "bob",
+	}))
+
+	silence.Comment = "extended"
+	silence.EndsAt = start.Add(2 * time.Hour)
+	require.NoError(t, silenceStorer.SaveSilence(ctx, silence))
+
+	silences, err = silenceStorer.GetSilences(ctx)
+	require.NoError(t, err)
+	require.Len(t, silences, 2)
+	assert.Equal(t, "a", silences[0].ID)
+	assert.Equal(t, "b", silences[1].ID)
+	assert.Equal(t, "extended", silences[1].Comment)
+	assert.True(t, silences[1].EndsAt.Equal(start.Add(2*time.Hour)))
+
+	require.NoError(t, silenceStorer.DeleteSilence(ctx, "a"))
+	assert.ErrorIs(t, silenceStorer.DeleteSilence(ctx, "a"), server.ErrNotFound)
+
+	silences, err = silenceStorer.GetSilences(ctx)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	assert.Equal(t, "b", silences[0].ID)
+}
+
+func testPing(t *testing.T, s server.Storager) {
+	require.NoError(t, s.Ping(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, s.Ping(ctx))
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 
@@ -52,3 +53,39 @@ func (bmw *BaseMiddleware) TrustedIPMiddleware(allowedSubnet string) func(http.H
 		return http.HandlerFunc(fn)
 	}
 }
+
+// parseTrustedSubnet parses the trusted subnet setting, nil means every
+// source is trusted.
+func parseTrustedSubnet(allowedSubnet string) (*net.IPNet, error) {
+	if allowedSubnet == "" {
+		return nil, nil
+	}
+	_, subnet, err := net.ParseCIDR(allowedSubnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted subnet format '%s': %w", allowedSubnet, err)
+	}
+	return subnet, nil
+}
+
+// trustedSource reports whether packets and connections of listeners other
+// than HTTP and gRPC are accepted from addr. Unlike requests through a proxy
+// they carry no X-Real-IP, so the source address is checked.
+func trustedSource(subnet *net.IPNet, addr net.Addr, logger *zap.Logger) bool {
+	if subnet == nil {
+		return true
+	}
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		ip = a.IP
+	case *net.TCPAddr:
+		ip = a.IP
+	}
+	if ip == nil || !subnet.Contains(ip) {
+		logger.Warn("Source address not in allowed subnet",
+			zap.String("addr", addr.String()),
+			zap.String("subnet", subnet.String()))
+		return false
+	}
+	return true
+}
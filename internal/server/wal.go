@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/logger"
+	"github.com/etoneja/go-metrics/internal/models"
+	"go.uber.org/zap"
+)
+
+// walDumpInterval is the snapshot period (seconds) used with a WAL when
+// StoreInterval is 0. Writes are durable through the WAL, snapshots only
+// keep it short.
+const walDumpInterval = 300
+
+const (
+	walOpUpdate = "update"
+	walOpDelete = "delete"
+	walOpExpire = "expire"
+
+	walOpSilence   = "silence"
+	walOpUnsilence = "unsilence"
+)
+
+// walRecord is a write logged to the WAL. Updates hold the resulting state
+// of the metrics rather than the change, so replaying records that are
+// already included in the snapshot is harmless. Time is the time of the
+// write, for expiry it is the cutoff. Removed silences are logged with
+// their ID as Key.
+type walRecord struct {
+	Op      string               `json:"op"`
+	Time    time.Time            `json:"ts"`
+	Metrics []models.MetricModel `json:"metrics,omitempty"`
+	MType   string               `json:"type,omitempty"`
+	Key     string               `json:"key,omitempty"`
+	Silence *models.Silence      `json:"silence,omitempty"`
+}
+
+func newWALUpdate(now time.Time, metrics ...models.MetricModel) *walRecord {
+	return &walRecord{Op: walOpUpdate, Time: now, Metrics: metrics}
+}
+
+// wal is an append-only log of MemStorage writes made since the last dump.
+type wal struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func openWAL(path string) (*wal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wal: %w", err)
+	}
+	return &wal{path: path, file: file}, nil
+}
+
+// append writes the record and syncs it to disk.
+func (w *wal) append(rec *walRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode wal record: %w", err)
+	}
+	data = append(data, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err = w.file.Write(data)
+	if err != nil {
+		return fmt.Errorf("failed to write wal: %w", err)
+	}
+	err = w.file.Sync()
+	if err != nil {
+		return fmt.Errorf("failed to sync wal: %w", err)
+	}
+	return nil
+}
+
+// replay calls apply for each record in the log. A partially written last
+// record, left by a crash during append, is cut off so later appends start
+// on a record boundary. On error the log is left as it is.
+func (w *wal) replay(apply func(*walRecord) error) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := w.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, fmt.Errorf("failed to seek wal: %w", err)
+	}
+
+	reader := bufio.NewReader(w.file)
+	count := 0
+	// offset is the end of the last complete record.
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				err = w.cut(offset)
+				if err != nil {
+					return count, err
+				}
+			}
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to read wal: %w", err)
+		}
+
+		var rec walRecord
+		err = json.Unmarshal(line, &rec)
+		if err != nil {
+			return count, fmt.Errorf("failed to decode wal record %d: %w", count+1, err)
+		}
+		err = apply(&rec)
+		if err != nil {
+			return count, fmt.Errorf("failed to apply wal record %d: %w", count+1, err)
+		}
+		offset += int64(len(line))
+		count++
+	}
+}
+
+// cut truncates the log to size bytes, the caller must hold w.mu.
+func (w *wal) cut(size int64) error {
+	err := w.file.Truncate(size)
+	if err != nil {
+		return fmt.Errorf("failed to truncate wal: %w", err)
+	}
+	err = w.file.Sync()
+	if err != nil {
+		return fmt.Errorf("failed to sync wal: %w", err)
+	}
+	return nil
+}
+
+// moveAside renames a log that failed to replay for inspection and starts
+// an empty one in its place. It returns the new path of the old log.
+func (w *wal) moveAside(now time.Time) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	aside := fmt.Sprintf("%s.corrupt-%d", w.path, now.UnixNano())
+	err := os.Rename(w.path, aside)
+	if err != nil {
+		return "", fmt.Errorf("failed to move wal aside: %w", err)
+	}
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("failed to open wal: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		logger.Get().Warn("Failed to close WAL moved aside", zap.Error(err))
+	}
+	w.file = file
+	return aside, nil
+}
+
+// truncate empties the log once its records are included in a dump.
+func (w *wal) truncate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.cut(0)
+}
+
+func (w *wal) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/etoneja/go-metrics/internal/common"
+	"github.com/etoneja/go-metrics/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAL_AppendReplayTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.wal")
+	w, err := openWAL(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, w.close())
+	}()
+
+	now := time.Now().UTC()
+	require.NoError(t, w.append(newWALUpdate(now, *models.NewMetricModel("PollCount", common.MetricTypeCounter, 5, 0))))
+	require.NoError(t, w.append(&walRecord{Op: walOpDelete, Time: now, MType: common.MetricTypeGauge, Key: "Alloc"}))
+
+	var records []*walRecord
+	count, err := w.replay(func(rec *walRecord) error {
+		records = append(records, rec)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, records, 2)
+	assert.Equal(t, walOpUpdate, records[0].Op)
+	assert.True(t, now.Equal(records[0].Time))
+	assert.Equal(t, int64(5), *records[0].Metrics[0].Delta)
+	assert.Equal(t, "Alloc", records[1].Key)
+
+	require.NoError(t, w.truncate())
+	require.NoError(t, w.append(newWALUpdate(now, *models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 1))))
+
+	count, err = w.replay(func(rec *walRecord) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestWAL_ReplaySkipsTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.wal")
+	w, err := openWAL(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, w.close())
+	}()
+
+	require.NoError(t, w.append(newWALUpdate(time.Now(), *models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 1))))
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"op":"update","ts":`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	count, err := w.replay(func(rec *walRecord) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestWAL_AppendAfterTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.wal")
+	require.NoError(t, os.WriteFile(path, []byte(`{"op":"update","ts":`), 0o644))
+
+	w, err := openWAL(path)
+	require.NoError(t, err)
+	count, err := w.replay(func(rec *walRecord) error { return nil })
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	require.NoError(t, w.append(newWALUpdate(time.Now(), *models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 1))))
+	require.NoError(t, w.close())
+
+	// Crash before the next dump: the appended record must still replay.
+	w, err = openWAL(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, w.close())
+	}()
+	var records []*walRecord
+	count, err = w.replay(func(rec *walRecord) error {
+		records = append(records, rec)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Alloc", records[0].Metrics[0].ID)
+}
+
+func TestWAL_ReplayErrorKeepsLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.wal")
+	w, err := openWAL(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, w.close())
+	}()
+
+	require.NoError(t, w.append(newWALUpdate(time.Now(), *models.NewMetricModel("Alloc", common.MetricTypeGauge, 0, 1))))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString("not json\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, w.append(newWALUpdate(time.Now(), *models.NewMetricModel("Frees", common.MetricTypeGauge, 0, 2))))
+	logged, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	count, err := w.replay(func(rec *walRecord) error { return nil })
+	assert.ErrorContains(t, err, "failed to decode wal record 2")
+	assert.Equal(t, 1, count)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, logged, data)
+
+	aside, err := w.moveAside(time.Now())
+	require.NoError(t, err)
+	data, err = os.ReadFile(aside)
+	require.NoError(t, err)
+	assert.Equal(t, logged, data)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}